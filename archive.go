@@ -0,0 +1,84 @@
+package resource
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// downloadArchive downloads the tarball of a commit and extracts it to dir.
+func downloadArchive(github Github, commitRef, dir string) error {
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(github.DownloadArchive(commitRef, w))
+	}()
+	defer r.Close()
+
+	if err := extractTarball(r, dir); err != nil {
+		return fmt.Errorf("failed to extract archive: %w", err)
+	}
+	return nil
+}
+
+// extractTarball extracts a gzipped tarball to dir, stripping the top-level directory
+// (which is named after the repository and commit).
+func extractTarball(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		parts := strings.SplitN(header.Name, "/", 2)
+		if len(parts) < 2 || parts[1] == "" {
+			continue
+		}
+		name := filepath.Join(dir, filepath.FromSlash(parts[1]))
+		if !strings.HasPrefix(name, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(name, os.ModePerm); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, archive); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, name); err != nil {
+				return err
+			}
+		}
+	}
+}
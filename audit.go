@@ -0,0 +1,154 @@
+package resource
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// auditGithub records the mutations made to pull requests as JSON lines. Comment and issue
+// bodies are recorded by their hash and length, so that the log can be kept without
+// leaking the (possibly sensitive) output of builds.
+type auditGithub struct {
+	Github
+	out    io.Writer
+	dryRun bool
+
+	mu sync.Mutex
+}
+
+// openAuditLog opens the audit log for appending, so that the puts of a build (e.g. in
+// each of its hooks) can share a log.
+func openAuditLog(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
+
+// digest of a body which is recorded in the audit log instead of the body itself.
+func digest(body string) map[string]interface{} {
+	sum := sha256.Sum256([]byte(body))
+	return map[string]interface{}{"sha256": hex.EncodeToString(sum[:]), "length": len(body)}
+}
+
+// record an action and its outcome in the audit log. Failing to write the log fails the
+// action, as the log would otherwise be incomplete.
+func (a *auditGithub) record(action string, err error, fields map[string]interface{}) error {
+	entry := map[string]interface{}{
+		"time":   time.Now().UTC().Format(time.RFC3339Nano),
+		"action": action,
+	}
+	for k, v := range fields {
+		entry[k] = v
+	}
+	if a.dryRun {
+		entry["dry_run"] = true
+	}
+	if err != nil {
+		entry["error"] = err.Error()
+	}
+	b, merr := json.Marshal(entry)
+	if merr != nil {
+		return merr
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, werr := a.out.Write(append(b, '\n')); werr != nil && err == nil {
+		err = werr
+	}
+	return err
+}
+
+func (a *auditGithub) PostComment(prNumber, comment string) error {
+	err := a.Github.PostComment(prNumber, comment)
+	return a.record("post_comment", err, map[string]interface{}{"pr": prNumber, "comment": digest(comment)})
+}
+
+func (a *auditGithub) UpdateComment(commentID, comment string) error {
+	err := a.Github.UpdateComment(commentID, comment)
+	return a.record("update_comment", err, map[string]interface{}{"comment_id": commentID, "comment": digest(comment)})
+}
+
+func (a *auditGithub) UpdateCommitStatus(commitRef, baseContext, statusContext, status, targetURL, description string) error {
+	err := a.Github.UpdateCommitStatus(commitRef, baseContext, statusContext, status, targetURL, description)
+	return a.record("set_status", err, map[string]interface{}{
+		"commit":       commitRef,
+		"base_context": baseContext,
+		"context":      statusContext,
+		"status":       status,
+		"target_url":   targetURL,
+		"description":  description,
+	})
+}
+
+func (a *auditGithub) DeletePreviousComments(prNumber, marker string, before time.Time) error {
+	err := a.Github.DeletePreviousComments(prNumber, marker, before)
+	fields := map[string]interface{}{"pr": prNumber, "marker": marker}
+	if !before.IsZero() {
+		fields["before"] = before.UTC().Format(time.RFC3339)
+	}
+	return a.record("delete_previous_comments", err, fields)
+}
+
+func (a *auditGithub) MinimizePreviousComments(prNumber, marker, classifier string) error {
+	err := a.Github.MinimizePreviousComments(prNumber, marker, classifier)
+	return a.record("minimize_previous_comments", err, map[string]interface{}{"pr": prNumber, "marker": marker, "classifier": classifier})
+}
+
+func (a *auditGithub) AddReaction(prNumber, commentID, reaction string) error {
+	err := a.Github.AddReaction(prNumber, commentID, reaction)
+	return a.record("add_reaction", err, map[string]interface{}{"pr": prNumber, "comment_id": commentID, "reaction": reaction})
+}
+
+func (a *auditGithub) DismissStaleReviews(prNumber, commitRef, message string) error {
+	err := a.Github.DismissStaleReviews(prNumber, commitRef, message)
+	return a.record("dismiss_stale_reviews", err, map[string]interface{}{"pr": prNumber, "commit": commitRef, "message": message})
+}
+
+func (a *auditGithub) CreateOrUpdateIssue(title, body string, labels []string) error {
+	err := a.Github.CreateOrUpdateIssue(title, body, labels)
+	return a.record("create_or_update_issue", err, map[string]interface{}{"title": title, "body": digest(body), "labels": labels})
+}
+
+func (a *auditGithub) CreateGist(description string, files map[string]string) (string, error) {
+	url, err := a.Github.CreateGist(description, files)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return url, a.record("create_gist", err, map[string]interface{}{"description": description, "files": names, "url": url})
+}
+
+func (a *auditGithub) RerequestCheckSuites(commitRef string) error {
+	err := a.Github.RerequestCheckSuites(commitRef)
+	return a.record("rerequest_check_suites", err, map[string]interface{}{"commit": commitRef})
+}
+
+func (a *auditGithub) SetLocked(prNumber string, locked bool, reason string) error {
+	err := a.Github.SetLocked(prNumber, locked, reason)
+	return a.record("set_locked", err, map[string]interface{}{"pr": prNumber, "locked": locked, "reason": reason})
+}
+
+func (a *auditGithub) AddLabels(prNumber string, labels []string) error {
+	err := a.Github.AddLabels(prNumber, labels)
+	return a.record("add_labels", err, map[string]interface{}{"pr": prNumber, "labels": labels})
+}
+
+func (a *auditGithub) RemoveLabel(prNumber string, label string) error {
+	err := a.Github.RemoveLabel(prNumber, label)
+	return a.record("remove_label", err, map[string]interface{}{"pr": prNumber, "label": label})
+}
+
+func (a *auditGithub) ClosePullRequest(prNumber string) error {
+	err := a.Github.ClosePullRequest(prNumber)
+	return a.record("close_pull_request", err, map[string]interface{}{"pr": prNumber})
+}
@@ -0,0 +1,279 @@
+package resource
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheMaxAge is how long a cached response is kept after it was last used.
+const cacheMaxAge = 7 * 24 * time.Hour
+
+// cacheMaxSize is the size of the largest response body which is cached.
+const cacheMaxSize = 1 << 20
+
+// CacheTransport caches responses to GET requests on disk, and revalidates them
+// using conditional requests (If-None-Match). Github does not count responses
+// with status 304 Not Modified against the rate limit. Only JSON responses of at
+// most 1MiB are cached, so that e.g. archives are never kept in memory or on disk.
+type CacheTransport struct {
+	base      http.RoundTripper
+	dir       string
+	namespace string
+}
+
+// NewCacheTransport returns a transport which caches responses in the given
+// directory. The namespace (e.g. the access token) is hashed into the cache
+// keys, so that responses are never shared between different credentials.
+// Responses which have not been used for a week are removed from the directory.
+func NewCacheTransport(base http.RoundTripper, dir, namespace string) *CacheTransport {
+	t := &CacheTransport{
+		base:      base,
+		dir:       dir,
+		namespace: namespace,
+	}
+	if err := t.prune(time.Now().Add(-cacheMaxAge)); err != nil {
+		logger.Warn("failed to prune cache", "error", err)
+	}
+	return t
+}
+
+// prune removes the cached responses (and leftover temporary files) last used before the given time.
+func (t *CacheTransport) prune(before time.Time) error {
+	files, err := ioutil.ReadDir(t.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.Mode().IsRegular() && f.ModTime().Before(before) {
+			if err := os.Remove(filepath.Join(t.dir, f.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || isArchive(req) {
+		return t.base.RoundTrip(req)
+	}
+	path := filepath.Join(t.dir, t.key(req))
+
+	cached, err := t.read(path, req)
+	if err == nil {
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			r := req.Clone(req.Context())
+			r.Header.Set("If-None-Match", etag)
+			req = r
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		logger.Debug("using cached response", "url", req.URL.String())
+		resp.Body.Close()
+		now := time.Now()
+		if err := os.Chtimes(path, now, now); err != nil {
+			logger.Warn("failed to update cached response", "error", err)
+		}
+		return cached, nil
+	}
+	if cached != nil {
+		cached.Body.Close()
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == "" || !isJSON(resp) || resp.ContentLength > cacheMaxSize {
+		return resp, nil
+	}
+
+	// The content length is unknown for chunked responses, so read at most one byte
+	// more than is cached, and pass on the rest of larger responses as is.
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, cacheMaxSize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > cacheMaxSize {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeAtomic(t.dir, path, dump); err != nil {
+		logger.Warn("failed to write response to cache", "error", err)
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+}
+
+// isArchive returns true for requests of repository archives (tarballs and zipballs),
+// which are downloaded once and too large to cache.
+func isArchive(req *http.Request) bool {
+	return strings.Contains(req.URL.Path, "/tarball/") || strings.Contains(req.URL.Path, "/zipball/")
+}
+
+// isJSON returns true for responses with a JSON media type (e.g. application/vnd.github.v3+json).
+func isJSON(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// key for the request, which depends on the headers that change the response.
+func (t *CacheTransport) key(req *http.Request) string {
+	h := sha256.New()
+	for _, s := range []string{t.namespace, req.URL.String(), req.Header.Get("Accept")} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *CacheTransport) read(path string, req *http.Request) (*http.Response, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), req)
+}
+
+// writeAtomic writes a file through a temporary file in the same directory, so that concurrent
+// invocations never read partial files or write to the same temporary file.
+func writeAtomic(dir, path string, b []byte) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// DefaultQueryCacheDir is where PullRequestCache persists pull requests by default.
+const DefaultQueryCacheDir = "/tmp/github-pr-resource-cache"
+
+// PullRequestCache keeps the pull requests listed by check for a while, in memory and on
+// disk, so that a get which immediately follows the check reuses them instead of querying
+// the API again. Pull requests are keyed by their number (i.e. the variables of the query
+// of get), replaced whenever they are listed again, and only used for the head commit they
+// were listed with, so that neither a new head nor an update listed since is hidden.
+type PullRequestCache struct {
+	dir       string
+	namespace string
+	ttl       time.Duration
+
+	mu      sync.Mutex
+	entries map[string]pullRequestCacheEntry
+}
+
+type pullRequestCacheEntry struct {
+	Stored      time.Time    `json:"stored"`
+	PullRequest *PullRequest `json:"pull_request"`
+}
+
+// NewPullRequestCache returns a cache which persists pull requests in the given directory
+// for the given time. The namespace (e.g. the repository and access token) is hashed into
+// the cache keys, so that pull requests are never shared between repositories or credentials.
+func NewPullRequestCache(dir, namespace string, ttl time.Duration) *PullRequestCache {
+	return &PullRequestCache{
+		dir:       dir,
+		namespace: namespace,
+		ttl:       ttl,
+		entries:   make(map[string]pullRequestCacheEntry),
+	}
+}
+
+// Put the pull request into the cache, replacing the pull request with the same number.
+func (c *PullRequestCache) Put(p *PullRequest) {
+	if c == nil {
+		return
+	}
+	entry := pullRequestCacheEntry{Stored: time.Now(), PullRequest: p}
+	c.mu.Lock()
+	c.entries[c.key(p.Number)] = entry
+	c.mu.Unlock()
+
+	b, err := json.Marshal(entry)
+	if err == nil {
+		err = writeAtomic(c.dir, filepath.Join(c.dir, c.key(p.Number)), b)
+	}
+	if err != nil {
+		logger.Warn("failed to write pull request to cache", "pr", p.Number, "error", err)
+	}
+}
+
+// Get the pull request with the given number from the cache, if it was cached within the
+// TTL and its head is the given commit.
+func (c *PullRequestCache) Get(number int, commit string) (*PullRequest, bool) {
+	if c == nil {
+		return nil, false
+	}
+	key := c.key(number)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		b, err := ioutil.ReadFile(filepath.Join(c.dir, key))
+		if err != nil || json.Unmarshal(b, &entry) != nil || entry.PullRequest == nil {
+			return nil, false
+		}
+	}
+	if time.Since(entry.Stored) > c.ttl || entry.PullRequest.Tip.OID != commit {
+		return nil, false
+	}
+	p := *entry.PullRequest
+	return &p, true
+}
+
+func (c *PullRequestCache) key(number int) string {
+	h := sha256.New()
+	for _, s := range []string{c.namespace, strconv.Itoa(number)} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return "pr-" + hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,156 @@
+package resource_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resource "github.com/telia-oss/github-pr-resource"
+)
+
+func TestCacheTransport(t *testing.T) {
+	tests := []struct {
+		description      string
+		method           string
+		path             string
+		etag             string
+		contentType      string
+		body             string
+		requests         int
+		expectedModified int
+		expectedCached   int
+	}{
+		{
+			description:      "revalidates cached responses",
+			method:           http.MethodGet,
+			etag:             `"abc"`,
+			contentType:      "application/json; charset=utf-8",
+			body:             `["files"]`,
+			requests:         3,
+			expectedModified: 1,
+			expectedCached:   1,
+		},
+		{
+			description:      "caches responses with a vendor json media type",
+			method:           http.MethodGet,
+			etag:             `"abc"`,
+			contentType:      "application/vnd.github.v3.diff+json",
+			body:             `["files"]`,
+			requests:         3,
+			expectedModified: 1,
+			expectedCached:   1,
+		},
+		{
+			description:      "does not cache responses without an etag",
+			method:           http.MethodGet,
+			contentType:      "application/json",
+			body:             `["files"]`,
+			requests:         3,
+			expectedModified: 3,
+		},
+		{
+			description:      "does not cache other methods",
+			method:           http.MethodPost,
+			etag:             `"abc"`,
+			contentType:      "application/json",
+			body:             `["files"]`,
+			requests:         3,
+			expectedModified: 3,
+		},
+		{
+			description:      "does not cache other content types",
+			method:           http.MethodGet,
+			etag:             `"abc"`,
+			contentType:      "application/x-gzip",
+			body:             "files",
+			requests:         3,
+			expectedModified: 3,
+		},
+		{
+			description:      "does not cache large responses",
+			method:           http.MethodGet,
+			etag:             `"abc"`,
+			contentType:      "application/json",
+			body:             `"` + strings.Repeat("a", 2<<20) + `"`,
+			requests:         3,
+			expectedModified: 3,
+		},
+		{
+			description:      "does not cache archives",
+			method:           http.MethodGet,
+			path:             "/repos/itsdalmo/test-repository/tarball/oid",
+			etag:             `"abc"`,
+			contentType:      "application/json",
+			body:             `["files"]`,
+			requests:         3,
+			expectedModified: 3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var modified int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.etag != "" && r.Header.Get("If-None-Match") == tc.etag {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				modified++
+				if tc.etag != "" {
+					w.Header().Set("ETag", tc.etag)
+				}
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			client := &http.Client{Transport: resource.NewCacheTransport(http.DefaultTransport, dir, "token")}
+			for i := 0; i < tc.requests; i++ {
+				req, err := http.NewRequest(tc.method, server.URL+tc.path, nil)
+				require.NoError(t, err)
+				resp, err := client.Do(req)
+				require.NoError(t, err)
+				body, err := ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, tc.body, string(body))
+			}
+			assert.Equal(t, tc.expectedModified, modified)
+
+			cached, err := ioutil.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedCached, len(cached))
+		})
+	}
+}
+
+func TestCacheTransportPrunesUnusedResponses(t *testing.T) {
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	stale := filepath.Join(dir, "stale")
+	recent := filepath.Join(dir, "recent")
+	for _, path := range []string{stale, recent} {
+		require.NoError(t, ioutil.WriteFile(path, []byte("response"), 0600))
+	}
+	old := time.Now().Add(-8 * 24 * time.Hour)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	resource.NewCacheTransport(http.DefaultTransport, dir, "token")
+
+	_, err := os.Stat(stale)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(recent)
+	assert.NoError(t, err)
+}
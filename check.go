@@ -1,11 +1,15 @@
 package resource
 
 import (
+	"errors"
 	"fmt"
-	"path/filepath"
+	"path"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/shurcooL/githubv4"
 )
@@ -13,6 +17,7 @@ import (
 // Check (business logic)
 func Check(request CheckRequest, manager Github) (CheckResponse, error) {
 	var response CheckResponse
+	start := time.Now()
 
 	// Filter out pull request if it does not have a filtered state
 	filterStates := []githubv4.PullRequestState{githubv4.PullRequestStateOpen}
@@ -20,41 +25,157 @@ func Check(request CheckRequest, manager Github) (CheckResponse, error) {
 		filterStates = request.Source.States
 	}
 
-	pulls, err := manager.ListPullRequests(filterStates)
+	// Modified files are listed together with the pull requests when filtering on paths,
+	// which saves a request per pull request unless they modify more than 100 files.
+	// The paths can also be configured by the trigger configuration of the repository.
+	filterPaths := len(request.Source.Paths) > 0 || len(request.Source.IgnorePaths) > 0 || len(request.Source.RequirePaths) > 0 || len(request.Source.Components) > 0 || request.Source.TriggerConfig != ""
+
+	// Modified files are cached between checks when a cache directory is configured.
+	var state *CheckState
+	if request.Source.CacheDir != "" {
+		state = LoadCheckState(request.Source.CacheDir, request.Source.Repository)
+	}
+
+	var pulls []*PullRequest
+	var err error
+	if request.Source.PagesPerCheck > 0 {
+		pulls, err = listPullRequestPages(manager, state, filterStates, filterPaths, request.Source.PagesPerCheck, start)
+	} else {
+		pulls, err = manager.ListPullRequests(filterStates, filterPaths)
+	}
+	if errors.Is(err, ErrAPIBudgetExhausted) {
+		logger.Warn("api budget exhausted, skipping the check", "error", err)
+		if request.Version.PR != "" {
+			response = append(response, request.Version)
+		}
+		return response, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get last commits: %s", err)
+		return nil, fmt.Errorf("failed to get last commits: %w", err)
+	}
+	logger.Debug("listed pull requests", "count", len(pulls), "states", filterStates)
+
+	// Versions stored by older releases of the resource can lack fields, which match any
+	// value. Without a date, the version is as recent as the update of the commit it refers
+	// to, instead of every pull request being newer than it.
+	since := request.Version.CommittedDate
+	if request.Version.PR != "" && since.IsZero() {
+		for _, p := range pulls {
+			if NewVersion(p).Matches(request.Version) {
+				since = updatedDate(request.Source, p).Time
+				break
+			}
+		}
+	}
+
+	// When listing a number of pages per check, a pull request can have been updated at any
+	// time since its page was listed by the previous cycle through the pages.
+	if request.Version.PR != "" && request.Source.PagesPerCheck > 0 && !state.PreviousCycleStarted.IsZero() &&
+		state.PreviousCycleStarted.Before(since) {
+		since = state.PreviousCycleStarted
+	}
+
+	// The first check only considers the pull requests updated within the initial lookback,
+	// and returns all of them unless backfilling is skipped.
+	if request.Version.PR == "" && request.Source.InitialLookback > 0 {
+		since = start.Add(-time.Duration(request.Source.InitialLookback))
+	}
+	backfill := request.Version.PR == "" && request.Source.InitialLookback > 0 && !request.Source.SkipBackfill
+
+	// Pull requests skipped because the API budget was exhausted are recorded in the state,
+	// and considered by the next check regardless of when they were updated.
+	pending := func(p *PullRequest) bool {
+		return state != nil && state.IsPending(p.Number)
+	}
+	var skipped []int
+
+	// Merge groups for the base branch are versions of the pull requests they test, which
+	// have to match the source as well (regardless of when they were updated). If the API
+	// budget was exhausted by the previous check, they are listed since it instead.
+	var mergeQueueSince time.Time
+	groups := make(map[int][]*MergeQueueEntry)
+	if request.Source.MergeQueue {
+		mergeQueueSince = since
+		if state != nil && state.MergeQueueSince != nil && state.MergeQueueSince.Before(since) {
+			mergeQueueSince = *state.MergeQueueSince
+		}
+		entries, err := manager.ListMergeQueueEntries(request.Source.BaseBranch)
+		if errors.Is(err, ErrAPIBudgetExhausted) && state != nil {
+			logger.Warn("api budget exhausted, skipping the merge queue until the next check", "error", err)
+			state.MergeQueueSince = &mergeQueueSince
+		} else if err != nil {
+			return nil, err
+		} else if state != nil {
+			state.MergeQueueSince = nil
+		}
+		for _, e := range entries {
+			if request.Source.Number > 0 && e.PullRequest.Number != request.Source.Number {
+				continue
+			}
+			if e.HeadCommit.CommittedDate.Time.After(mergeQueueSince) {
+				groups[e.PullRequest.Number] = append(groups[e.PullRequest.Number], e)
+			}
+		}
 	}
 
-	disableSkipCI := request.Source.DisableCISkip
+	triggers := &triggerSources{manager: manager, source: request.Source, sources: make(map[string]Source)}
+	teams := &teamMembers{manager: manager, members: make(map[string]map[string]bool), userTeams: make(map[string][]string)}
+	var candidates []*PullRequest
+	var sources []Source
+	var updates []bool
 
 Loop:
 	for _, p := range pulls {
+		updated := updatedDate(request.Source, p).Time.After(since) || pending(p)
+		queued := len(groups[p.Number]) > 0
+		source, err := triggers.get(p.BaseRefName)
+		if errors.Is(err, ErrAPIBudgetExhausted) {
+			if updated || queued {
+				skipped = append(skipped, p.Number)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		disableSkipCI := source.DisableCISkip
+
 		// [ci skip]/[skip ci] in Pull request title
 		if !disableSkipCI && ContainsSkipCI(p.Title) {
+			logger.Debug("skipping pull request", "pr", p.Number, "reason", "skip ci in title")
 			continue
 		}
 
 		// [ci skip]/[skip ci] in Commit message
 		if !disableSkipCI && ContainsSkipCI(p.Tip.Message) {
+			logger.Debug("skipping pull request", "pr", p.Number, "reason", "skip ci in commit message")
 			continue
 		}
 
 		// Filter pull request if the BaseBranch does not match the one specified in source
 		if request.Source.BaseBranch != "" && p.PullRequestObject.BaseRefName != request.Source.BaseBranch {
+			logger.Debug("skipping pull request", "pr", p.Number, "reason", "base branch does not match", "base", p.BaseRefName)
+			continue
+		}
+
+		// Filter pull request if the base branch is ignored.
+		if MatchesAnyPattern(request.Source.IgnoreBaseBranches, p.BaseRefName) {
+			logger.Debug("skipping pull request", "pr", p.Number, "reason", "base branch is ignored", "base", p.BaseRefName)
 			continue
 		}
 
 		// Filter out commits that are too old.
-		if !p.UpdatedDate().Time.After(request.Version.CommittedDate) {
+		if !updated && !queued {
+			logger.Debug("skipping pull request", "pr", p.Number, "reason", "not updated since last version")
 			continue
 		}
 
 		// Filter out pull request if it does not contain at least one of the desired labels
-		if len(request.Source.Labels) > 0 {
+		if len(source.Labels) > 0 {
 			labelFound := false
 
 		LabelLoop:
-			for _, wantedLabel := range request.Source.Labels {
+			for _, wantedLabel := range source.Labels {
 				for _, targetLabel := range p.Labels {
 					if targetLabel.Name == wantedLabel {
 						labelFound = true
@@ -64,91 +185,682 @@ Loop:
 			}
 
 			if !labelFound {
+				logger.Debug("skipping pull request", "pr", p.Number, "reason", "missing required label")
 				continue Loop
 			}
 		}
 
 		// Filter out forks.
 		if request.Source.DisableForks && p.IsCrossRepository {
+			logger.Debug("skipping pull request", "pr", p.Number, "reason", "pull request is from a fork")
 			continue
 		}
 
+		// Filter out forks which have been deleted (or cannot be read with the access token)
+		// or archived if specified, as their head can still be fetched from the pull request.
+		if request.Source.SkipUnavailableForks && p.IsCrossRepository {
+			if p.HeadRepository == nil {
+				logger.Info("skipping pull request", "pr", p.Number, "reason", "head repository has been deleted")
+				continue
+			}
+			if p.HeadRepository.IsArchived {
+				logger.Info("skipping pull request", "pr", p.Number, "reason", "head repository is archived")
+				continue
+			}
+		}
+
+		// Filter out forks by their author.
+		if p.IsCrossRepository {
+			if len(request.Source.ForkAuthorsAllow) > 0 && !containsString(request.Source.ForkAuthorsAllow, p.Author.Login) {
+				logger.Debug("skipping pull request", "pr", p.Number, "reason", "fork author is not allowed", "author", p.Author.Login)
+				continue
+			}
+			if containsString(request.Source.ForkAuthorsDeny, p.Author.Login) {
+				logger.Debug("skipping pull request", "pr", p.Number, "reason", "fork author is denied", "author", p.Author.Login)
+				continue
+			}
+		}
+
 		// Filter out drafts.
-		if request.Source.IgnoreDrafts && p.IsDraft {
+		if source.IgnoreDrafts && p.IsDraft {
+			logger.Debug("skipping pull request", "pr", p.Number, "reason", "pull request is a draft")
 			continue
 		}
 
-		// Filter pull request if it does not have the required number of approved review(s).
-		if p.ApprovedReviewCount < request.Source.RequiredReviewApprovals {
+		// Filter out pull requests which are ready for review.
+		if request.Source.OnlyDrafts && !p.IsDraft {
+			logger.Debug("skipping pull request", "pr", p.Number, "reason", "pull request is not a draft")
 			continue
 		}
 
-		// Fetch files once if paths/ignore_paths are specified.
-		var files []string
+		// Approvals from the teams of the author do not count if specified.
+		required := request.Source.RequiredReviewApprovals
+		approvals, approvedBy := p.ApprovedReviewCount, p.ApprovedBy
+		if request.Source.ExcludeAuthorTeamApprovals && (required.Count > 0 || len(required.Teams) > 0) {
+			approvedBy, err = teams.excludeAuthorTeams(p.Author.Login, p.ApprovedBy)
+			if errors.Is(err, ErrAPIBudgetExhausted) {
+				skipped = append(skipped, p.Number)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			approvals = len(approvedBy)
+		}
+
+		// Filter pull request if it does not have the required number of approved review(s).
+		if approvals < required.Count {
+			logger.Debug("skipping pull request", "pr", p.Number, "reason", "not enough approved reviews", "approvals", approvals)
+			continue
+		}
 
-		if len(request.Source.Paths) > 0 || len(request.Source.IgnorePaths) > 0 {
-			files, err = manager.ListModifiedFiles(p.Number)
+		// Filter pull request if a team does not have the required number of approved review(s).
+		if len(required.Teams) > 0 {
+			team, err := teams.missingApprovals(required.Teams, approvedBy)
+			if errors.Is(err, ErrAPIBudgetExhausted) {
+				skipped = append(skipped, p.Number)
+				continue
+			}
 			if err != nil {
-				return nil, fmt.Errorf("failed to list modified files: %s", err)
+				return nil, err
+			}
+			if team != "" {
+				logger.Debug("skipping pull request", "pr", p.Number, "reason", "not enough approved reviews from team", "team", team)
+				continue
 			}
 		}
 
-		// Skip version if no files match the specified paths.
-		if len(request.Source.Paths) > 0 {
-			var wanted []string
-			for _, pattern := range request.Source.Paths {
-				w, err := FilterPath(files, pattern)
-				if err != nil {
-					return nil, fmt.Errorf("path match failed: %s", err)
-				}
-				wanted = append(wanted, w...)
+		// Filter pull request if the number of changed lines is out of bounds.
+		if changed := p.Additions + p.Deletions; changed < request.Source.MinChangedLines ||
+			(request.Source.MaxChangedLines > 0 && changed > request.Source.MaxChangedLines) {
+			logger.Debug("skipping pull request", "pr", p.Number, "reason", "changed lines out of bounds", "changed_lines", changed)
+			continue
+		}
+
+		candidates = append(candidates, p)
+		sources = append(sources, source)
+		updates = append(updates, updated)
+	}
+
+	// Fetch files once if paths/ignore_paths are specified.
+	var files [][]ChangedFileObject
+	var exhausted map[int]bool
+	if filterPaths {
+		files, exhausted, err = listModifiedFiles(manager, candidates, sources, state, request.Source.Concurrency)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, p := range candidates {
+		if exhausted[i] {
+			skipped = append(skipped, p.Number)
+			continue
+		}
+		if filterPaths {
+			reason, err := matchPaths(sources[i], files[i])
+			if err != nil {
+				return nil, err
 			}
-			if len(wanted) == 0 {
-				continue Loop
+			if reason != "" {
+				logger.Debug("skipping pull request", "pr", p.Number, "reason", reason)
+				continue
 			}
 		}
+		for _, e := range groups[p.Number] {
+			logger.Debug("found new merge group", "pr", p.Number, "commit", e.HeadCommit.OID)
+			response = append(response, Version{
+				PR:            strconv.Itoa(p.Number),
+				Commit:        e.HeadCommit.OID,
+				CommittedDate: e.HeadCommit.CommittedDate.Time,
+				State:         githubv4.PullRequestStateOpen,
+				BaseSHA:       e.BaseCommit.OID,
+				MergeGroup:    e.Ref(request.Source.BaseBranch),
+				HeadSHA:       e.PullRequest.HeadRefOID,
+			})
+		}
+		if !updates[i] {
+			continue
+		}
+		logger.Debug("found new version", "pr", p.Number, "commit", p.Tip.OID)
+		v := NewVersion(p)
+		v.CommittedDate = updatedDate(request.Source, p).Time
+		if inVersionKey(request.Source, "base_sha") {
+			v.BaseSHA = p.BaseRefOID
+		}
+		if request.Source.TrackReviewApprovals {
+			v.ApprovedReviewCount = strconv.Itoa(p.ApprovedReviewCount)
+		}
+		if request.Source.DetectForcePushes && p.ForcePushed {
+			v.ForcePushed = "true"
+		}
+		if c := triggerComment(request.Source, p); c != nil && c.CreatedAt.Equal(v.CommittedDate) {
+			v.Comment = strconv.FormatInt(c.DatabaseID, 10)
+		}
+		if len(request.Source.VersionKey) > 0 {
+			v = versionKey(request.Source.VersionKey, v, p)
+		}
 
-		// Skip version if all files are ignored.
-		if len(request.Source.IgnorePaths) > 0 {
-			wanted := files
-			for _, pattern := range request.Source.IgnorePaths {
-				wanted, err = FilterIgnorePath(wanted, pattern)
-				if err != nil {
-					return nil, fmt.Errorf("ignore path match failed: %s", err)
-				}
+		// Commits pushed to the pull request since the previous version are versions as well
+		// if all_new_versions is set. Without a previous version only the latest is returned,
+		// unless the initial lookback is backfilled.
+		versions := []Version{v}
+		if allNewVersions(request.Source) && (request.Version.PR != "" || backfill) && p.State == githubv4.PullRequestStateOpen {
+			intermediate, err := intermediateVersions(manager, v, since)
+			if errors.Is(err, ErrAPIBudgetExhausted) {
+				skipped = append(skipped, p.Number)
+				continue
 			}
-			if len(wanted) == 0 {
-				continue Loop
+			if err != nil {
+				return nil, err
+			}
+			versions = append(intermediate, v)
+		}
+		if len(request.Source.Components) == 0 {
+			response = append(response, versions...)
+			continue
+		}
+
+		// Emit a version per affected component.
+		components := AffectedComponents(request.Source.Components, files[i])
+		if len(components) == 0 {
+			logger.Debug("skipping pull request", "pr", p.Number, "reason", "no files match components")
+			continue
+		}
+		for _, v := range versions {
+			for _, c := range components {
+				v.Component = c
+				response = append(response, v)
 			}
 		}
-		response = append(response, NewVersion(p))
 	}
 
-	// Sort the commits by date
-	sort.Sort(response)
+	for _, n := range skipped {
+		logger.Debug("skipping pull request", "pr", n, "reason", "api budget exhausted")
+	}
+	if len(skipped) > 0 {
+		logger.Warn("api budget exhausted, skipping pull requests until the next check", "skipped", len(skipped))
+	}
+	if state != nil {
+		state.Pending = skipped
+		for _, n := range skipped {
+			if len(groups[n]) > 0 {
+				state.MergeQueueSince = &mergeQueueSince
+			}
+		}
+	}
 
-	// If there are no new but an old version = return the old
-	if len(response) == 0 && request.Version.PR != "" {
+	// Always include the previous version, which Concourse expects as the first
+	// version in the response (it is older than any of the new versions).
+	if request.Version.PR != "" {
 		response = append(response, request.Version)
 	}
-	// If there are new versions and no previous = return just the latest
-	if len(response) != 0 && request.Version.PR == "" {
-		response = CheckResponse{response[len(response)-1]}
+
+	// Sort the versions by date and pull request number, and remove duplicates
+	// (e.g. pull requests listed twice because they moved between pages).
+	sort.Stable(response)
+	response = response.deduplicate()
+	if !allNewVersions(request.Source) {
+		response = response.latestPerPR(since)
+	}
+
+	// If there are new versions and no previous = return just the latest (for each of
+	// the components affected by it), unless the versions within the initial lookback
+	// are backfilled.
+	if len(response) != 0 && request.Version.PR == "" && !backfill {
+		latest := response[len(response)-1]
+		first := len(response) - 1
+		for first > 0 && response[first-1].PR == latest.PR && response[first-1].Commit == latest.Commit &&
+			response[first-1].CommittedDate.Equal(latest.CommittedDate) && response[first-1].Component != "" {
+			first--
+		}
+		response = response[first:]
 	}
+	if state != nil {
+		if err := state.Save(); err != nil {
+			logger.Warn("failed to save check state", "error", err)
+		}
+	}
+	logger.Info("check finished", "pull_requests", len(pulls), "versions", len(response), "last_activity", lastActivity(request.Source, pulls), "duration", time.Since(start))
+	reportRateLimit(manager)
 	return response, nil
 }
 
+// listPullRequestPages lists the given number of pages of pull requests, resuming from where
+// the previous check stopped, and records where the next check should resume from. Listing
+// restarts from the first page (within the same cycle) if the cursor is no longer valid.
+func listPullRequestPages(manager Github, state *CheckState, states []githubv4.PullRequestState, includeFiles bool, pages int, now time.Time) ([]*PullRequest, error) {
+	cursor := state.NextPages(now)
+	pulls, next, err := manager.ListPullRequestPages(states, includeFiles, cursor, pages)
+	if err != nil && cursor != "" && !errors.Is(err, ErrAPIBudgetExhausted) {
+		logger.Warn("failed to resume listing pull requests, restarting from the first page", "error", err)
+		state.Cursor, cursor = "", ""
+		pulls, next, err = manager.ListPullRequestPages(states, includeFiles, cursor, pages)
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.Cursor = next
+	logger.Debug("listed pages of pull requests", "pages", pages, "resumed", cursor != "", "completed", next == "")
+	return pulls, nil
+}
+
+// teamMembers lists the members of teams (and the teams of users) once per check.
+type teamMembers struct {
+	manager   Github
+	members   map[string]map[string]bool
+	userTeams map[string][]string
+}
+
+// get the members of a team.
+func (t *teamMembers) get(team string) (map[string]bool, error) {
+	if m, ok := t.members[team]; ok {
+		return m, nil
+	}
+	logins, err := t.manager.ListTeamMembers(team)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]bool)
+	for _, l := range logins {
+		m[l] = true
+	}
+	t.members[team] = m
+	return m, nil
+}
+
+// excludeAuthorTeams returns the distinct approvers of a pull request who are not the
+// author, or members of any of the teams of the author.
+func (t *teamMembers) excludeAuthorTeams(author string, approvedBy []string) ([]string, error) {
+	authorTeams, ok := t.userTeams[author]
+	if !ok {
+		var err error
+		authorTeams, err = t.manager.ListUserTeams(author)
+		if err != nil {
+			return nil, err
+		}
+		t.userTeams[author] = authorTeams
+	}
+
+	excluded := map[string]bool{author: true}
+	for _, team := range authorTeams {
+		members, err := t.get(team)
+		if err != nil {
+			return nil, err
+		}
+		for login := range members {
+			excluded[login] = true
+		}
+	}
+
+	var out []string
+	for _, login := range approvedBy {
+		if !excluded[login] {
+			excluded[login] = true
+			out = append(out, login)
+		}
+	}
+	return out, nil
+}
+
+// missingApprovals returns the first team (by name) which has not approved the pull
+// request the required number of times, or an empty string if all teams have.
+func (t *teamMembers) missingApprovals(required map[string]int, approvedBy []string) (string, error) {
+	var names []string
+	for team := range required {
+		names = append(names, team)
+	}
+	sort.Strings(names)
+
+	for _, team := range names {
+		members, err := t.get(team)
+		if err != nil {
+			return "", err
+		}
+		approvers := make(map[string]bool)
+		for _, login := range approvedBy {
+			if members[login] {
+				approvers[login] = true
+			}
+		}
+		if len(approvers) < required[team] {
+			return team, nil
+		}
+	}
+	return "", nil
+}
+
+// updatedDate of the pull request, which is when it was marked as ready for review
+// if trigger_on_ready is set, or when the trigger phrase was last commented, if that
+// happened after it was last updated.
+func updatedDate(source Source, p *PullRequest) githubv4.DateTime {
+	date := p.UpdatedDate()
+	if source.TriggerOnReady && p.State == githubv4.PullRequestStateOpen && !p.IsDraft && p.ReadyAt.After(date.Time) {
+		date = p.ReadyAt
+	}
+	if c := triggerComment(source, p); c != nil && c.CreatedAt.After(date.Time) {
+		date = c.CreatedAt
+	}
+	// Changes of the labels or approvals of open pull requests are new versions if they are
+	// part of the version key.
+	if p.State == githubv4.PullRequestStateOpen {
+		if inVersionKey(source, "labels") && p.LabeledAt.After(date.Time) {
+			date = p.LabeledAt
+		}
+		if inVersionKey(source, "approvals") && p.ApprovedAt.After(date.Time) {
+			date = p.ApprovedAt
+		}
+	}
+	return date
+}
+
+// allNewVersions returns true if every commit pushed to a pull request is a version,
+// instead of only the latest commit of each pull request (latest_per_pr, the default).
+func allNewVersions(source Source) bool {
+	return source.AllNewVersions || (source.LatestPerPR != nil && !*source.LatestPerPR)
+}
+
+// intermediateVersions returns the versions of the commits of a pull request which were
+// committed after the given date and before the version of its latest commit.
+func intermediateVersions(manager Github, latest Version, since time.Time) ([]Version, error) {
+	commits, err := manager.ListPullRequestCommits(latest.PR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits of pull request %s: %w", latest.PR, err)
+	}
+	var versions []Version
+	for _, c := range commits {
+		if c.OID == latest.Commit || !c.CommittedDate.After(since) || c.CommittedDate.After(latest.CommittedDate) {
+			continue
+		}
+		v := latest
+		v.Commit = c.OID
+		v.CommittedDate = c.CommittedDate
+		v.ForcePushed = ""
+		v.Comment = ""
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// inVersionKey returns true if the field is part of the version key, which includes the
+// approvals if track_review_approvals is set and the base commit if pin_base_sha is set.
+func inVersionKey(source Source, field string) bool {
+	if field == "approvals" && source.TrackReviewApprovals {
+		return true
+	}
+	if field == "base_sha" && source.PinBaseSHA {
+		return true
+	}
+	return containsString(source.VersionKey, field)
+}
+
+// versionKey keeps the fields of the version which are part of the version key, in
+// addition to the commit (and the fields which identify the pull request).
+func versionKey(key []string, v Version, p *PullRequest) Version {
+	v.ApprovedReviewCount = ""
+	if containsString(key, "approvals") {
+		v.ApprovedReviewCount = strconv.Itoa(p.ApprovedReviewCount)
+	}
+	v.Labels = ""
+	if containsString(key, "labels") {
+		var labels []string
+		for _, l := range p.Labels {
+			if l.Name != "" {
+				labels = append(labels, l.Name)
+			}
+		}
+		sort.Strings(labels)
+		v.Labels = strings.Join(labels, ",")
+	}
+	if !containsString(key, "base_sha") {
+		v.BaseSHA = ""
+	}
+	return v
+}
+
+// triggerComment returns the last comment on an open pull request which consists of
+// the trigger phrase (on a line of its own) and was made by one of the users allowed
+// to trigger builds, or nil if there is none.
+func triggerComment(source Source, p *PullRequest) *CommentObject {
+	if source.TriggerPhrase == "" || p.State != githubv4.PullRequestStateOpen {
+		return nil
+	}
+	var last *CommentObject
+	for i, c := range p.Comments {
+		if len(source.TriggerPhraseUsers) > 0 && !containsString(source.TriggerPhraseUsers, c.Author.Login) {
+			continue
+		}
+		for _, line := range strings.Split(c.Body, "\n") {
+			if strings.TrimSpace(line) == source.TriggerPhrase {
+				if last == nil || c.CreatedAt.After(last.CreatedAt.Time) {
+					last = &p.Comments[i]
+				}
+				break
+			}
+		}
+	}
+	return last
+}
+
+// matchPaths checks the modified files against paths, ignore_paths and require_paths, and returns
+// the reason for skipping the pull request, or an empty string if it is wanted.
+// Adding files can only turn a skipped pull request into a wanted one.
+func matchPaths(source Source, changed []ChangedFileObject) (string, error) {
+	var files, typed []string
+	for _, f := range changed {
+		files = append(files, f.Path)
+		if len(source.PathsChangeType) == 0 || containsString(source.PathsChangeType, f.ChangeType) {
+			typed = append(typed, f.Path)
+		}
+	}
+
+	// Skip version if no files (with the specified change types) match the specified paths.
+	if len(source.Paths) > 0 {
+		var wanted []string
+		for _, pattern := range source.Paths {
+			w, err := FilterPath(typed, pattern)
+			if err != nil {
+				return "", fmt.Errorf("path match failed: %w", err)
+			}
+			wanted = append(wanted, w...)
+		}
+		if len(wanted) == 0 {
+			return "no files match paths", nil
+		}
+	}
+
+	// Skip version if all files are ignored.
+	if len(source.IgnorePaths) > 0 {
+		wanted := files
+		for _, pattern := range source.IgnorePaths {
+			var err error
+			wanted, err = FilterIgnorePath(wanted, pattern)
+			if err != nil {
+				return "", fmt.Errorf("ignore path match failed: %w", err)
+			}
+		}
+		if len(wanted) == 0 {
+			return "all files match ignore_paths", nil
+		}
+	}
+
+	// Skip version if any of the required paths is not modified (e.g. a changelog entry).
+	for _, pattern := range source.RequirePaths {
+		required, err := FilterPath(files, pattern)
+		if err != nil {
+			return "", fmt.Errorf("require path match failed: %w", err)
+		}
+		if len(required) == 0 {
+			return fmt.Sprintf("no files match required path %s", pattern), nil
+		}
+	}
+	return "", nil
+}
+
+// MatchedPaths returns the patterns in paths which match at least one of the modified
+// files (with the specified change types) that is not ignored by ignore_paths.
+func MatchedPaths(source Source, changed []ChangedFileObject) ([]string, error) {
+	var typed []string
+	for _, f := range changed {
+		if len(source.PathsChangeType) == 0 || containsString(source.PathsChangeType, f.ChangeType) {
+			typed = append(typed, f.Path)
+		}
+	}
+	for _, pattern := range source.IgnorePaths {
+		var err error
+		typed, err = FilterIgnorePath(typed, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("ignore path match failed: %w", err)
+		}
+	}
+
+	var matched []string
+	for _, pattern := range source.Paths {
+		w, err := FilterPath(typed, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("path match failed: %w", err)
+		}
+		if len(w) > 0 {
+			matched = append(matched, pattern)
+		}
+	}
+	return matched, nil
+}
+
+// listModifiedFiles returns the modified files of each pull request, using the files listed
+// together with the pull request or cached by an earlier check when possible. The remaining
+// pull requests are fetched by a pool of workers, which stop paginating as soon as the files
+// listed so far are enough for the pull request to be wanted. The pull requests skipped by the
+// previous check are fetched first, followed by the most recently updated ones, and the pull
+// requests whose files could not be fetched because the API budget was exhausted are returned
+// as well.
+func listModifiedFiles(manager Github, pulls []*PullRequest, sources []Source, state *CheckState, concurrency int) ([][]ChangedFileObject, map[int]bool, error) {
+	files := make([][]ChangedFileObject, len(pulls))
+
+	var fetch []int
+	for i, p := range pulls {
+		if p.FilesComplete {
+			files[i] = p.Files
+			continue
+		}
+		if state != nil {
+			if f, ok := state.Files(p); ok {
+				logger.Debug("using cached modified files", "pr", p.Number, "commit", p.Tip.OID)
+				files[i] = f
+				continue
+			}
+		}
+		fetch = append(fetch, i)
+	}
+	sort.SliceStable(fetch, func(a, b int) bool {
+		if state != nil && state.IsPending(pulls[fetch[a]].Number) != state.IsPending(pulls[fetch[b]].Number) {
+			return state.IsPending(pulls[fetch[a]].Number)
+		}
+		return updatedDate(sources[fetch[a]], pulls[fetch[a]]).After(updatedDate(sources[fetch[b]], pulls[fetch[b]]).Time)
+	})
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	jobs := make(chan int)
+	errs := make(chan error, len(fetch))
+	partial := make([]bool, len(pulls))
+	skipped := make([]bool, len(pulls))
+
+	// The remaining pull requests are not fetched once a worker fails.
+	failed := make(chan struct{})
+	var fail sync.Once
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				select {
+				case <-failed:
+					continue
+				default:
+				}
+				stop := func(files []ChangedFileObject) bool {
+					select {
+					case <-failed:
+						return true
+					default:
+					}
+					// Components are affected by any of the files, which must all be listed.
+					if len(sources[i].Components) > 0 {
+						return false
+					}
+					reason, err := matchPaths(sources[i], files)
+					partial[i] = err == nil && reason == ""
+					return partial[i]
+				}
+				f, err := manager.ListModifiedFiles(pulls[i].Number, stop)
+				if errors.Is(err, ErrAPIBudgetExhausted) {
+					skipped[i] = true
+					continue
+				}
+				if err != nil {
+					errs <- fmt.Errorf("failed to list modified files: %w", err)
+					fail.Do(func() { close(failed) })
+					continue
+				}
+				files[i] = f
+			}
+		}()
+	}
+feed:
+	for _, i := range fetch {
+		select {
+		case jobs <- i:
+		case <-failed:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return nil, nil, err
+	}
+	exhausted := make(map[int]bool)
+	for _, i := range fetch {
+		if skipped[i] {
+			exhausted[i] = true
+			continue
+		}
+		// Only complete lists of files are cached.
+		if state != nil && !partial[i] {
+			state.SetFiles(pulls[i], files[i])
+		}
+	}
+	return files, exhausted, nil
+}
+
 // ContainsSkipCI returns true if a string contains [ci skip] or [skip ci].
 func ContainsSkipCI(s string) bool {
 	re := regexp.MustCompile("(?i)\\[(ci skip|skip ci)\\]")
 	return re.MatchString(s)
 }
 
+// MatchesAnyPattern returns true if the name matches one of the (path.Match) patterns.
+// Invalid patterns never match, as they are rejected when validating the source.
+func MatchesAnyPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if match, _ := path.Match(pattern, name); match {
+			return true
+		}
+	}
+	return false
+}
+
 // FilterIgnorePath ...
 func FilterIgnorePath(files []string, pattern string) ([]string, error) {
 	var out []string
 	for _, file := range files {
-		match, err := filepath.Match(pattern, file)
+		match, err := path.Match(pattern, file)
 		if err != nil {
 			return nil, err
 		}
@@ -163,7 +875,7 @@ func FilterIgnorePath(files []string, pattern string) ([]string, error) {
 func FilterPath(files []string, pattern string) ([]string, error) {
 	var out []string
 	for _, file := range files {
-		match, err := filepath.Match(pattern, file)
+		match, err := path.Match(pattern, file)
 		if err != nil {
 			return nil, err
 		}
@@ -186,8 +898,8 @@ func IsInsidePath(parent, child string) bool {
 	// we add a trailing slash so that we only get prefix matches on a
 	// directory separator
 	parentWithTrailingSlash := parent
-	if !strings.HasSuffix(parentWithTrailingSlash, string(filepath.Separator)) {
-		parentWithTrailingSlash += string(filepath.Separator)
+	if !strings.HasSuffix(parentWithTrailingSlash, "/") {
+		parentWithTrailingSlash += "/"
 	}
 
 	return strings.HasPrefix(child, parentWithTrailingSlash)
@@ -207,9 +919,58 @@ func (r CheckResponse) Len() int {
 }
 
 func (r CheckResponse) Less(i, j int) bool {
-	return r[j].CommittedDate.After(r[i].CommittedDate)
+	if !r[i].CommittedDate.Equal(r[j].CommittedDate) {
+		return r[i].CommittedDate.Before(r[j].CommittedDate)
+	}
+	a, errA := strconv.Atoi(r[i].PR)
+	b, errB := strconv.Atoi(r[j].PR)
+	if errA != nil || errB != nil {
+		return r[i].PR < r[j].PR
+	}
+	return a < b
 }
 
 func (r CheckResponse) Swap(i, j int) {
 	r[i], r[j] = r[j], r[i]
 }
+
+// latestPerPR keeps only the latest of the new versions (found after the given date) of
+// each pull request and component, e.g. when a pull request was listed twice because it
+// was updated while being listed. Merge groups are versions of their own.
+func (r CheckResponse) latestPerPR(since time.Time) CheckResponse {
+	key := func(v Version) string {
+		return strings.Join([]string{v.PR, v.Component, v.MergeGroup}, "/")
+	}
+	latest := make(map[string]int)
+	for i, v := range r {
+		if v.CommittedDate.After(since) {
+			latest[key(v)] = i
+		}
+	}
+	var out CheckResponse
+	for i, v := range r {
+		if v.CommittedDate.After(since) && latest[key(v)] != i {
+			logger.Debug("skipping version", "pr", v.PR, "commit", v.Commit, "reason", "newer version of the pull request")
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// deduplicate keeps the first of the versions for the same update of a pull request
+// (and component). A change of state (e.g. being merged or reopened) is a new version even if the
+// commit is the same.
+func (r CheckResponse) deduplicate() CheckResponse {
+	seen := make(map[string]bool)
+	var out CheckResponse
+	for _, v := range r {
+		key := strings.Join([]string{v.PR, v.Commit, string(v.State), v.CommittedDate.UTC().Format(time.RFC3339Nano), v.Component}, "/")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, v)
+	}
+	return out
+}
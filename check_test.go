@@ -1,10 +1,17 @@
 package resource_test
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/shurcooL/githubv4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	resource "github.com/telia-oss/github-pr-resource"
 	"github.com/telia-oss/github-pr-resource/fakes"
 )
@@ -49,6 +56,48 @@ func TestCheck(t *testing.T) {
 			},
 		},
 
+		{
+			description: "check includes the approved review count when tracking review approvals",
+			source: resource.Source{
+				Repository:           "itsdalmo/test-repository",
+				AccessToken:          "oauthtoken",
+				TrackReviewApprovals: true,
+			},
+			version:      resource.Version{},
+			pullRequests: testPullRequests,
+			files:        [][]string{},
+			expected: resource.CheckResponse{
+				func() resource.Version {
+					v := resource.NewVersion(testPullRequests[1])
+					v.ApprovedReviewCount = "0"
+					return v
+				}(),
+			},
+		},
+
+		{
+			description: "check flags versions that were force-pushed when detecting force-pushes",
+			source: resource.Source{
+				Repository:        "itsdalmo/test-repository",
+				AccessToken:       "oauthtoken",
+				DetectForcePushes: true,
+			},
+			version: resource.Version{},
+			pullRequests: func() []*resource.PullRequest {
+				p := *testPullRequests[1]
+				p.ForcePushed = true
+				return []*resource.PullRequest{&p}
+			}(),
+			files: [][]string{},
+			expected: resource.CheckResponse{
+				func() resource.Version {
+					v := resource.NewVersion(testPullRequests[1])
+					v.ForcePushed = "true"
+					return v
+				}(),
+			},
+		},
+
 		{
 			description: "check returns the previous version when its still latest",
 			source: resource.Source{
@@ -73,6 +122,7 @@ func TestCheck(t *testing.T) {
 			pullRequests: testPullRequests,
 			files:        [][]string{},
 			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[3]),
 				resource.NewVersion(testPullRequests[2]),
 				resource.NewVersion(testPullRequests[1]),
 			},
@@ -93,6 +143,7 @@ func TestCheck(t *testing.T) {
 				{"terraform/modules/variables.tf", "travis.yml"},
 			},
 			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[3]),
 				resource.NewVersion(testPullRequests[2]),
 			},
 		},
@@ -112,10 +163,31 @@ func TestCheck(t *testing.T) {
 				{"terraform/modules/variables.tf", "travis.yml"},
 			},
 			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[3]),
 				resource.NewVersion(testPullRequests[2]),
 			},
 		},
 
+		{
+			description: "check will skip versions which do not modify the required paths",
+			source: resource.Source{
+				Repository:   "itsdalmo/test-repository",
+				AccessToken:  "oauthtoken",
+				RequirePaths: []string{"changelog.d/*"},
+			},
+			version:      resource.NewVersion(testPullRequests[3]),
+			pullRequests: testPullRequests,
+			files: [][]string{
+				{"README.md", "changelog.d/123.md"},
+				{"terraform/modules/ecs/main.tf", "README.md"},
+				{"changelog.d"},
+			},
+			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[3]),
+				resource.NewVersion(testPullRequests[1]),
+			},
+		},
+
 		{
 			description: "check correctly ignores [skip ci] when specified",
 			source: resource.Source{
@@ -126,6 +198,7 @@ func TestCheck(t *testing.T) {
 			version:      resource.NewVersion(testPullRequests[1]),
 			pullRequests: testPullRequests,
 			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[1]),
 				resource.NewVersion(testPullRequests[0]),
 			},
 		},
@@ -140,10 +213,26 @@ func TestCheck(t *testing.T) {
 			version:      resource.NewVersion(testPullRequests[3]),
 			pullRequests: testPullRequests,
 			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[3]),
 				resource.NewVersion(testPullRequests[1]),
 			},
 		},
 
+		{
+			description: "check only returns drafts when only drafts are wanted",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+				OnlyDrafts:  true,
+			},
+			version:      resource.NewVersion(testPullRequests[3]),
+			pullRequests: testPullRequests,
+			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[3]),
+				resource.NewVersion(testPullRequests[2]),
+			},
+		},
+
 		{
 			description: "check does not ignore drafts when drafts are not ignored",
 			source: resource.Source{
@@ -154,6 +243,7 @@ func TestCheck(t *testing.T) {
 			version:      resource.NewVersion(testPullRequests[3]),
 			pullRequests: testPullRequests,
 			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[3]),
 				resource.NewVersion(testPullRequests[2]),
 				resource.NewVersion(testPullRequests[1]),
 			},
@@ -169,6 +259,7 @@ func TestCheck(t *testing.T) {
 			version:      resource.NewVersion(testPullRequests[5]),
 			pullRequests: testPullRequests,
 			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[5]),
 				resource.NewVersion(testPullRequests[3]),
 				resource.NewVersion(testPullRequests[2]),
 				resource.NewVersion(testPullRequests[1]),
@@ -190,16 +281,33 @@ func TestCheck(t *testing.T) {
 			},
 		},
 
+		{
+			description: "check supports ignoring base branches",
+			source: resource.Source{
+				Repository:         "itsdalmo/test-repository",
+				AccessToken:        "oauthtoken",
+				IgnoreBaseBranches: []string{"gh-pages", "dev*"},
+			},
+			version:      resource.NewVersion(testPullRequests[8]),
+			pullRequests: testPullRequests[5:9],
+			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[8]),
+				resource.NewVersion(testPullRequests[7]),
+				resource.NewVersion(testPullRequests[5]),
+			},
+		},
+
 		{
 			description: "check correctly ignores PRs with no approved reviews when specified",
 			source: resource.Source{
 				Repository:              "itsdalmo/test-repository",
 				AccessToken:             "oauthtoken",
-				RequiredReviewApprovals: 1,
+				RequiredReviewApprovals: resource.ReviewApprovals{Count: 1},
 			},
 			version:      resource.NewVersion(testPullRequests[8]),
 			pullRequests: testPullRequests,
 			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[8]),
 				resource.NewVersion(testPullRequests[7]),
 			},
 		},
@@ -258,6 +366,7 @@ func TestCheck(t *testing.T) {
 			pullRequests: testPullRequests,
 			files:        [][]string{},
 			expected: resource.CheckResponse{
+				resource.NewVersion(testPullRequests[11]),
 				resource.NewVersion(testPullRequests[9]),
 				resource.NewVersion(testPullRequests[10]),
 			},
@@ -283,7 +392,7 @@ func TestCheck(t *testing.T) {
 			github.ListPullRequestsReturns(pullRequests, nil)
 
 			for i, file := range tc.files {
-				github.ListModifiedFilesReturnsOnCall(i, file, nil)
+				github.ListModifiedFilesReturnsOnCall(i, changedFiles(file...), nil)
 			}
 
 			input := resource.CheckRequest{Source: tc.source, Version: tc.version}
@@ -297,181 +406,925 @@ func TestCheck(t *testing.T) {
 	}
 }
 
-func TestContainsSkipCI(t *testing.T) {
+func TestCheckCachesModifiedFiles(t *testing.T) {
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	source := resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		Paths:       []string{"terraform/*/*.tf"},
+		CacheDir:    dir,
+	}
+	pullRequests := []*resource.PullRequest{
+		createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+	}
+
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns(pullRequests, nil)
+	github.ListModifiedFilesReturns(changedFiles("terraform/modules/variables.tf"), nil)
+
+	// The first check fetches the files of every pull request.
+	output, err := resource.Check(resource.CheckRequest{Source: source}, github)
+	require.NoError(t, err)
+	assert.Equal(t, resource.CheckResponse{resource.NewVersion(pullRequests[0])}, output)
+	assert.Equal(t, 2, github.ListModifiedFilesCallCount())
+
+	// Pull requests that have not moved use the cached files.
+	output, err = resource.Check(resource.CheckRequest{Source: source}, github)
+	require.NoError(t, err)
+	assert.Equal(t, resource.CheckResponse{resource.NewVersion(pullRequests[0])}, output)
+	assert.Equal(t, 2, github.ListModifiedFilesCallCount())
+
+	// A new commit invalidates the cached files.
+	moved := createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	moved.Tip.OID = "newcommit"
+	github.ListPullRequestsReturns([]*resource.PullRequest{pullRequests[0], moved}, nil)
+
+	_, err = resource.Check(resource.CheckRequest{Source: source}, github)
+	require.NoError(t, err)
+	assert.Equal(t, 3, github.ListModifiedFilesCallCount())
+	pr, _ := github.ListModifiedFilesArgsForCall(2)
+	assert.Equal(t, 2, pr)
+}
+
+func TestCheckPagesPerCheck(t *testing.T) {
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	source := resource.Source{
+		Repository:    "itsdalmo/test-repository",
+		AccessToken:   "oauthtoken",
+		CacheDir:      dir,
+		PagesPerCheck: 1,
+	}
+	previous := createTestPR(5, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	first := createTestPR(3, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	second := createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+
+	github := new(fakes.FakeGithub)
+
+	// The first check lists the first page and records where the next check should resume.
+	github.ListPullRequestPagesReturns([]*resource.PullRequest{first}, "cursor", nil)
+	output, err := resource.Check(resource.CheckRequest{Source: source, Version: resource.NewVersion(previous)}, github)
+	require.NoError(t, err)
+	assert.Equal(t, resource.CheckResponse{resource.NewVersion(previous), resource.NewVersion(first)}, output)
+	_, _, cursor, pages := github.ListPullRequestPagesArgsForCall(0)
+	assert.Equal(t, "", cursor)
+	assert.Equal(t, 1, pages)
+	assert.Equal(t, 0, github.ListPullRequestsCallCount())
+
+	// The second check resumes from the cursor and completes the cycle.
+	github.ListPullRequestPagesReturns([]*resource.PullRequest{second}, "", nil)
+	output, err = resource.Check(resource.CheckRequest{Source: source, Version: resource.NewVersion(first)}, github)
+	require.NoError(t, err)
+	assert.Equal(t, resource.CheckResponse{resource.NewVersion(first), resource.NewVersion(second)}, output)
+	_, _, cursor, _ = github.ListPullRequestPagesArgsForCall(1)
+	assert.Equal(t, "cursor", cursor)
+
+	// The next cycle starts from the first page, and includes the pull requests updated since
+	// the previous cycle started even if they are older than the last version.
+	latest := createTestPR(6, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	latest.Tip.CommittedDate = githubv4.DateTime{Time: time.Now().Add(time.Hour)}
+	updated := createTestPR(7, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	updated.Tip.CommittedDate = githubv4.DateTime{Time: time.Now()}
+
+	github.ListPullRequestPagesReturns([]*resource.PullRequest{updated, latest}, "", nil)
+	output, err = resource.Check(resource.CheckRequest{Source: source, Version: resource.NewVersion(latest)}, github)
+	require.NoError(t, err)
+	assert.Equal(t, resource.CheckResponse{resource.NewVersion(updated), resource.NewVersion(latest)}, output)
+	_, _, cursor, _ = github.ListPullRequestPagesArgsForCall(2)
+	assert.Equal(t, "", cursor)
+
+	// The state is written through a temporary file, which is not left behind.
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.Regexp(t, `check-[0-9a-f]+\.json$`, files[0])
+	}
+}
+
+func TestCheckUsesListedFiles(t *testing.T) {
+	source := resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		Paths:       []string{"terraform/*/*.tf"},
+	}
+	complete := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	complete.Files = changedFiles("README.md")
+	complete.FilesComplete = true
+	incomplete := createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	incomplete.Files = changedFiles("README.md")
+
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns([]*resource.PullRequest{complete, incomplete}, nil)
+	github.ListModifiedFilesReturns(changedFiles("terraform/modules/variables.tf"), nil)
+
+	output, err := resource.Check(resource.CheckRequest{Source: source}, github)
+	require.NoError(t, err)
+	assert.Equal(t, resource.CheckResponse{resource.NewVersion(incomplete)}, output)
+
+	_, includeFiles := github.ListPullRequestsArgsForCall(0)
+	assert.True(t, includeFiles)
+	if assert.Equal(t, 1, github.ListModifiedFilesCallCount()) {
+		pr, _ := github.ListModifiedFilesArgsForCall(0)
+		assert.Equal(t, 2, pr)
+	}
+}
+
+func TestCheckConcurrency(t *testing.T) {
+	source := resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		Paths:       []string{"terraform/*/*.tf"},
+		Concurrency: 4,
+	}
+	var pullRequests []*resource.PullRequest
+	for i := 1; i <= 20; i++ {
+		pullRequests = append(pullRequests, createTestPR(i, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen))
+	}
+
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns(pullRequests, nil)
+	github.ListModifiedFilesStub = func(pr int, _ func([]resource.ChangedFileObject) bool) ([]resource.ChangedFileObject, error) {
+		if pr%2 == 0 {
+			return changedFiles("terraform/modules/variables.tf"), nil
+		}
+		return changedFiles("README.md"), nil
+	}
+
+	expected := resource.CheckResponse{resource.Version{PR: "100"}}
+	for i := len(pullRequests); i > 0; i-- {
+		if i%2 == 0 {
+			expected = append(expected, resource.NewVersion(pullRequests[i-1]))
+		}
+	}
+
+	input := resource.CheckRequest{Source: source, Version: resource.Version{PR: "100"}}
+	output, err := resource.Check(input, github)
+	require.NoError(t, err)
+	assert.Equal(t, expected, output)
+	assert.Equal(t, 20, github.ListModifiedFilesCallCount())
+}
+
+func TestCheckConcurrencyStopsOnError(t *testing.T) {
+	source := resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		Paths:       []string{"terraform/*/*.tf"},
+		Concurrency: 4,
+	}
+	var pullRequests []*resource.PullRequest
+	for i := 1; i <= 20; i++ {
+		pullRequests = append(pullRequests, createTestPR(i, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen))
+	}
+
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns(pullRequests, nil)
+	github.ListModifiedFilesReturns(nil, errors.New("server error"))
+
+	input := resource.CheckRequest{Source: source, Version: resource.Version{PR: "100"}}
+	_, err := resource.Check(input, github)
+	assert.EqualError(t, err, "failed to list modified files: server error")
+	assert.True(t, github.ListModifiedFilesCallCount() <= 4, "modified files were listed %d times", github.ListModifiedFilesCallCount())
+}
+
+func TestCheckStopsListingModifiedFiles(t *testing.T) {
 	tests := []struct {
 		description string
-		message     string
-		want        bool
+		source      resource.Source
+		files       []string
+		stop        bool
 	}{
 		{
-			description: "does not just match any symbol in the regexp",
-			message:     "(",
-			want:        false,
+			description: "stops when a file matches paths",
+			source:      resource.Source{Paths: []string{"terraform/*/*.tf"}},
+			files:       []string{"README.md", "terraform/modules/variables.tf"},
+			stop:        true,
 		},
 		{
-			description: "does not match when it should not",
-			message:     "test",
-			want:        false,
+			description: "continues while no file matches paths",
+			source:      resource.Source{Paths: []string{"terraform/*/*.tf"}},
+			files:       []string{"README.md"},
 		},
 		{
-			description: "matches [ci skip]",
-			message:     "[ci skip]",
-			want:        true,
+			description: "stops when a file is not ignored",
+			source:      resource.Source{IgnorePaths: []string{"*.md"}},
+			files:       []string{"README.md", "main.go"},
+			stop:        true,
 		},
 		{
-			description: "matches [skip ci]",
-			message:     "[skip ci]",
-			want:        true,
+			description: "continues while all files are ignored",
+			source:      resource.Source{IgnorePaths: []string{"*.md"}},
+			files:       []string{"README.md"},
 		},
 		{
-			description: "matches trailing skip ci",
-			message:     "trailing [skip ci]",
-			want:        true,
+			description: "stops when the required paths are modified",
+			source:      resource.Source{RequirePaths: []string{"changelog.d/*"}},
+			files:       []string{"main.go", "changelog.d/1.md"},
+			stop:        true,
 		},
 		{
-			description: "matches leading skip ci",
-			message:     "[skip ci] leading",
-			want:        true,
+			description: "continues while the required paths are not modified",
+			source:      resource.Source{RequirePaths: []string{"changelog.d/*"}},
+			files:       []string{"main.go"},
 		},
 		{
-			description: "is case insensitive",
-			message:     "case[Skip CI]insensitive",
-			want:        true,
+			description: "continues while components are set",
+			source:      resource.Source{Components: map[string][]string{"api": {"api"}}},
+			files:       []string{"api/main.go"},
+		},
+		{
+			description: "continues while the matching files are ignored",
+			source:      resource.Source{Paths: []string{"docs"}, IgnorePaths: []string{"docs/*.md"}},
+			files:       []string{"docs/README.md"},
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.description, func(t *testing.T) {
-			got := resource.ContainsSkipCI(tc.message)
-			assert.Equal(t, tc.want, got)
+			tc.source.Repository = "itsdalmo/test-repository"
+			tc.source.AccessToken = "oauthtoken"
+			pull := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns([]*resource.PullRequest{pull}, nil)
+			github.ListModifiedFilesReturns(changedFiles(tc.files...), nil)
+
+			_, err := resource.Check(resource.CheckRequest{Source: tc.source}, github)
+			require.NoError(t, err)
+			if assert.Equal(t, 1, github.ListModifiedFilesCallCount()) {
+				_, stop := github.ListModifiedFilesArgsForCall(0)
+				assert.Equal(t, tc.stop, stop(changedFiles(tc.files...)))
+			}
 		})
 	}
 }
 
-func TestFilterPath(t *testing.T) {
-	cases := []struct {
+func TestCheckPathsChangeType(t *testing.T) {
+	source := resource.Source{
+		Repository:      "itsdalmo/test-repository",
+		AccessToken:     "oauthtoken",
+		Paths:           []string{"migrations/"},
+		PathsChangeType: []string{"ADDED"},
+	}
+	added := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	added.Files = []resource.ChangedFileObject{{Path: "migrations/002.sql", ChangeType: "ADDED"}}
+	added.FilesComplete = true
+	modified := createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	modified.Files = []resource.ChangedFileObject{
+		{Path: "migrations/001.sql", ChangeType: "MODIFIED"},
+		{Path: "README.md", ChangeType: "ADDED"},
+	}
+	modified.FilesComplete = true
+
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns([]*resource.PullRequest{added, modified}, nil)
+
+	input := resource.CheckRequest{Source: source, Version: resource.NewVersion(createTestPR(3, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen))}
+	output, err := resource.Check(input, github)
+	require.NoError(t, err)
+	assert.Equal(t, resource.CheckResponse{input.Version, resource.NewVersion(added)}, output)
+}
+
+func TestCheckChangedLines(t *testing.T) {
+	tests := []struct {
 		description string
-		pattern     string
-		files       []string
-		want        []string
+		min         int
+		max         int
+		expected    []int
 	}{
 		{
-			description: "returns all matching files",
-			pattern:     "*.txt",
-			files: []string{
-				"file1.txt",
-				"test/file2.txt",
-			},
-			want: []string{
-				"file1.txt",
-			},
+			description: "all pull requests by default",
+			expected:    []int{3, 2, 1},
 		},
 		{
-			description: "works with wildcard",
-			pattern:     "test/*",
-			files: []string{
-				"file1.txt",
-				"test/file2.txt",
-			},
-			want: []string{
-				"test/file2.txt",
-			},
+			description: "skips pull requests with too few changed lines",
+			min:         20,
+			expected:    []int{2, 1},
 		},
 		{
-			description: "excludes unmatched files",
-			pattern:     "*/*.txt",
-			files: []string{
-				"test/file1.go",
-				"test/file2.txt",
-			},
-			want: []string{
-				"test/file2.txt",
-			},
+			description: "skips pull requests with too many changed lines",
+			max:         20,
+			expected:    []int{3, 2},
 		},
 		{
-			description: "handles prefix matches",
-			pattern:     "foo/",
-			files: []string{
-				"foo/a",
-				"foo/a.txt",
-				"foo/a/b/c/d.txt",
-				"foo",
-				"bar",
-				"bar/a.txt",
-			},
-			want: []string{
-				"foo/a",
-				"foo/a.txt",
-				"foo/a/b/c/d.txt",
-			},
+			description: "bounds are inclusive",
+			min:         20,
+			max:         20,
+			expected:    []int{2},
 		},
 	}
-	for _, tc := range cases {
+
+	for _, tc := range tests {
 		t.Run(tc.description, func(t *testing.T) {
-			got, err := resource.FilterPath(tc.files, tc.pattern)
-			if assert.NoError(t, err) {
-				assert.Equal(t, tc.want, got)
+			var pullRequests []*resource.PullRequest
+			for i, lines := range []int{5000, 20, 5} {
+				p := createTestPR(i+1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+				p.Additions, p.Deletions = lines/2, lines-lines/2
+				pullRequests = append(pullRequests, p)
+			}
+
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns(pullRequests, nil)
+
+			source := resource.Source{
+				Repository:      "itsdalmo/test-repository",
+				AccessToken:     "oauthtoken",
+				MinChangedLines: tc.min,
+				MaxChangedLines: tc.max,
+			}
+			output, err := resource.Check(resource.CheckRequest{Source: source, Version: resource.Version{PR: "100"}}, github)
+			require.NoError(t, err)
+
+			var numbers []int
+			for _, v := range output[1:] {
+				n, _ := strconv.Atoi(v.PR)
+				numbers = append(numbers, n)
 			}
+			assert.Equal(t, tc.expected, numbers)
 		})
 	}
 }
 
-func TestFilterIgnorePath(t *testing.T) {
-	cases := []struct {
+func TestCheckTeamApprovals(t *testing.T) {
+	teams := map[string][]string{
+		"platform-team": {"alice", "bob"},
+		"security":      {"carol"},
+	}
+
+	tests := []struct {
 		description string
-		pattern     string
-		files       []string
-		want        []string
+		approvedBy  []string
+		expected    bool
 	}{
 		{
-			description: "excludes all matching files",
-			pattern:     "*.txt",
-			files: []string{
-				"file1.txt",
-				"test/file2.txt",
-			},
-			want: []string{
-				"test/file2.txt",
-			},
+			description: "emits a version when each team has approved",
+			approvedBy:  []string{"alice", "carol"},
+			expected:    true,
 		},
 		{
-			description: "works with wildcard",
-			pattern:     "test/*",
-			files: []string{
-				"file1.txt",
-				"test/file2.txt",
-			},
-			want: []string{
-				"file1.txt",
-			},
+			description: "skips the pull request when a team has not approved",
+			approvedBy:  []string{"alice", "bob"},
 		},
 		{
-			description: "includes unmatched files",
-			pattern:     "*/*.txt",
-			files: []string{
-				"test/file1.go",
-				"test/file2.txt",
-			},
-			want: []string{
-				"test/file1.go",
-			},
+			description: "counts each reviewer once",
+			approvedBy:  []string{"carol", "carol"},
 		},
-		{
-			description: "handles prefix matches",
-			pattern:     "foo/",
-			files: []string{
-				"foo/a",
-				"foo/a.txt",
-				"foo/a/b/c/d.txt",
-				"foo",
-				"bar",
-				"bar/a.txt",
-			},
-			want: []string{
-				"foo",
-				"bar",
-				"bar/a.txt",
-			},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			pulls := []*resource.PullRequest{
+				createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+				createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+			}
+			for _, p := range pulls {
+				p.ApprovedBy = tc.approvedBy
+			}
+
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns(pulls, nil)
+			github.ListTeamMembersStub = func(team string) ([]string, error) {
+				return teams[team], nil
+			}
+
+			source := resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+				RequiredReviewApprovals: resource.ReviewApprovals{
+					Teams: map[string]int{"platform-team": 1, "security": 1},
+				},
+			}
+			output, err := resource.Check(resource.CheckRequest{Source: source, Version: resource.Version{PR: "100"}}, github)
+			require.NoError(t, err)
+
+			if tc.expected {
+				assert.Len(t, output, 3)
+			} else {
+				assert.Len(t, output, 1)
+			}
+			// Team members are only listed once per check.
+			assert.True(t, github.ListTeamMembersCallCount() <= 2)
+		})
+	}
+}
+
+func TestCheckExcludeAuthorTeamApprovals(t *testing.T) {
+	teams := map[string][]string{
+		"platform-team": {"alice", "bob"},
+		"security":      {"carol"},
+	}
+
+	tests := []struct {
+		description string
+		approvedBy  []string
+		required    resource.ReviewApprovals
+		expected    bool
+	}{
+		{
+			description: "does not count approvals from the team of the author",
+			approvedBy:  []string{"bob", "carol"},
+			required:    resource.ReviewApprovals{Count: 2},
+		},
+		{
+			description: "counts approvals from other teams",
+			approvedBy:  []string{"bob", "carol", "dave"},
+			required:    resource.ReviewApprovals{Count: 2},
+			expected:    true,
+		},
+		{
+			description: "applies to team requirements",
+			approvedBy:  []string{"bob", "carol"},
+			required:    resource.ReviewApprovals{Teams: map[string]int{"platform-team": 1}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			pull := createTestPR(1, "master", false, false, len(tc.approvedBy), nil, false, githubv4.PullRequestStateOpen)
+			pull.Author.Login = "alice"
+			pull.ApprovedBy = tc.approvedBy
+
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns([]*resource.PullRequest{pull}, nil)
+			github.ListTeamMembersStub = func(team string) ([]string, error) {
+				return teams[team], nil
+			}
+			github.ListUserTeamsReturns([]string{"platform-team"}, nil)
+
+			source := resource.Source{
+				Repository:                 "itsdalmo/test-repository",
+				AccessToken:                "oauthtoken",
+				RequiredReviewApprovals:    tc.required,
+				ExcludeAuthorTeamApprovals: true,
+			}
+			output, err := resource.Check(resource.CheckRequest{Source: source}, github)
+			require.NoError(t, err)
+
+			if tc.expected {
+				assert.Len(t, output, 1)
+			} else {
+				assert.Len(t, output, 0)
+			}
+			if assert.Equal(t, 1, github.ListUserTeamsCallCount()) {
+				assert.Equal(t, "alice", github.ListUserTeamsArgsForCall(0))
+			}
+		})
+	}
+}
+
+func TestCheckMergeQueue(t *testing.T) {
+	previous := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+
+	entry := func(number int, date time.Time) *resource.MergeQueueEntry {
+		e := &resource.MergeQueueEntry{}
+		e.PullRequest.Number = number
+		e.PullRequest.HeadRefOID = "oid" + strconv.Itoa(number)
+		e.HeadCommit.OID = "mergegroup" + strconv.Itoa(number)
+		e.HeadCommit.CommittedDate = githubv4.DateTime{Time: date}
+		e.BaseCommit.OID = "basesha"
+		return e
+	}
+
+	// Merge groups are only versions if their pull requests are listed and match the source,
+	// even if the pull requests themselves have not been updated.
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns([]*resource.PullRequest{
+		previous,
+		createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		createTestPR(3, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		createTestPR(4, "master", false, false, 0, nil, true, githubv4.PullRequestStateOpen),
+	}, nil)
+	github.ListMergeQueueEntriesReturns([]*resource.MergeQueueEntry{
+		entry(2, previous.Tip.CommittedDate.Time.Add(time.Minute)),
+		entry(3, previous.Tip.CommittedDate.Time.Add(-time.Minute)),
+		entry(4, previous.Tip.CommittedDate.Time.Add(time.Minute)),
+		entry(5, previous.Tip.CommittedDate.Time.Add(time.Minute)),
+	}, nil)
+
+	input := resource.CheckRequest{
+		Source: resource.Source{
+			Repository:   "itsdalmo/test-repository",
+			AccessToken:  "oauthtoken",
+			BaseBranch:   "master",
+			MergeQueue:   true,
+			IgnoreDrafts: true,
+		},
+		Version: resource.NewVersion(previous),
+	}
+	output, err := resource.Check(input, github)
+	require.NoError(t, err)
+
+	if assert.Equal(t, 1, github.ListMergeQueueEntriesCallCount()) {
+		assert.Equal(t, "master", github.ListMergeQueueEntriesArgsForCall(0))
+	}
+	if assert.Len(t, output, 2) {
+		assert.Equal(t, resource.Version{
+			PR:            "2",
+			Commit:        "mergegroup2",
+			CommittedDate: previous.Tip.CommittedDate.Time.Add(time.Minute),
+			State:         githubv4.PullRequestStateOpen,
+			BaseSHA:       "basesha",
+			MergeGroup:    "gh-readonly-queue/master/pr-2-oid2",
+			HeadSHA:       "oid2",
+		}, output[1])
+	}
+
+	// Merge groups of other pull requests are ignored when the source is pinned to one.
+	input.Source.Number = 4
+	input.Source.IgnoreDrafts = false
+	output, err = resource.Check(input, github)
+	require.NoError(t, err)
+	if assert.Len(t, output, 2) {
+		assert.Equal(t, "gh-readonly-queue/master/pr-4-oid4", output[1].MergeGroup)
+	}
+
+	input.Source.StateLookback = resource.Duration(time.Hour)
+	assert.EqualError(t, input.Source.Validate(), "number cannot be combined with pages_per_check, search_query_extra or state_lookback")
+}
+
+func TestCheckOrdering(t *testing.T) {
+	previous := createTestPR(20, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+
+	// Pull requests 10 and 2 were updated at the same time, and 3 is listed twice.
+	pr10 := createTestPR(10, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	pr2 := createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	pr2.Tip.CommittedDate = pr10.Tip.CommittedDate
+	pr3 := createTestPR(3, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns([]*resource.PullRequest{pr3, pr10, previous, pr3, pr2}, nil)
+
+	input := resource.CheckRequest{
+		Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+		Version: resource.NewVersion(previous),
+	}
+	output, err := resource.Check(input, github)
+	require.NoError(t, err)
+
+	expected := resource.CheckResponse{
+		resource.NewVersion(previous),
+		resource.NewVersion(pr2),
+		resource.NewVersion(pr10),
+		resource.NewVersion(pr3),
+	}
+	assert.Equal(t, expected, output)
+}
+
+func TestCheckStateTransitions(t *testing.T) {
+	source := resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		States:      []githubv4.PullRequestState{githubv4.PullRequestStateOpen, githubv4.PullRequestStateMerged},
+	}
+	open := createTestPR(3, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+
+	tests := []struct {
+		description string
+		update      func(p *resource.PullRequest)
+	}{
+		{
+			description: "check emits a version when a pull request is merged without new commits",
+			update: func(p *resource.PullRequest) {
+				p.State = githubv4.PullRequestStateMerged
+				p.MergedAt = githubv4.DateTime{Time: p.Tip.CommittedDate.Add(time.Hour)}
+			},
+		},
+		{
+			description: "check emits a version when a pull request is reopened without new commits",
+			update: func(p *resource.PullRequest) {
+				p.ReopenedAt = githubv4.DateTime{Time: p.Tip.CommittedDate.Add(time.Hour)}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			updated := *open
+			tc.update(&updated)
+
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns([]*resource.PullRequest{&updated}, nil)
+
+			input := resource.CheckRequest{Source: source, Version: resource.NewVersion(open)}
+			output, err := resource.Check(input, github)
+			require.NoError(t, err)
+			assert.Equal(t, resource.CheckResponse{resource.NewVersion(open), resource.NewVersion(&updated)}, output)
+		})
+	}
+}
+
+func TestCheckAllNewVersions(t *testing.T) {
+	previous := createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	latest := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	stale := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	stale.Tip.OID = "stale"
+	stale.Tip.CommittedDate = githubv4.DateTime{Time: latest.Tip.CommittedDate.Add(-time.Hour)}
+
+	date := latest.Tip.CommittedDate.Time
+	commits := []resource.PullRequestCommit{
+		{OID: "old", CommittedDate: date.AddDate(0, 0, -2)},
+		{OID: "intermediate", CommittedDate: date.Add(-12 * time.Hour)},
+		{OID: "oid1", CommittedDate: date},
+	}
+	intermediate := resource.NewVersion(latest)
+	intermediate.Commit = "intermediate"
+	intermediate.CommittedDate = date.Add(-12 * time.Hour)
+	disabled := false
+
+	tests := []struct {
+		description   string
+		source        resource.Source
+		version       resource.Version
+		pulls         []*resource.PullRequest
+		expected      resource.CheckResponse
+		expectCommits bool
+	}{
+		{
+			description: "returns the latest version of each pull request by default",
+			version:     resource.NewVersion(previous),
+			pulls:       []*resource.PullRequest{stale, latest},
+			expected:    resource.CheckResponse{resource.NewVersion(previous), resource.NewVersion(latest)},
+		},
+		{
+			description:   "returns the commits pushed since the previous version with all_new_versions",
+			source:        resource.Source{AllNewVersions: true},
+			version:       resource.NewVersion(previous),
+			pulls:         []*resource.PullRequest{latest},
+			expected:      resource.CheckResponse{resource.NewVersion(previous), intermediate, resource.NewVersion(latest)},
+			expectCommits: true,
+		},
+		{
+			description:   "returns the commits pushed since the previous version without latest_per_pr",
+			source:        resource.Source{LatestPerPR: &disabled},
+			version:       resource.NewVersion(previous),
+			pulls:         []*resource.PullRequest{latest},
+			expected:      resource.CheckResponse{resource.NewVersion(previous), intermediate, resource.NewVersion(latest)},
+			expectCommits: true,
+		},
+		{
+			description: "returns only the latest version without a previous version",
+			source:      resource.Source{AllNewVersions: true},
+			pulls:       []*resource.PullRequest{latest},
+			expected:    resource.CheckResponse{resource.NewVersion(latest)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns(tc.pulls, nil)
+			github.ListPullRequestCommitsReturns(commits, nil)
+
+			tc.source.Repository = "itsdalmo/test-repository"
+			tc.source.AccessToken = "oauthtoken"
+			output, err := resource.Check(resource.CheckRequest{Source: tc.source, Version: tc.version}, github)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+			if tc.expectCommits && assert.Equal(t, 1, github.ListPullRequestCommitsCallCount()) {
+				assert.Equal(t, "1", github.ListPullRequestCommitsArgsForCall(0))
+			} else if !tc.expectCommits {
+				assert.Equal(t, 0, github.ListPullRequestCommitsCallCount())
+			}
+		})
+	}
+}
+
+func TestCheckInitialLookback(t *testing.T) {
+	pulls := []*resource.PullRequest{
+		createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		createTestPR(3, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		createTestPR(10, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+	}
+
+	tests := []struct {
+		description string
+		lookback    resource.Duration
+		skip        bool
+		expected    resource.CheckResponse
+	}{
+		{
+			description: "returns the latest version by default",
+			expected:    resource.CheckResponse{resource.NewVersion(pulls[0])},
+		},
+		{
+			description: "returns the versions within the initial lookback",
+			lookback:    resource.Duration(96 * time.Hour),
+			expected:    resource.CheckResponse{resource.NewVersion(pulls[1]), resource.NewVersion(pulls[0])},
+		},
+		{
+			description: "returns the latest version within the initial lookback when skipping backfill",
+			lookback:    resource.Duration(96 * time.Hour),
+			skip:        true,
+			expected:    resource.CheckResponse{resource.NewVersion(pulls[0])},
+		},
+		{
+			description: "returns no version if no pull request was updated within the initial lookback",
+			lookback:    resource.Duration(12 * time.Hour),
+			skip:        true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns(pulls, nil)
+
+			source := resource.Source{
+				Repository:      "itsdalmo/test-repository",
+				AccessToken:     "oauthtoken",
+				InitialLookback: tc.lookback,
+				SkipBackfill:    tc.skip,
+			}
+			require.NoError(t, source.Validate())
+			output, err := resource.Check(resource.CheckRequest{Source: source}, github)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+		})
+	}
+
+	// Without initial_lookback, skip_backfill would do nothing.
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken", SkipBackfill: true}
+	assert.EqualError(t, source.Validate(), "skip_backfill requires initial_lookback")
+}
+
+func TestContainsSkipCI(t *testing.T) {
+	tests := []struct {
+		description string
+		message     string
+		want        bool
+	}{
+		{
+			description: "does not just match any symbol in the regexp",
+			message:     "(",
+			want:        false,
+		},
+		{
+			description: "does not match when it should not",
+			message:     "test",
+			want:        false,
+		},
+		{
+			description: "matches [ci skip]",
+			message:     "[ci skip]",
+			want:        true,
+		},
+		{
+			description: "matches [skip ci]",
+			message:     "[skip ci]",
+			want:        true,
+		},
+		{
+			description: "matches trailing skip ci",
+			message:     "trailing [skip ci]",
+			want:        true,
+		},
+		{
+			description: "matches leading skip ci",
+			message:     "[skip ci] leading",
+			want:        true,
+		},
+		{
+			description: "is case insensitive",
+			message:     "case[Skip CI]insensitive",
+			want:        true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			got := resource.ContainsSkipCI(tc.message)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestFilterPath(t *testing.T) {
+	cases := []struct {
+		description string
+		pattern     string
+		files       []string
+		want        []string
+	}{
+		{
+			description: "returns all matching files",
+			pattern:     "*.txt",
+			files: []string{
+				"file1.txt",
+				"test/file2.txt",
+			},
+			want: []string{
+				"file1.txt",
+			},
+		},
+		{
+			description: "works with wildcard",
+			pattern:     "test/*",
+			files: []string{
+				"file1.txt",
+				"test/file2.txt",
+			},
+			want: []string{
+				"test/file2.txt",
+			},
+		},
+		{
+			description: "excludes unmatched files",
+			pattern:     "*/*.txt",
+			files: []string{
+				"test/file1.go",
+				"test/file2.txt",
+			},
+			want: []string{
+				"test/file2.txt",
+			},
+		},
+		{
+			description: "handles prefix matches",
+			pattern:     "foo/",
+			files: []string{
+				"foo/a",
+				"foo/a.txt",
+				"foo/a/b/c/d.txt",
+				"foo",
+				"bar",
+				"bar/a.txt",
+			},
+			want: []string{
+				"foo/a",
+				"foo/a.txt",
+				"foo/a/b/c/d.txt",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			got, err := resource.FilterPath(tc.files, tc.pattern)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterIgnorePath(t *testing.T) {
+	cases := []struct {
+		description string
+		pattern     string
+		files       []string
+		want        []string
+	}{
+		{
+			description: "excludes all matching files",
+			pattern:     "*.txt",
+			files: []string{
+				"file1.txt",
+				"test/file2.txt",
+			},
+			want: []string{
+				"test/file2.txt",
+			},
+		},
+		{
+			description: "works with wildcard",
+			pattern:     "test/*",
+			files: []string{
+				"file1.txt",
+				"test/file2.txt",
+			},
+			want: []string{
+				"file1.txt",
+			},
+		},
+		{
+			description: "includes unmatched files",
+			pattern:     "*/*.txt",
+			files: []string{
+				"test/file1.go",
+				"test/file2.txt",
+			},
+			want: []string{
+				"test/file1.go",
+			},
+		},
+		{
+			description: "handles prefix matches",
+			pattern:     "foo/",
+			files: []string{
+				"foo/a",
+				"foo/a.txt",
+				"foo/a/b/c/d.txt",
+				"foo",
+				"bar",
+				"bar/a.txt",
+			},
+			want: []string{
+				"foo",
+				"bar",
+				"bar/a.txt",
+			},
 		},
 	}
 	for _, tc := range cases {
@@ -550,3 +1403,615 @@ func TestIsInsidePath(t *testing.T) {
 		})
 	}
 }
+
+func changedFiles(paths ...string) []resource.ChangedFileObject {
+	var files []resource.ChangedFileObject
+	for _, p := range paths {
+		files = append(files, resource.ChangedFileObject{Path: p, ChangeType: "MODIFIED"})
+	}
+	return files
+}
+
+func TestCheckTriggerOnReady(t *testing.T) {
+	draft := createTestPR(3, "master", false, false, 0, nil, true, githubv4.PullRequestStateOpen)
+	ready := *draft
+	ready.IsDraft = false
+	ready.ReadyAt = githubv4.DateTime{Time: draft.Tip.CommittedDate.Add(time.Hour)}
+
+	tests := []struct {
+		description    string
+		triggerOnReady bool
+		expected       resource.CheckResponse
+	}{
+		{
+			description: "check does not emit a version when a draft is marked as ready by default",
+			expected:    resource.CheckResponse{resource.NewVersion(draft)},
+		},
+		{
+			description:    "check emits a version when a draft is marked as ready",
+			triggerOnReady: true,
+			expected: resource.CheckResponse{
+				resource.NewVersion(draft),
+				{PR: "3", Commit: "oid3", CommittedDate: ready.ReadyAt.Time, State: githubv4.PullRequestStateOpen},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns([]*resource.PullRequest{&ready}, nil)
+
+			source := resource.Source{
+				Repository:     "itsdalmo/test-repository",
+				AccessToken:    "oauthtoken",
+				TriggerOnReady: tc.triggerOnReady,
+			}
+			output, err := resource.Check(resource.CheckRequest{Source: source, Version: resource.NewVersion(draft)}, github)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+		})
+	}
+}
+
+func TestCheckTriggerPhrase(t *testing.T) {
+	previous := createTestPR(3, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+
+	comment := func(id int64, login, body string, after time.Duration) resource.CommentObject {
+		c := resource.CommentObject{DatabaseID: id, Body: body}
+		c.Author.Login = login
+		c.CreatedAt = githubv4.DateTime{Time: previous.Tip.CommittedDate.Add(after)}
+		return c
+	}
+
+	tests := []struct {
+		description string
+		users       []string
+		comments    []resource.CommentObject
+		expected    resource.CheckResponse
+	}{
+		{
+			description: "emits a version for the last trigger comment",
+			comments: []resource.CommentObject{
+				comment(1, "alice", "/retest", time.Hour),
+				comment(2, "bob", "Flaky test.\n /retest \n", 2*time.Hour),
+				comment(3, "bob", "/retest-all", 3*time.Hour),
+			},
+			expected: resource.CheckResponse{
+				resource.NewVersion(previous),
+				{PR: "3", Commit: "oid3", CommittedDate: previous.Tip.CommittedDate.Add(2 * time.Hour), State: githubv4.PullRequestStateOpen, Comment: "2"},
+			},
+		},
+		{
+			description: "ignores comments from other users",
+			users:       []string{"alice"},
+			comments: []resource.CommentObject{
+				comment(1, "alice", "/retest", time.Hour),
+				comment(2, "bob", "/retest", 2*time.Hour),
+			},
+			expected: resource.CheckResponse{
+				resource.NewVersion(previous),
+				{PR: "3", Commit: "oid3", CommittedDate: previous.Tip.CommittedDate.Add(time.Hour), State: githubv4.PullRequestStateOpen, Comment: "1"},
+			},
+		},
+		{
+			description: "ignores comments made before the last version",
+			comments: []resource.CommentObject{
+				comment(1, "alice", "/retest", -time.Hour),
+			},
+			expected: resource.CheckResponse{resource.NewVersion(previous)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			pull := *previous
+			pull.Comments = tc.comments
+
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns([]*resource.PullRequest{&pull}, nil)
+
+			source := resource.Source{
+				Repository:         "itsdalmo/test-repository",
+				AccessToken:        "oauthtoken",
+				TriggerPhrase:      "/retest",
+				TriggerPhraseUsers: tc.users,
+			}
+			output, err := resource.Check(resource.CheckRequest{Source: source, Version: resource.NewVersion(previous)}, github)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+		})
+	}
+}
+
+func TestCheckVersionKey(t *testing.T) {
+	pull := createTestPR(1, "master", false, false, 2, []string{"bug", "approved"}, false, githubv4.PullRequestStateOpen)
+	pull.BaseRefOID = "basesha"
+	date := pull.Tip.CommittedDate.Time
+
+	tests := []struct {
+		description string
+		key         []string
+		pin         bool
+		expected    resource.Version
+	}{
+		{
+			description: "commit by default",
+			expected:    resource.Version{PR: "1", Commit: "oid1", CommittedDate: date, State: githubv4.PullRequestStateOpen},
+		},
+		{
+			description: "includes the base commit if it is pinned",
+			pin:         true,
+			expected:    resource.Version{PR: "1", Commit: "oid1", CommittedDate: date, State: githubv4.PullRequestStateOpen, BaseSHA: "basesha"},
+		},
+		{
+			description: "commit only",
+			key:         []string{"commit"},
+			expected:    resource.Version{PR: "1", Commit: "oid1", CommittedDate: date, State: githubv4.PullRequestStateOpen},
+		},
+		{
+			description: "commit and approvals",
+			key:         []string{"commit", "approvals"},
+			expected:    resource.Version{PR: "1", Commit: "oid1", CommittedDate: date, State: githubv4.PullRequestStateOpen, ApprovedReviewCount: "2"},
+		},
+		{
+			description: "commit and labels",
+			key:         []string{"commit", "labels"},
+			expected:    resource.Version{PR: "1", Commit: "oid1", CommittedDate: date, State: githubv4.PullRequestStateOpen, Labels: "approved,bug"},
+		},
+		{
+			description: "commit and base commit",
+			key:         []string{"commit", "base_sha"},
+			expected:    resource.Version{PR: "1", Commit: "oid1", CommittedDate: date, State: githubv4.PullRequestStateOpen, BaseSHA: "basesha"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns([]*resource.PullRequest{pull}, nil)
+
+			source := resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+				VersionKey:  tc.key,
+				PinBaseSHA:  tc.pin,
+			}
+			output, err := resource.Check(resource.CheckRequest{Source: source}, github)
+			require.NoError(t, err)
+			assert.Equal(t, resource.CheckResponse{tc.expected}, output)
+		})
+	}
+}
+
+func TestCheckBaseMoves(t *testing.T) {
+	pull := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	pull.BaseRefOID = "base1"
+
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns([]*resource.PullRequest{pull}, nil)
+
+	source := resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+	}
+	output, err := resource.Check(resource.CheckRequest{Source: source}, github)
+	require.NoError(t, err)
+	require.Len(t, output, 1)
+	previous := output[0]
+
+	// The version does not change when only the base moves, unless the base is pinned.
+	moved := *pull
+	moved.BaseRefOID = "base2"
+	github.ListPullRequestsReturns([]*resource.PullRequest{&moved}, nil)
+
+	output, err = resource.Check(resource.CheckRequest{Source: source, Version: previous}, github)
+	require.NoError(t, err)
+	assert.Equal(t, resource.CheckResponse{previous}, output)
+	assert.Equal(t, "", output[0].BaseSHA)
+}
+
+func TestCheckVersionKeyChanges(t *testing.T) {
+	tests := []struct {
+		description string
+		key         []string
+		track       bool
+		labeled     bool
+		approved    bool
+		expected    bool
+	}{
+		{
+			description: "label changes are new versions with labels in the key",
+			key:         []string{"commit", "labels"},
+			labeled:     true,
+			expected:    true,
+		},
+		{
+			description: "approval changes are new versions with approvals in the key",
+			key:         []string{"commit", "approvals"},
+			approved:    true,
+			expected:    true,
+		},
+		{
+			description: "approval changes are new versions with track_review_approvals",
+			track:       true,
+			approved:    true,
+			expected:    true,
+		},
+		{
+			description: "label changes are not new versions without labels in the key",
+			key:         []string{"commit", "approvals"},
+			labeled:     true,
+		},
+		{
+			description: "approval changes are not new versions by default",
+			approved:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			pull := createTestPR(1, "master", false, false, 1, []string{"bug"}, false, githubv4.PullRequestStateOpen)
+			source := resource.Source{
+				Repository:           "itsdalmo/test-repository",
+				AccessToken:          "oauthtoken",
+				VersionKey:           tc.key,
+				TrackReviewApprovals: tc.track,
+			}
+			require.NoError(t, source.Validate())
+
+			// The previous version is of the same commit, before the labels or approvals changed.
+			previous := resource.NewVersion(pull)
+			if tc.labeled {
+				pull.LabeledAt = githubv4.DateTime{Time: time.Now()}
+			}
+			if tc.approved {
+				pull.ApprovedAt = githubv4.DateTime{Time: time.Now()}
+			}
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns([]*resource.PullRequest{pull}, nil)
+
+			output, err := resource.Check(resource.CheckRequest{Source: source, Version: previous}, github)
+			require.NoError(t, err)
+			if !tc.expected {
+				assert.Equal(t, resource.CheckResponse{previous}, output)
+				return
+			}
+			if assert.Len(t, output, 2) {
+				assert.Equal(t, "oid1", output[1].Commit)
+				assert.True(t, output[1].CommittedDate.After(previous.CommittedDate))
+			}
+		})
+	}
+
+	// track_review_approvals cannot be combined with a version key which leaves out approvals.
+	source := resource.Source{
+		Repository:           "itsdalmo/test-repository",
+		AccessToken:          "oauthtoken",
+		VersionKey:           []string{"commit", "labels"},
+		TrackReviewApprovals: true,
+	}
+	assert.EqualError(t, source.Validate(), "track_review_approvals conflicts with a version_key without approvals")
+
+	// Neither can pin_base_sha with a version key which leaves out the base commit.
+	source = resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		VersionKey:  []string{"commit"},
+		PinBaseSHA:  true,
+	}
+	assert.EqualError(t, source.Validate(), "pin_base_sha conflicts with a version_key without base_sha")
+}
+
+func TestCheckComponents(t *testing.T) {
+	files := map[int][]string{
+		1: {"docs/README.md"},
+		2: {"api/main.go", "web/index.html"},
+		3: {"api/main.go"},
+		4: {"api/main.go"},
+	}
+	pulls := make(map[int]*resource.PullRequest)
+	for n, f := range files {
+		pulls[n] = createTestPR(n, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+		pulls[n].Files = changedFiles(f...)
+		pulls[n].FilesComplete = true
+	}
+	version := func(n int, component string) resource.Version {
+		v := resource.NewVersion(pulls[n])
+		v.Component = component
+		return v
+	}
+
+	tests := []struct {
+		description string
+		version     resource.Version
+		expected    resource.CheckResponse
+	}{
+		{
+			description: "returns a version for each component affected by the latest pull request",
+			expected:    resource.CheckResponse{version(2, "api"), version(2, "web")},
+		},
+		{
+			description: "returns a version for each affected component after the previous version",
+			version:     version(4, "api"),
+			expected:    resource.CheckResponse{version(4, "api"), version(3, "api"), version(2, "api"), version(2, "web")},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns([]*resource.PullRequest{pulls[1], pulls[2], pulls[3], pulls[4]}, nil)
+
+			source := resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+				Components:  map[string][]string{"api": {"api/*"}, "web": {"web"}},
+			}
+			output, err := resource.Check(resource.CheckRequest{Source: source, Version: tc.version}, github)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+			assert.Equal(t, 0, github.ListModifiedFilesCallCount())
+		})
+	}
+}
+
+func TestCheckVersionWithoutDate(t *testing.T) {
+	pulls := []*resource.PullRequest{
+		createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		createTestPR(3, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+	}
+
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns(pulls, nil)
+
+	// A version stored by an older release of the resource.
+	previous := resource.Version{PR: "2", Commit: "oid2"}
+	input := resource.CheckRequest{
+		Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+		Version: previous,
+	}
+	output, err := resource.Check(input, github)
+	require.NoError(t, err)
+
+	// Only pull requests updated after the commit of the previous version are new.
+	if assert.Len(t, output, 2) {
+		assert.Equal(t, previous, output[0])
+		assert.Equal(t, resource.NewVersion(pulls[0]), output[1])
+	}
+}
+
+func TestCheckTriggerConfig(t *testing.T) {
+	previous := createTestPR(5, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	draft := createTestPR(1, "master", false, false, 0, nil, true, githubv4.PullRequestStateOpen)
+	docs := createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	src := createTestPR(3, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	develop := createTestPR(4, "develop", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	for p, files := range map[*resource.PullRequest][]resource.ChangedFileObject{
+		draft:   changedFiles("src/main.go"),
+		docs:    changedFiles("docs/index.md"),
+		src:     changedFiles("src/main.go"),
+		develop: changedFiles("docs/index.md"),
+	} {
+		p.Files, p.FilesComplete = files, true
+	}
+
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns([]*resource.PullRequest{draft, docs, src, develop, previous}, nil)
+	github.GetFileContentsStub = func(path, ref string) ([]byte, error) {
+		if ref == "master" {
+			return []byte("paths:\n  - src/*\nignore_drafts: true\n"), nil
+		}
+		return nil, nil
+	}
+
+	source := resource.Source{
+		Repository:    "itsdalmo/test-repository",
+		AccessToken:   "oauthtoken",
+		TriggerConfig: ".concourse/trigger.json",
+	}
+	output, err := resource.Check(resource.CheckRequest{Source: source, Version: resource.NewVersion(previous)}, github)
+	require.NoError(t, err)
+	assert.Equal(t, resource.CheckResponse{
+		resource.NewVersion(previous),
+		resource.NewVersion(develop),
+		resource.NewVersion(src),
+	}, output)
+
+	// The configuration is fetched once per base branch.
+	assert.Equal(t, 2, github.GetFileContentsCallCount())
+	path, _ := github.GetFileContentsArgsForCall(0)
+	assert.Equal(t, ".concourse/trigger.json", path)
+}
+
+func TestParseTriggerConfig(t *testing.T) {
+	drafts := true
+	tests := []struct {
+		description string
+		config      string
+		expected    *resource.TriggerConfig
+		err         string
+	}{
+		{
+			description: "parses yaml",
+			config:      "paths:\n  - src/*\nignore_drafts: true\n",
+			expected:    &resource.TriggerConfig{Paths: []string{"src/*"}, IgnoreDrafts: &drafts},
+		},
+		{
+			description: "parses json",
+			config:      `{"paths": ["src/*"], "ignore_drafts": true}`,
+			expected:    &resource.TriggerConfig{Paths: []string{"src/*"}, IgnoreDrafts: &drafts},
+		},
+		{
+			description: "rejects unknown fields",
+			config:      "path: src/*\n",
+			err:         "field path not found",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			c, err := resource.ParseTriggerConfig([]byte(tc.config))
+			if tc.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.err)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, c)
+		})
+	}
+}
+
+func TestCheckAPIBudget(t *testing.T) {
+	previous := createTestPR(4, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	pulls := []*resource.PullRequest{
+		createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		createTestPR(2, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		createTestPR(3, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		previous,
+	}
+
+	// The budget allows listing the files of two pull requests.
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns(pulls, nil)
+	var listed []int
+	github.ListModifiedFilesStub = func(pr int, _ func([]resource.ChangedFileObject) bool) ([]resource.ChangedFileObject, error) {
+		if len(listed) == 2 {
+			return nil, resource.ErrAPIBudgetExhausted
+		}
+		listed = append(listed, pr)
+		return changedFiles("src/main.go"), nil
+	}
+
+	// The merge queue cannot be listed either.
+	github.ListMergeQueueEntriesReturns(nil, fmt.Errorf("failed to list merge queue of master: %w", resource.ErrAPIBudgetExhausted))
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+	source := resource.Source{
+		Repository:        "itsdalmo/test-repository",
+		AccessToken:       "oauthtoken",
+		Paths:             []string{"src/*"},
+		CacheDir:          dir,
+		APIBudgetPerCheck: 2,
+		MergeQueue:        true,
+	}
+	output, err := resource.Check(resource.CheckRequest{Source: source, Version: resource.NewVersion(previous)}, github)
+	require.NoError(t, err)
+
+	// The most recently updated pull requests are prioritized.
+	assert.Equal(t, []int{1, 2}, listed)
+	assert.Equal(t, resource.CheckResponse{
+		resource.NewVersion(previous),
+		resource.NewVersion(pulls[1]),
+		resource.NewVersion(pulls[0]),
+	}, output)
+
+	// The skipped pull request and merge groups are considered by the next check, even though
+	// the latest version is more recent than them.
+	group := &resource.MergeQueueEntry{}
+	group.PullRequest.Number = 3
+	group.HeadCommit.OID = "group3"
+	group.HeadCommit.CommittedDate = githubv4.DateTime{Time: time.Now().AddDate(0, 0, -3)}
+	group.BaseCommit.OID = "base"
+	github.ListMergeQueueEntriesReturns([]*resource.MergeQueueEntry{group}, nil)
+	listed = nil
+	github.ListModifiedFilesStub = func(pr int, _ func([]resource.ChangedFileObject) bool) ([]resource.ChangedFileObject, error) {
+		listed = append(listed, pr)
+		return changedFiles("src/main.go"), nil
+	}
+
+	output, err = resource.Check(resource.CheckRequest{Source: source, Version: output[len(output)-1]}, github)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, listed)
+	if assert.Len(t, output, 3) {
+		assert.Equal(t, "3", output[0].PR)
+		assert.Equal(t, "oid3", output[0].Commit)
+		assert.Equal(t, "group3", output[1].Commit)
+		assert.Equal(t, resource.NewVersion(pulls[0]), output[2])
+	}
+
+	// The budget requires the cache directory to keep the skipped pull requests in.
+	source.CacheDir = ""
+	assert.EqualError(t, source.Validate(), "cache_dir must be set together with api_budget_per_check")
+}
+
+func TestCheckForkAuthors(t *testing.T) {
+	previous := createTestPR(4, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	bot := createTestPR(1, "master", false, true, 0, nil, false, githubv4.PullRequestStateOpen)
+	bot.Author.Login = "sync-bot"
+	fork := createTestPR(2, "master", false, true, 0, nil, false, githubv4.PullRequestStateOpen)
+	fork.Author.Login = "mallory"
+	local := createTestPR(3, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	local.Author.Login = "alice"
+
+	tests := []struct {
+		description string
+		allow       []string
+		deny        []string
+		expected    resource.CheckResponse
+	}{
+		{
+			description: "only allowed authors are built from forks",
+			allow:       []string{"sync-bot", "alice"},
+			expected:    resource.CheckResponse{resource.NewVersion(previous), resource.NewVersion(local), resource.NewVersion(bot)},
+		},
+		{
+			description: "denied authors are not built from forks",
+			deny:        []string{"mallory", "alice"},
+			expected:    resource.CheckResponse{resource.NewVersion(previous), resource.NewVersion(local), resource.NewVersion(bot)},
+		},
+		{
+			description: "all forks are built by default",
+			expected:    resource.CheckResponse{resource.NewVersion(previous), resource.NewVersion(local), resource.NewVersion(fork), resource.NewVersion(bot)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns([]*resource.PullRequest{bot, fork, local, previous}, nil)
+
+			source := resource.Source{
+				Repository:       "itsdalmo/test-repository",
+				AccessToken:      "oauthtoken",
+				ForkAuthorsAllow: tc.allow,
+				ForkAuthorsDeny:  tc.deny,
+			}
+			output, err := resource.Check(resource.CheckRequest{Source: source, Version: resource.NewVersion(previous)}, github)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+		})
+	}
+}
+
+func TestCheckHeadRepository(t *testing.T) {
+	previous := createTestPR(4, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	deleted := createTestPR(1, "master", false, true, 0, nil, false, githubv4.PullRequestStateOpen)
+	deleted.HeadRepository = nil
+	archived := createTestPR(2, "master", false, true, 0, nil, false, githubv4.PullRequestStateOpen)
+	archived.HeadRepository = &resource.HeadRepositoryObject{IsArchived: true}
+	fork := createTestPR(3, "master", false, true, 0, nil, false, githubv4.PullRequestStateOpen)
+
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns([]*resource.PullRequest{deleted, archived, fork, previous}, nil)
+
+	// Their heads can still be fetched from the pull requests by default.
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	output, err := resource.Check(resource.CheckRequest{Source: source, Version: resource.NewVersion(previous)}, github)
+	require.NoError(t, err)
+	assert.Equal(t, resource.CheckResponse{
+		resource.NewVersion(previous),
+		resource.NewVersion(fork),
+		resource.NewVersion(archived),
+		resource.NewVersion(deleted),
+	}, output)
+
+	source.SkipUnavailableForks = true
+	output, err = resource.Check(resource.CheckRequest{Source: source, Version: resource.NewVersion(previous)}, github)
+	require.NoError(t, err)
+	assert.Equal(t, resource.CheckResponse{resource.NewVersion(previous), resource.NewVersion(fork)}, output)
+}
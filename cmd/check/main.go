@@ -4,28 +4,65 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"time"
 
 	"github.com/telia-oss/github-pr-resource"
 )
 
 func main() {
-	var request resource.CheckRequest
+	if len(os.Args) > 1 && os.Args[1] == "--validate" {
+		if err := resource.ValidateConfig(os.Stdin); err != nil {
+			log.Fatalf("invalid configuration:\n%s", err)
+		}
+		log.Println("configuration is valid")
+		return
+	}
 
-	decoder := json.NewDecoder(os.Stdin)
-	decoder.DisallowUnknownFields()
+	// Suggest a check_every interval instead of checking, e.g. when tuning the pipeline.
+	suggest := len(os.Args) > 1 && os.Args[1] == "--suggest-interval"
 
-	if err := decoder.Decode(&request); err != nil {
+	var request resource.CheckRequest
+
+	if err := resource.DecodeRequest(os.Stdin, &request); err != nil {
 		log.Fatalf("failed to unmarshal request: %s", err)
 	}
 
 	if err := request.Source.Validate(); err != nil {
 		log.Fatalf("invalid source configuration: %s", err)
 	}
+	if err := request.Source.ResolveAccessToken(); err != nil {
+		log.Fatalf("failed to resolve access token: %s", err)
+	}
+	resource.SetLogger(resource.NewLoggerFromSource(&request.Source))
+	resource.SetTracer(resource.NewTracerFromSource(&request.Source))
+
 	github, err := resource.NewGithubClient(&request.Source)
 	if err != nil {
 		log.Fatalf("failed to create github manager: %s", err)
 	}
+	if request.Source.VerifyPermissions {
+		if err := github.VerifyPermissions(false); err != nil {
+			log.Fatalf("failed to verify permissions: %s", err)
+		}
+	}
+	if suggest {
+		suggestion, err := resource.SuggestInterval(request, github, time.Now())
+		if err != nil {
+			log.Fatalf("failed to suggest interval: %s", resource.ClassifyError(err))
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(suggestion); err != nil {
+			log.Fatalf("failed to marshal suggestion: %s", err)
+		}
+		return
+	}
+	github.SetAPIBudget(request.Source.APIBudgetPerCheck)
+	span := resource.StartSpan("check", "repository", request.Source.Repository)
 	response, err := resource.Check(request, github)
+	err = resource.ClassifyError(err)
+	span.End(err)
+	if err := resource.FlushTraces(); err != nil {
+		log.Printf("failed to export traces: %s", err)
+	}
 	if err != nil {
 		log.Fatalf("check failed: %s", err)
 	}
@@ -9,12 +9,21 @@ import (
 )
 
 func main() {
-	var request resource.GetRequest
+	// Git runs the resource to ask for the access token on Windows workers.
+	if resource.Askpass() {
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "--validate" {
+		if err := resource.ValidateConfig(os.Stdin); err != nil {
+			log.Fatalf("invalid configuration:\n%s", err)
+		}
+		log.Println("configuration is valid")
+		return
+	}
 
-	decoder := json.NewDecoder(os.Stdin)
-	decoder.DisallowUnknownFields()
+	var request resource.GetRequest
 
-	if err := decoder.Decode(&request); err != nil {
+	if err := resource.DecodeRequest(os.Stdin, &request); err != nil {
 		log.Fatalf("failed to unmarshal request: %s", err)
 	}
 
@@ -25,6 +34,12 @@ func main() {
 	if err := request.Source.Validate(); err != nil {
 		log.Fatalf("invalid source configuration: %s", err)
 	}
+	if err := request.Source.ResolveAccessToken(); err != nil {
+		log.Fatalf("failed to resolve access token: %s", err)
+	}
+	resource.SetLogger(resource.NewLoggerFromSource(&request.Source))
+	resource.SetTracer(resource.NewTracerFromSource(&request.Source))
+
 	git, err := resource.NewGitClient(&request.Source, outputDir, os.Stderr)
 	if err != nil {
 		log.Fatalf("failed to create git client: %s", err)
@@ -33,7 +48,18 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to create github manager: %s", err)
 	}
+	if request.Source.VerifyPermissions {
+		if err := github.VerifyPermissions(false); err != nil {
+			log.Fatalf("failed to verify permissions: %s", err)
+		}
+	}
+	span := resource.StartSpan("get", "repository", request.Source.Repository)
 	response, err := resource.Get(request, github, git, outputDir)
+	err = resource.ClassifyError(err)
+	span.End(err)
+	if err := resource.FlushTraces(); err != nil {
+		log.Printf("failed to export traces: %s", err)
+	}
 	if err != nil {
 		log.Fatalf("get failed: %s", err)
 	}
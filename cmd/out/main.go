@@ -9,12 +9,17 @@ import (
 )
 
 func main() {
-	var request resource.PutRequest
+	if len(os.Args) > 1 && os.Args[1] == "--validate" {
+		if err := resource.ValidateConfig(os.Stdin); err != nil {
+			log.Fatalf("invalid configuration:\n%s", err)
+		}
+		log.Println("configuration is valid")
+		return
+	}
 
-	decoder := json.NewDecoder(os.Stdin)
-	decoder.DisallowUnknownFields()
+	var request resource.PutRequest
 
-	if err := decoder.Decode(&request); err != nil {
+	if err := resource.DecodeRequest(os.Stdin, &request); err != nil {
 		log.Fatalf("failed to unmarshal request: %s", err)
 	}
 
@@ -25,11 +30,29 @@ func main() {
 	if err := request.Source.Validate(); err != nil {
 		log.Fatalf("invalid source configuration: %s", err)
 	}
+	if err := request.Source.ResolveAccessToken(); err != nil {
+		log.Fatalf("failed to resolve access token: %s", err)
+	}
+	resource.SetLogger(resource.NewLoggerFromSource(&request.Source))
+	resource.SetTracer(resource.NewTracerFromSource(&request.Source))
+
 	github, err := resource.NewGithubClient(&request.Source)
 	if err != nil {
 		log.Fatalf("failed to create github manager: %s", err)
 	}
+	github.StrictStatuses = request.Params.Idempotent
+	if request.Source.VerifyPermissions {
+		if err := github.VerifyPermissions(true); err != nil {
+			log.Fatalf("failed to verify permissions: %s", err)
+		}
+	}
+	span := resource.StartSpan("put", "repository", request.Source.Repository)
 	response, err := resource.Put(request, github, sourceDir)
+	err = resource.ClassifyError(err)
+	span.End(err)
+	if err := resource.FlushTraces(); err != nil {
+		log.Printf("failed to export traces: %s", err)
+	}
 	if err != nil {
 		log.Fatalf("put failed: %s", err)
 	}
@@ -0,0 +1,56 @@
+package resource
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// validateComponents checks that every component has valid path patterns.
+func validateComponents(components map[string][]string) error {
+	for _, name := range componentNames(components) {
+		if name == "" {
+			return fmt.Errorf("components cannot have an empty name")
+		}
+		if len(components[name]) == 0 {
+			return fmt.Errorf("component %s must have at least one path", name)
+		}
+		for _, pattern := range components[name] {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("component %s path pattern '%s' is invalid: %s", name, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// componentNames returns the names of the components in order.
+func componentNames(components map[string][]string) []string {
+	var names []string
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AffectedComponents returns the names (in order) of the components with a path matching
+// at least one of the modified files. Paths are matched like paths in the source.
+func AffectedComponents(components map[string][]string, changed []ChangedFileObject) []string {
+	var files []string
+	for _, f := range changed {
+		files = append(files, f.Path)
+	}
+
+	var affected []string
+	for _, name := range componentNames(components) {
+		for _, pattern := range components[name] {
+			// Patterns are validated together with the source.
+			if matched, _ := FilterPath(files, pattern); len(matched) > 0 {
+				affected = append(affected, name)
+				break
+			}
+		}
+	}
+	return affected
+}
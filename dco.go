@@ -0,0 +1,56 @@
+package resource
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// dcoContext is the context of the status set by dco.
+const dcoContext = "dco"
+
+// SignedOff returns true if the commit message has a Signed-off-by trailer with the
+// email of the author of the commit, as required by the Developer Certificate of Origin.
+func SignedOff(c PullRequestCommit) bool {
+	scanner := bufio.NewScanner(strings.NewReader(c.Message))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(strings.ToLower(line), "signed-off-by:") {
+			continue
+		}
+		start, end := strings.LastIndex(line, "<"), strings.LastIndex(line, ">")
+		if start >= 0 && end > start && strings.EqualFold(line[start+1:end], c.AuthorEmail) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDCO returns the status and description of the DCO status of a pull request, which
+// fails unless all of its commits (except merge commits) are signed off by their author.
+func checkDCO(manager Github, prNumber string) (string, string, error) {
+	commits, err := manager.ListPullRequestCommits(prNumber)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list commits: %w", err)
+	}
+	var missing []string
+	for _, c := range commits {
+		if c.Parents > 1 || SignedOff(c) {
+			continue
+		}
+		sha := c.OID
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		missing = append(missing, sha)
+	}
+	switch {
+	case len(missing) == 0:
+		return "success", "All commits are signed off", nil
+	case len(missing) > 5:
+		// Descriptions of statuses are limited to 140 characters.
+		return "failure", fmt.Sprintf("Commits are not signed off by their author: %s and %d more", strings.Join(missing[:5], ", "), len(missing)-5), nil
+	default:
+		return "failure", fmt.Sprintf("Commits are not signed off by their author: %s", strings.Join(missing, ", ")), nil
+	}
+}
@@ -0,0 +1,46 @@
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	resource "github.com/telia-oss/github-pr-resource"
+)
+
+func TestSignedOff(t *testing.T) {
+	tests := []struct {
+		description string
+		message     string
+		expected    bool
+	}{
+		{
+			description: "signed off by the author",
+			message:     "Fix bug\n\nSigned-off-by: Jane Doe <jane@example.com>",
+			expected:    true,
+		},
+		{
+			description: "email is case insensitive",
+			message:     "Fix bug\n\nsigned-off-by: Jane Doe <Jane@Example.com>",
+			expected:    true,
+		},
+		{
+			description: "signed off by someone else",
+			message:     "Fix bug\n\nSigned-off-by: John Doe <john@example.com>",
+		},
+		{
+			description: "not signed off",
+			message:     "Fix bug",
+		},
+		{
+			description: "signed off without email",
+			message:     "Fix bug\n\nSigned-off-by: Jane Doe",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			commit := resource.PullRequestCommit{Message: tc.message, AuthorName: "Jane Doe", AuthorEmail: "jane@example.com"}
+			assert.Equal(t, tc.expected, resource.SignedOff(commit))
+		})
+	}
+}
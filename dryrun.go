@@ -0,0 +1,82 @@
+package resource
+
+import "time"
+
+// dryRunGithub logs the mutations that would be made to pull requests instead of making them.
+type dryRunGithub struct {
+	Github
+}
+
+func (d *dryRunGithub) PostComment(prNumber, comment string) error {
+	logger.Info("dry run: would post comment", "pr", prNumber, "comment", comment)
+	return nil
+}
+
+func (d *dryRunGithub) UpdateComment(commentID, comment string) error {
+	logger.Info("dry run: would update comment", "comment_id", commentID, "comment", comment)
+	return nil
+}
+
+func (d *dryRunGithub) UpdateCommitStatus(commitRef, baseContext, statusContext, status, targetURL, description string) error {
+	logger.Info("dry run: would set status", "commit", commitRef, "base_context", baseContext, "context", statusContext, "status", status, "target_url", targetURL, "description", description)
+	return nil
+}
+
+func (d *dryRunGithub) DeletePreviousComments(prNumber, marker string, before time.Time) error {
+	logger.Info("dry run: would delete previous comments", "pr", prNumber, "marker", marker, "before", before)
+	return nil
+}
+
+func (d *dryRunGithub) MinimizePreviousComments(prNumber, marker, classifier string) error {
+	logger.Info("dry run: would minimize previous comments", "pr", prNumber, "marker", marker, "classifier", classifier)
+	return nil
+}
+
+func (d *dryRunGithub) AddReaction(prNumber, commentID, reaction string) error {
+	logger.Info("dry run: would add reaction", "pr", prNumber, "comment_id", commentID, "reaction", reaction)
+	return nil
+}
+
+func (d *dryRunGithub) DismissStaleReviews(prNumber, commitRef, message string) error {
+	logger.Info("dry run: would dismiss stale reviews", "pr", prNumber, "commit", commitRef, "message", message)
+	return nil
+}
+
+func (d *dryRunGithub) CreateOrUpdateIssue(title, body string, labels []string) error {
+	logger.Info("dry run: would open issue", "title", title, "body", body, "labels", labels)
+	return nil
+}
+
+func (d *dryRunGithub) CreateGist(description string, files map[string]string) (string, error) {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	logger.Info("dry run: would create gist", "description", description, "files", names)
+	return "https://gist.github.com/dry-run", nil
+}
+
+func (d *dryRunGithub) RerequestCheckSuites(commitRef string) error {
+	logger.Info("dry run: would re-request check suites", "commit", commitRef)
+	return nil
+}
+
+func (d *dryRunGithub) SetLocked(prNumber string, locked bool, reason string) error {
+	logger.Info("dry run: would set lock", "pr", prNumber, "locked", locked, "reason", reason)
+	return nil
+}
+
+func (d *dryRunGithub) AddLabels(prNumber string, labels []string) error {
+	logger.Info("dry run: would add labels", "pr", prNumber, "labels", labels)
+	return nil
+}
+
+func (d *dryRunGithub) RemoveLabel(prNumber string, label string) error {
+	logger.Info("dry run: would remove label", "pr", prNumber, "label", label)
+	return nil
+}
+
+func (d *dryRunGithub) ClosePullRequest(prNumber string) error {
+	logger.Info("dry run: would close pull request", "pr", prNumber)
+	return nil
+}
@@ -140,7 +140,7 @@ func TestCheckE2E(t *testing.T) {
 				AccessToken:             os.Getenv("GITHUB_ACCESS_TOKEN"),
 				V3Endpoint:              "https://api.github.com/",
 				V4Endpoint:              "https://api.github.com/graphql",
-				RequiredReviewApprovals: 1,
+				RequiredReviewApprovals: resource.ReviewApprovals{Count: 1},
 			},
 			version: resource.Version{},
 			expected: resource.CheckResponse{
@@ -155,7 +155,7 @@ func TestCheckE2E(t *testing.T) {
 				AccessToken:             os.Getenv("GITHUB_ACCESS_TOKEN"),
 				V3Endpoint:              "https://api.github.com/",
 				V4Endpoint:              "https://api.github.com/graphql",
-				RequiredReviewApprovals: 2,
+				RequiredReviewApprovals: resource.ReviewApprovals{Count: 2},
 			},
 			version:  resource.Version{},
 			expected: resource.CheckResponse(nil),
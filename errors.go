@@ -0,0 +1,95 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// hintedError is an error from the Github API together with a hint on how to fix it.
+type hintedError struct {
+	Err  error
+	Hint string
+}
+
+func (e *hintedError) Error() string {
+	return fmt.Sprintf("%s\nhint: %s", e.Err, e.Hint)
+}
+
+func (e *hintedError) Unwrap() error {
+	return e.Err
+}
+
+// AuthError is returned when the access token is invalid, or lacks a scope or permission.
+type AuthError struct{ hintedError }
+
+// NotFoundError is returned when the repository, pull request or commit does not exist, or
+// the access token cannot see it.
+type NotFoundError struct{ hintedError }
+
+// RateLimitError is returned when the access token has exceeded a rate limit of the API.
+type RateLimitError struct{ hintedError }
+
+// SchemaError is returned when the GraphQL API does not support a query, which happens
+// with older versions of Github Enterprise.
+type SchemaError struct{ hintedError }
+
+// ClassifyError returns a typed error with a hint on how to fix it for errors returned by
+// the Github API, and the error itself for other errors. Errors of the V3 API are classified
+// by their type, and errors of the V4 API (which are plain errors) by their message.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var (
+		auth      *AuthError
+		notFound  *NotFoundError
+		rateLimit *RateLimitError
+		schema    *SchemaError
+	)
+	if errors.As(err, &auth) || errors.As(err, &notFound) || errors.As(err, &rateLimit) || errors.As(err, &schema) {
+		return err
+	}
+
+	var rle *github.RateLimitError
+	if errors.As(err, &rle) {
+		return &RateLimitError{hintedError{err, fmt.Sprintf("the rate limit resets at %s, consider increasing the check_every interval or using access_tokens", rle.Rate.Reset.Format(time.RFC3339))}}
+	}
+	var abuse *github.AbuseRateLimitError
+	if errors.As(err, &abuse) {
+		return &RateLimitError{hintedError{err, "Github limits how quickly content can be created, consider posting fewer comments (e.g. with update_comment)"}}
+	}
+
+	status := 0
+	var response *github.ErrorResponse
+	if errors.As(err, &response) && response.Response != nil {
+		status = response.Response.StatusCode
+	}
+	msg := err.Error()
+	contains := func(patterns ...string) bool {
+		for _, p := range patterns {
+			if strings.Contains(msg, p) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case contains("API rate limit exceeded", "secondary rate limit", "RATE_LIMITED"):
+		return &RateLimitError{hintedError{err, "the access token has exceeded the rate limit, consider increasing the check_every interval or using access_tokens"}}
+	case status == http.StatusUnauthorized || contains("non-200 OK status code: 401", "Bad credentials"):
+		return &AuthError{hintedError{err, "the access token is invalid or has expired"}}
+	case status == http.StatusForbidden || contains("non-200 OK status code: 403", "Resource not accessible by integration"):
+		return &AuthError{hintedError{err, "the access token lacks a scope or permission, e.g. the repo:status scope to set statuses, or write access to pull requests to comment"}}
+	case status == http.StatusNotFound || contains("non-200 OK status code: 404", "Could not resolve to a"):
+		return &NotFoundError{hintedError{err, "the repository, pull request or commit does not exist, or the access token cannot access it (private repositories require the repo scope)"}}
+	case contains("doesn't exist on type", "doesn't accept argument", "isn't a defined input type", "Unknown directive"):
+		return &SchemaError{hintedError{err, "the GraphQL API of this Github Enterprise version does not support the query, consider upgrading Github Enterprise"}}
+	}
+	return err
+}
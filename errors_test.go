@@ -0,0 +1,165 @@
+package resource_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resource "github.com/telia-oss/github-pr-resource"
+)
+
+func TestClassifyError(t *testing.T) {
+	response := func(status int) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: status, Request: &http.Request{Method: "POST"}}, Message: http.StatusText(status)}
+	}
+
+	tests := []struct {
+		description string
+		err         error
+		check       func(error) bool
+		hint        string
+	}{
+		{
+			description: "invalid tokens are auth errors",
+			err:         fmt.Errorf("failed to get last commits: %s", errors.New("non-200 OK status code: 401 Unauthorized body: \"\"")),
+			check:       func(err error) bool { var e *resource.AuthError; return errors.As(err, &e) },
+			hint:        "the access token is invalid or has expired",
+		},
+		{
+			description: "missing permissions are auth errors",
+			err:         response(http.StatusForbidden),
+			check:       func(err error) bool { var e *resource.AuthError; return errors.As(err, &e) },
+			hint:        "the access token lacks a scope or permission",
+		},
+		{
+			description: "unknown pull requests are not found errors",
+			err:         errors.New("Could not resolve to a PullRequest with the number of 1."),
+			check:       func(err error) bool { var e *resource.NotFoundError; return errors.As(err, &e) },
+			hint:        "does not exist, or the access token cannot access it",
+		},
+		{
+			description: "rate limits are rate limit errors",
+			err:         errors.New("API rate limit exceeded for user ID 1."),
+			check:       func(err error) bool { var e *resource.RateLimitError; return errors.As(err, &e) },
+			hint:        "exceeded the rate limit",
+		},
+		{
+			description: "unsupported queries are schema errors",
+			err:         errors.New("Field 'isDraft' doesn't exist on type 'PullRequest'"),
+			check:       func(err error) bool { var e *resource.SchemaError; return errors.As(err, &e) },
+			hint:        "does not support the query",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			err := resource.ClassifyError(tc.err)
+			assert.True(t, tc.check(err), "unexpected error type: %T", err)
+			assert.Contains(t, err.Error(), tc.err.Error())
+			assert.Contains(t, err.Error(), "\nhint: ")
+			assert.Contains(t, err.Error(), tc.hint)
+			assert.True(t, errors.Is(err, tc.err))
+
+			// Classified errors are not classified again.
+			assert.Equal(t, err, resource.ClassifyError(err))
+		})
+	}
+
+	// Errors of git are not mistaken for errors of the API.
+	for _, other := range []error{
+		errors.New("git fetch failed"),
+		fmt.Errorf("pull failed: %w", errors.New("fatal: unable to access 'https://github.com/owner/repo/': 403 Forbidden")),
+		fmt.Errorf("pull failed: %w", errors.New("remote: 404 Not Found")),
+	} {
+		assert.Equal(t, other, resource.ClassifyError(other))
+	}
+	assert.Nil(t, resource.ClassifyError(nil))
+}
+
+func TestClassifyErrorFromClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/graphql":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message": "Bad credentials"}`))
+		case r.URL.Path == "/repos/itsdalmo/test-repository/commits/commit1/status":
+			w.Write([]byte(`{"statuses": []}`))
+		case r.URL.Path == "/repos/itsdalmo/test-repository/statuses/commit1":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "Resource not accessible"}`))
+		case r.URL.Path == "/repos/itsdalmo/test-repository/issues/1/labels":
+			w.Header().Set("X-RateLimit-Limit", "5000")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1588334400")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "API rate limit exceeded for user ID 1."}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := resource.NewGithubClient(&resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		V3Endpoint:  server.URL + "/",
+		V4Endpoint:  server.URL + "/graphql",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		description string
+		call        func() error
+		check       func(error) bool
+		hint        string
+	}{
+		{
+			description: "v4 errors are classified by their message",
+			call: func() error {
+				_, err := client.ListOwnComments("1")
+				return fmt.Errorf("failed to list previous comments: %w", err)
+			},
+			check: func(err error) bool { var e *resource.AuthError; return errors.As(err, &e) },
+			hint:  "the access token is invalid or has expired",
+		},
+		{
+			description: "v3 errors are classified by their status",
+			call: func() error {
+				err := client.UpdateCommitStatus("commit1", "", "", "SUCCESS", "https://ci/builds/1", "")
+				return fmt.Errorf("failed to set status for context 'status': %w", err)
+			},
+			check: func(err error) bool { var e *resource.AuthError; return errors.As(err, &e) },
+			hint:  "the access token lacks a scope or permission",
+		},
+		{
+			description: "v3 rate limits are classified by their type",
+			call: func() error {
+				return fmt.Errorf("failed to add label: %w", client.AddLabels("1", []string{"size/S"}))
+			},
+			check: func(err error) bool { var e *resource.RateLimitError; return errors.As(err, &e) },
+			hint:  "the rate limit resets at 2020-05-01T12:00:00Z",
+		},
+		{
+			description: "v3 missing resources are classified by their status",
+			call: func() error {
+				return fmt.Errorf("failed to re-request check suites: %w", client.RerequestCheckSuites("commit1"))
+			},
+			check: func(err error) bool { var e *resource.NotFoundError; return errors.As(err, &e) },
+			hint:  "does not exist",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			err := resource.ClassifyError(tc.call())
+			assert.True(t, tc.check(err), "unexpected error type: %T (%s)", err, err)
+			assert.Contains(t, err.Error(), tc.hint)
+		})
+	}
+}
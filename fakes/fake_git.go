@@ -8,6 +8,28 @@ import (
 )
 
 type FakeGit struct {
+	AddReferenceStub        func(string) error
+	addReferenceMutex       sync.RWMutex
+	addReferenceArgsForCall []struct {
+		arg1 string
+	}
+	addReferenceReturns struct {
+		result1 error
+	}
+	addReferenceReturnsOnCall map[int]struct {
+		result1 error
+	}
+	BundleStub        func(string) error
+	bundleMutex       sync.RWMutex
+	bundleArgsForCall []struct {
+		arg1 string
+	}
+	bundleReturns struct {
+		result1 error
+	}
+	bundleReturnsOnCall map[int]struct {
+		result1 error
+	}
 	CheckoutStub        func(string, string, bool) error
 	checkoutMutex       sync.RWMutex
 	checkoutArgsForCall []struct {
@@ -21,6 +43,28 @@ type FakeGit struct {
 	checkoutReturnsOnCall map[int]struct {
 		result1 error
 	}
+	CheckoutTreeStub        func(string, string) error
+	checkoutTreeMutex       sync.RWMutex
+	checkoutTreeArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	checkoutTreeReturns struct {
+		result1 error
+	}
+	checkoutTreeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DissociateStub        func() error
+	dissociateMutex       sync.RWMutex
+	dissociateArgsForCall []struct {
+	}
+	dissociateReturns struct {
+		result1 error
+	}
+	dissociateReturnsOnCall map[int]struct {
+		result1 error
+	}
 	FetchStub        func(string, int, int, bool) error
 	fetchMutex       sync.RWMutex
 	fetchArgsForCall []struct {
@@ -35,6 +79,20 @@ type FakeGit struct {
 	fetchReturnsOnCall map[int]struct {
 		result1 error
 	}
+	FetchForkStub        func(string, string, int, bool) error
+	fetchForkMutex       sync.RWMutex
+	fetchForkArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 int
+		arg4 bool
+	}
+	fetchForkReturns struct {
+		result1 error
+	}
+	fetchForkReturnsOnCall map[int]struct {
+		result1 error
+	}
 	GitCryptUnlockStub        func(string) error
 	gitCryptUnlockMutex       sync.RWMutex
 	gitCryptUnlockArgsForCall []struct {
@@ -69,6 +127,20 @@ type FakeGit struct {
 	mergeReturnsOnCall map[int]struct {
 		result1 error
 	}
+	MergeBaseStub        func(string, string) (string, error)
+	mergeBaseMutex       sync.RWMutex
+	mergeBaseArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	mergeBaseReturns struct {
+		result1 string
+		result2 error
+	}
+	mergeBaseReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	PullStub        func(string, string, int, bool, bool) error
 	pullMutex       sync.RWMutex
 	pullArgsForCall []struct {
@@ -97,6 +169,18 @@ type FakeGit struct {
 	rebaseReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ResetStub        func(string, int) error
+	resetMutex       sync.RWMutex
+	resetArgsForCall []struct {
+		arg1 string
+		arg2 int
+	}
+	resetReturns struct {
+		result1 error
+	}
+	resetReturnsOnCall map[int]struct {
+		result1 error
+	}
 	RevParseStub        func(string) (string, error)
 	revParseMutex       sync.RWMutex
 	revParseArgsForCall []struct {
@@ -110,10 +194,142 @@ type FakeGit struct {
 		result1 string
 		result2 error
 	}
+	VerifyCommitStub        func(string, []string) error
+	verifyCommitMutex       sync.RWMutex
+	verifyCommitArgsForCall []struct {
+		arg1 string
+		arg2 []string
+	}
+	verifyCommitReturns struct {
+		result1 error
+	}
+	verifyCommitReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeGit) AddReference(arg1 string) error {
+	fake.addReferenceMutex.Lock()
+	ret, specificReturn := fake.addReferenceReturnsOnCall[len(fake.addReferenceArgsForCall)]
+	fake.addReferenceArgsForCall = append(fake.addReferenceArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("AddReference", []interface{}{arg1})
+	fake.addReferenceMutex.Unlock()
+	if fake.AddReferenceStub != nil {
+		return fake.AddReferenceStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.addReferenceReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) AddReferenceCallCount() int {
+	fake.addReferenceMutex.RLock()
+	defer fake.addReferenceMutex.RUnlock()
+	return len(fake.addReferenceArgsForCall)
+}
+
+func (fake *FakeGit) AddReferenceCalls(stub func(string) error) {
+	fake.addReferenceMutex.Lock()
+	defer fake.addReferenceMutex.Unlock()
+	fake.AddReferenceStub = stub
+}
+
+func (fake *FakeGit) AddReferenceArgsForCall(i int) string {
+	fake.addReferenceMutex.RLock()
+	defer fake.addReferenceMutex.RUnlock()
+	argsForCall := fake.addReferenceArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGit) AddReferenceReturns(result1 error) {
+	fake.addReferenceMutex.Lock()
+	defer fake.addReferenceMutex.Unlock()
+	fake.AddReferenceStub = nil
+	fake.addReferenceReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) AddReferenceReturnsOnCall(i int, result1 error) {
+	fake.addReferenceMutex.Lock()
+	defer fake.addReferenceMutex.Unlock()
+	fake.AddReferenceStub = nil
+	if fake.addReferenceReturnsOnCall == nil {
+		fake.addReferenceReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addReferenceReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) Bundle(arg1 string) error {
+	fake.bundleMutex.Lock()
+	ret, specificReturn := fake.bundleReturnsOnCall[len(fake.bundleArgsForCall)]
+	fake.bundleArgsForCall = append(fake.bundleArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Bundle", []interface{}{arg1})
+	fake.bundleMutex.Unlock()
+	if fake.BundleStub != nil {
+		return fake.BundleStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.bundleReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) BundleCallCount() int {
+	fake.bundleMutex.RLock()
+	defer fake.bundleMutex.RUnlock()
+	return len(fake.bundleArgsForCall)
+}
+
+func (fake *FakeGit) BundleCalls(stub func(string) error) {
+	fake.bundleMutex.Lock()
+	defer fake.bundleMutex.Unlock()
+	fake.BundleStub = stub
+}
+
+func (fake *FakeGit) BundleArgsForCall(i int) string {
+	fake.bundleMutex.RLock()
+	defer fake.bundleMutex.RUnlock()
+	argsForCall := fake.bundleArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGit) BundleReturns(result1 error) {
+	fake.bundleMutex.Lock()
+	defer fake.bundleMutex.Unlock()
+	fake.BundleStub = nil
+	fake.bundleReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) BundleReturnsOnCall(i int, result1 error) {
+	fake.bundleMutex.Lock()
+	defer fake.bundleMutex.Unlock()
+	fake.BundleStub = nil
+	if fake.bundleReturnsOnCall == nil {
+		fake.bundleReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.bundleReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeGit) Checkout(arg1 string, arg2 string, arg3 bool) error {
 	fake.checkoutMutex.Lock()
 	ret, specificReturn := fake.checkoutReturnsOnCall[len(fake.checkoutArgsForCall)]
@@ -176,6 +392,119 @@ func (fake *FakeGit) CheckoutReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeGit) CheckoutTree(arg1 string, arg2 string) error {
+	fake.checkoutTreeMutex.Lock()
+	ret, specificReturn := fake.checkoutTreeReturnsOnCall[len(fake.checkoutTreeArgsForCall)]
+	fake.checkoutTreeArgsForCall = append(fake.checkoutTreeArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("CheckoutTree", []interface{}{arg1, arg2})
+	fake.checkoutTreeMutex.Unlock()
+	if fake.CheckoutTreeStub != nil {
+		return fake.CheckoutTreeStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.checkoutTreeReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) CheckoutTreeCallCount() int {
+	fake.checkoutTreeMutex.RLock()
+	defer fake.checkoutTreeMutex.RUnlock()
+	return len(fake.checkoutTreeArgsForCall)
+}
+
+func (fake *FakeGit) CheckoutTreeCalls(stub func(string, string) error) {
+	fake.checkoutTreeMutex.Lock()
+	defer fake.checkoutTreeMutex.Unlock()
+	fake.CheckoutTreeStub = stub
+}
+
+func (fake *FakeGit) CheckoutTreeArgsForCall(i int) (string, string) {
+	fake.checkoutTreeMutex.RLock()
+	defer fake.checkoutTreeMutex.RUnlock()
+	argsForCall := fake.checkoutTreeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGit) CheckoutTreeReturns(result1 error) {
+	fake.checkoutTreeMutex.Lock()
+	defer fake.checkoutTreeMutex.Unlock()
+	fake.CheckoutTreeStub = nil
+	fake.checkoutTreeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) CheckoutTreeReturnsOnCall(i int, result1 error) {
+	fake.checkoutTreeMutex.Lock()
+	defer fake.checkoutTreeMutex.Unlock()
+	fake.CheckoutTreeStub = nil
+	if fake.checkoutTreeReturnsOnCall == nil {
+		fake.checkoutTreeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.checkoutTreeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) Dissociate() error {
+	fake.dissociateMutex.Lock()
+	ret, specificReturn := fake.dissociateReturnsOnCall[len(fake.dissociateArgsForCall)]
+	fake.dissociateArgsForCall = append(fake.dissociateArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Dissociate", []interface{}{})
+	fake.dissociateMutex.Unlock()
+	if fake.DissociateStub != nil {
+		return fake.DissociateStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.dissociateReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) DissociateCallCount() int {
+	fake.dissociateMutex.RLock()
+	defer fake.dissociateMutex.RUnlock()
+	return len(fake.dissociateArgsForCall)
+}
+
+func (fake *FakeGit) DissociateCalls(stub func() error) {
+	fake.dissociateMutex.Lock()
+	defer fake.dissociateMutex.Unlock()
+	fake.DissociateStub = stub
+}
+
+func (fake *FakeGit) DissociateReturns(result1 error) {
+	fake.dissociateMutex.Lock()
+	defer fake.dissociateMutex.Unlock()
+	fake.DissociateStub = nil
+	fake.dissociateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) DissociateReturnsOnCall(i int, result1 error) {
+	fake.dissociateMutex.Lock()
+	defer fake.dissociateMutex.Unlock()
+	fake.DissociateStub = nil
+	if fake.dissociateReturnsOnCall == nil {
+		fake.dissociateReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.dissociateReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeGit) Fetch(arg1 string, arg2 int, arg3 int, arg4 bool) error {
 	fake.fetchMutex.Lock()
 	ret, specificReturn := fake.fetchReturnsOnCall[len(fake.fetchArgsForCall)]
@@ -239,6 +568,69 @@ func (fake *FakeGit) FetchReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeGit) FetchFork(arg1 string, arg2 string, arg3 int, arg4 bool) error {
+	fake.fetchForkMutex.Lock()
+	ret, specificReturn := fake.fetchForkReturnsOnCall[len(fake.fetchForkArgsForCall)]
+	fake.fetchForkArgsForCall = append(fake.fetchForkArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 int
+		arg4 bool
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("FetchFork", []interface{}{arg1, arg2, arg3, arg4})
+	fake.fetchForkMutex.Unlock()
+	if fake.FetchForkStub != nil {
+		return fake.FetchForkStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.fetchForkReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) FetchForkCallCount() int {
+	fake.fetchForkMutex.RLock()
+	defer fake.fetchForkMutex.RUnlock()
+	return len(fake.fetchForkArgsForCall)
+}
+
+func (fake *FakeGit) FetchForkCalls(stub func(string, string, int, bool) error) {
+	fake.fetchForkMutex.Lock()
+	defer fake.fetchForkMutex.Unlock()
+	fake.FetchForkStub = stub
+}
+
+func (fake *FakeGit) FetchForkArgsForCall(i int) (string, string, int, bool) {
+	fake.fetchForkMutex.RLock()
+	defer fake.fetchForkMutex.RUnlock()
+	argsForCall := fake.fetchForkArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeGit) FetchForkReturns(result1 error) {
+	fake.fetchForkMutex.Lock()
+	defer fake.fetchForkMutex.Unlock()
+	fake.FetchForkStub = nil
+	fake.fetchForkReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) FetchForkReturnsOnCall(i int, result1 error) {
+	fake.fetchForkMutex.Lock()
+	defer fake.fetchForkMutex.Unlock()
+	fake.FetchForkStub = nil
+	if fake.fetchForkReturnsOnCall == nil {
+		fake.fetchForkReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.fetchForkReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeGit) GitCryptUnlock(arg1 string) error {
 	fake.gitCryptUnlockMutex.Lock()
 	ret, specificReturn := fake.gitCryptUnlockReturnsOnCall[len(fake.gitCryptUnlockArgsForCall)]
@@ -420,6 +812,70 @@ func (fake *FakeGit) MergeReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeGit) MergeBase(arg1 string, arg2 string) (string, error) {
+	fake.mergeBaseMutex.Lock()
+	ret, specificReturn := fake.mergeBaseReturnsOnCall[len(fake.mergeBaseArgsForCall)]
+	fake.mergeBaseArgsForCall = append(fake.mergeBaseArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("MergeBase", []interface{}{arg1, arg2})
+	fake.mergeBaseMutex.Unlock()
+	if fake.MergeBaseStub != nil {
+		return fake.MergeBaseStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.mergeBaseReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGit) MergeBaseCallCount() int {
+	fake.mergeBaseMutex.RLock()
+	defer fake.mergeBaseMutex.RUnlock()
+	return len(fake.mergeBaseArgsForCall)
+}
+
+func (fake *FakeGit) MergeBaseCalls(stub func(string, string) (string, error)) {
+	fake.mergeBaseMutex.Lock()
+	defer fake.mergeBaseMutex.Unlock()
+	fake.MergeBaseStub = stub
+}
+
+func (fake *FakeGit) MergeBaseArgsForCall(i int) (string, string) {
+	fake.mergeBaseMutex.RLock()
+	defer fake.mergeBaseMutex.RUnlock()
+	argsForCall := fake.mergeBaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGit) MergeBaseReturns(result1 string, result2 error) {
+	fake.mergeBaseMutex.Lock()
+	defer fake.mergeBaseMutex.Unlock()
+	fake.MergeBaseStub = nil
+	fake.mergeBaseReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGit) MergeBaseReturnsOnCall(i int, result1 string, result2 error) {
+	fake.mergeBaseMutex.Lock()
+	defer fake.mergeBaseMutex.Unlock()
+	fake.MergeBaseStub = nil
+	if fake.mergeBaseReturnsOnCall == nil {
+		fake.mergeBaseReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.mergeBaseReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeGit) Pull(arg1 string, arg2 string, arg3 int, arg4 bool, arg5 bool) error {
 	fake.pullMutex.Lock()
 	ret, specificReturn := fake.pullReturnsOnCall[len(fake.pullArgsForCall)]
@@ -546,6 +1002,67 @@ func (fake *FakeGit) RebaseReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeGit) Reset(arg1 string, arg2 int) error {
+	fake.resetMutex.Lock()
+	ret, specificReturn := fake.resetReturnsOnCall[len(fake.resetArgsForCall)]
+	fake.resetArgsForCall = append(fake.resetArgsForCall, struct {
+		arg1 string
+		arg2 int
+	}{arg1, arg2})
+	fake.recordInvocation("Reset", []interface{}{arg1, arg2})
+	fake.resetMutex.Unlock()
+	if fake.ResetStub != nil {
+		return fake.ResetStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.resetReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) ResetCallCount() int {
+	fake.resetMutex.RLock()
+	defer fake.resetMutex.RUnlock()
+	return len(fake.resetArgsForCall)
+}
+
+func (fake *FakeGit) ResetCalls(stub func(string, int) error) {
+	fake.resetMutex.Lock()
+	defer fake.resetMutex.Unlock()
+	fake.ResetStub = stub
+}
+
+func (fake *FakeGit) ResetArgsForCall(i int) (string, int) {
+	fake.resetMutex.RLock()
+	defer fake.resetMutex.RUnlock()
+	argsForCall := fake.resetArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGit) ResetReturns(result1 error) {
+	fake.resetMutex.Lock()
+	defer fake.resetMutex.Unlock()
+	fake.ResetStub = nil
+	fake.resetReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) ResetReturnsOnCall(i int, result1 error) {
+	fake.resetMutex.Lock()
+	defer fake.resetMutex.Unlock()
+	fake.ResetStub = nil
+	if fake.resetReturnsOnCall == nil {
+		fake.resetReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.resetReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeGit) RevParse(arg1 string) (string, error) {
 	fake.revParseMutex.Lock()
 	ret, specificReturn := fake.revParseReturnsOnCall[len(fake.revParseArgsForCall)]
@@ -609,25 +1126,107 @@ func (fake *FakeGit) RevParseReturnsOnCall(i int, result1 string, result2 error)
 	}{result1, result2}
 }
 
+func (fake *FakeGit) VerifyCommit(arg1 string, arg2 []string) error {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.verifyCommitMutex.Lock()
+	ret, specificReturn := fake.verifyCommitReturnsOnCall[len(fake.verifyCommitArgsForCall)]
+	fake.verifyCommitArgsForCall = append(fake.verifyCommitArgsForCall, struct {
+		arg1 string
+		arg2 []string
+	}{arg1, arg2Copy})
+	fake.recordInvocation("VerifyCommit", []interface{}{arg1, arg2Copy})
+	fake.verifyCommitMutex.Unlock()
+	if fake.VerifyCommitStub != nil {
+		return fake.VerifyCommitStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.verifyCommitReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) VerifyCommitCallCount() int {
+	fake.verifyCommitMutex.RLock()
+	defer fake.verifyCommitMutex.RUnlock()
+	return len(fake.verifyCommitArgsForCall)
+}
+
+func (fake *FakeGit) VerifyCommitCalls(stub func(string, []string) error) {
+	fake.verifyCommitMutex.Lock()
+	defer fake.verifyCommitMutex.Unlock()
+	fake.VerifyCommitStub = stub
+}
+
+func (fake *FakeGit) VerifyCommitArgsForCall(i int) (string, []string) {
+	fake.verifyCommitMutex.RLock()
+	defer fake.verifyCommitMutex.RUnlock()
+	argsForCall := fake.verifyCommitArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGit) VerifyCommitReturns(result1 error) {
+	fake.verifyCommitMutex.Lock()
+	defer fake.verifyCommitMutex.Unlock()
+	fake.VerifyCommitStub = nil
+	fake.verifyCommitReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) VerifyCommitReturnsOnCall(i int, result1 error) {
+	fake.verifyCommitMutex.Lock()
+	defer fake.verifyCommitMutex.Unlock()
+	fake.VerifyCommitStub = nil
+	if fake.verifyCommitReturnsOnCall == nil {
+		fake.verifyCommitReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.verifyCommitReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeGit) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.addReferenceMutex.RLock()
+	defer fake.addReferenceMutex.RUnlock()
+	fake.bundleMutex.RLock()
+	defer fake.bundleMutex.RUnlock()
 	fake.checkoutMutex.RLock()
 	defer fake.checkoutMutex.RUnlock()
+	fake.checkoutTreeMutex.RLock()
+	defer fake.checkoutTreeMutex.RUnlock()
+	fake.dissociateMutex.RLock()
+	defer fake.dissociateMutex.RUnlock()
 	fake.fetchMutex.RLock()
 	defer fake.fetchMutex.RUnlock()
+	fake.fetchForkMutex.RLock()
+	defer fake.fetchForkMutex.RUnlock()
 	fake.gitCryptUnlockMutex.RLock()
 	defer fake.gitCryptUnlockMutex.RUnlock()
 	fake.initMutex.RLock()
 	defer fake.initMutex.RUnlock()
 	fake.mergeMutex.RLock()
 	defer fake.mergeMutex.RUnlock()
+	fake.mergeBaseMutex.RLock()
+	defer fake.mergeBaseMutex.RUnlock()
 	fake.pullMutex.RLock()
 	defer fake.pullMutex.RUnlock()
 	fake.rebaseMutex.RLock()
 	defer fake.rebaseMutex.RUnlock()
+	fake.resetMutex.RLock()
+	defer fake.resetMutex.RUnlock()
 	fake.revParseMutex.RLock()
 	defer fake.revParseMutex.RUnlock()
+	fake.verifyCommitMutex.RLock()
+	defer fake.verifyCommitMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
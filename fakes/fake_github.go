@@ -2,17 +2,84 @@
 package fakes
 
 import (
+	"io"
 	"sync"
+	"time"
 
 	"github.com/shurcooL/githubv4"
 	resource "github.com/telia-oss/github-pr-resource"
 )
 
 type FakeGithub struct {
-	DeletePreviousCommentsStub        func(string) error
+	AddLabelsStub        func(string, []string) error
+	addLabelsMutex       sync.RWMutex
+	addLabelsArgsForCall []struct {
+		arg1 string
+		arg2 []string
+	}
+	addLabelsReturns struct {
+		result1 error
+	}
+	addLabelsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	AddReactionStub        func(string, string, string) error
+	addReactionMutex       sync.RWMutex
+	addReactionArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	addReactionReturns struct {
+		result1 error
+	}
+	addReactionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ClosePullRequestStub        func(string) error
+	closePullRequestMutex       sync.RWMutex
+	closePullRequestArgsForCall []struct {
+		arg1 string
+	}
+	closePullRequestReturns struct {
+		result1 error
+	}
+	closePullRequestReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CreateGistStub        func(string, map[string]string) (string, error)
+	createGistMutex       sync.RWMutex
+	createGistArgsForCall []struct {
+		arg1 string
+		arg2 map[string]string
+	}
+	createGistReturns struct {
+		result1 string
+		result2 error
+	}
+	createGistReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	CreateOrUpdateIssueStub        func(string, string, []string) error
+	createOrUpdateIssueMutex       sync.RWMutex
+	createOrUpdateIssueArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 []string
+	}
+	createOrUpdateIssueReturns struct {
+		result1 error
+	}
+	createOrUpdateIssueReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeletePreviousCommentsStub        func(string, string, time.Time) error
 	deletePreviousCommentsMutex       sync.RWMutex
 	deletePreviousCommentsArgsForCall []struct {
 		arg1 string
+		arg2 string
+		arg3 time.Time
 	}
 	deletePreviousCommentsReturns struct {
 		result1 error
@@ -20,6 +87,31 @@ type FakeGithub struct {
 	deletePreviousCommentsReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DismissStaleReviewsStub        func(string, string, string) error
+	dismissStaleReviewsMutex       sync.RWMutex
+	dismissStaleReviewsArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	dismissStaleReviewsReturns struct {
+		result1 error
+	}
+	dismissStaleReviewsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DownloadArchiveStub        func(string, io.Writer) error
+	downloadArchiveMutex       sync.RWMutex
+	downloadArchiveArgsForCall []struct {
+		arg1 string
+		arg2 io.Writer
+	}
+	downloadArchiveReturns struct {
+		result1 error
+	}
+	downloadArchiveReturnsOnCall map[int]struct {
+		result1 error
+	}
 	GetChangedFilesStub        func(string, string) ([]resource.ChangedFileObject, error)
 	getChangedFilesMutex       sync.RWMutex
 	getChangedFilesArgsForCall []struct {
@@ -34,6 +126,20 @@ type FakeGithub struct {
 		result1 []resource.ChangedFileObject
 		result2 error
 	}
+	GetFileContentsStub        func(string, string) ([]byte, error)
+	getFileContentsMutex       sync.RWMutex
+	getFileContentsArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getFileContentsReturns struct {
+		result1 []byte
+		result2 error
+	}
+	getFileContentsReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
 	GetPullRequestStub        func(string, string) (*resource.PullRequest, error)
 	getPullRequestMutex       sync.RWMutex
 	getPullRequestArgsForCall []struct {
@@ -48,23 +154,107 @@ type FakeGithub struct {
 		result1 *resource.PullRequest
 		result2 error
 	}
-	ListModifiedFilesStub        func(int) ([]string, error)
+	GetRateLimitStub        func() (*resource.RateLimit, error)
+	getRateLimitMutex       sync.RWMutex
+	getRateLimitArgsForCall []struct {
+	}
+	getRateLimitReturns struct {
+		result1 *resource.RateLimit
+		result2 error
+	}
+	getRateLimitReturnsOnCall map[int]struct {
+		result1 *resource.RateLimit
+		result2 error
+	}
+	GetSignatureVerificationStub        func(string) (*resource.SignatureVerification, error)
+	getSignatureVerificationMutex       sync.RWMutex
+	getSignatureVerificationArgsForCall []struct {
+		arg1 string
+	}
+	getSignatureVerificationReturns struct {
+		result1 *resource.SignatureVerification
+		result2 error
+	}
+	getSignatureVerificationReturnsOnCall map[int]struct {
+		result1 *resource.SignatureVerification
+		result2 error
+	}
+	ListMergeQueueEntriesStub        func(string) ([]*resource.MergeQueueEntry, error)
+	listMergeQueueEntriesMutex       sync.RWMutex
+	listMergeQueueEntriesArgsForCall []struct {
+		arg1 string
+	}
+	listMergeQueueEntriesReturns struct {
+		result1 []*resource.MergeQueueEntry
+		result2 error
+	}
+	listMergeQueueEntriesReturnsOnCall map[int]struct {
+		result1 []*resource.MergeQueueEntry
+		result2 error
+	}
+	ListModifiedFilesStub        func(int, func([]resource.ChangedFileObject) bool) ([]resource.ChangedFileObject, error)
 	listModifiedFilesMutex       sync.RWMutex
 	listModifiedFilesArgsForCall []struct {
 		arg1 int
+		arg2 func([]resource.ChangedFileObject) bool
 	}
 	listModifiedFilesReturns struct {
-		result1 []string
+		result1 []resource.ChangedFileObject
 		result2 error
 	}
 	listModifiedFilesReturnsOnCall map[int]struct {
-		result1 []string
+		result1 []resource.ChangedFileObject
+		result2 error
+	}
+	ListOwnCommentsStub        func(string) ([]resource.CommentObject, error)
+	listOwnCommentsMutex       sync.RWMutex
+	listOwnCommentsArgsForCall []struct {
+		arg1 string
+	}
+	listOwnCommentsReturns struct {
+		result1 []resource.CommentObject
+		result2 error
+	}
+	listOwnCommentsReturnsOnCall map[int]struct {
+		result1 []resource.CommentObject
+		result2 error
+	}
+	ListPullRequestCommitsStub        func(string) ([]resource.PullRequestCommit, error)
+	listPullRequestCommitsMutex       sync.RWMutex
+	listPullRequestCommitsArgsForCall []struct {
+		arg1 string
+	}
+	listPullRequestCommitsReturns struct {
+		result1 []resource.PullRequestCommit
+		result2 error
+	}
+	listPullRequestCommitsReturnsOnCall map[int]struct {
+		result1 []resource.PullRequestCommit
 		result2 error
 	}
-	ListPullRequestsStub        func([]githubv4.PullRequestState) ([]*resource.PullRequest, error)
+	ListPullRequestPagesStub        func([]githubv4.PullRequestState, bool, string, int) ([]*resource.PullRequest, string, error)
+	listPullRequestPagesMutex       sync.RWMutex
+	listPullRequestPagesArgsForCall []struct {
+		arg1 []githubv4.PullRequestState
+		arg2 bool
+		arg3 string
+		arg4 int
+	}
+	listPullRequestPagesReturns struct {
+		result1 []*resource.PullRequest
+		result2 string
+		result3 error
+	}
+	listPullRequestPagesReturnsOnCall map[int]struct {
+		result1 []*resource.PullRequest
+		result2 string
+		result3 error
+	}
+	ListPullRequestsStub        func([]githubv4.PullRequestState, bool) ([]*resource.PullRequest, error)
 	listPullRequestsMutex       sync.RWMutex
 	listPullRequestsArgsForCall []struct {
 		arg1 []githubv4.PullRequestState
+		arg2 bool
 	}
 	listPullRequestsReturns struct {
 		result1 []*resource.PullRequest
@@ -74,6 +264,45 @@ type FakeGithub struct {
 		result1 []*resource.PullRequest
 		result2 error
 	}
+	ListTeamMembersStub        func(string) ([]string, error)
+	listTeamMembersMutex       sync.RWMutex
+	listTeamMembersArgsForCall []struct {
+		arg1 string
+	}
+	listTeamMembersReturns struct {
+		result1 []string
+		result2 error
+	}
+	listTeamMembersReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	ListUserTeamsStub        func(string) ([]string, error)
+	listUserTeamsMutex       sync.RWMutex
+	listUserTeamsArgsForCall []struct {
+		arg1 string
+	}
+	listUserTeamsReturns struct {
+		result1 []string
+		result2 error
+	}
+	listUserTeamsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	MinimizePreviousCommentsStub        func(string, string, string) error
+	minimizePreviousCommentsMutex       sync.RWMutex
+	minimizePreviousCommentsArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	minimizePreviousCommentsReturns struct {
+		result1 error
+	}
+	minimizePreviousCommentsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	PostCommentStub        func(string, string) error
 	postCommentMutex       sync.RWMutex
 	postCommentArgsForCall []struct {
@@ -86,6 +315,54 @@ type FakeGithub struct {
 	postCommentReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RemoveLabelStub        func(string, string) error
+	removeLabelMutex       sync.RWMutex
+	removeLabelArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	removeLabelReturns struct {
+		result1 error
+	}
+	removeLabelReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RerequestCheckSuitesStub        func(string) error
+	rerequestCheckSuitesMutex       sync.RWMutex
+	rerequestCheckSuitesArgsForCall []struct {
+		arg1 string
+	}
+	rerequestCheckSuitesReturns struct {
+		result1 error
+	}
+	rerequestCheckSuitesReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SetLockedStub        func(string, bool, string) error
+	setLockedMutex       sync.RWMutex
+	setLockedArgsForCall []struct {
+		arg1 string
+		arg2 bool
+		arg3 string
+	}
+	setLockedReturns struct {
+		result1 error
+	}
+	setLockedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdateCommentStub        func(string, string) error
+	updateCommentMutex       sync.RWMutex
+	updateCommentArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	updateCommentReturns struct {
+		result1 error
+	}
+	updateCommentReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UpdateCommitStatusStub        func(string, string, string, string, string, string) error
 	updateCommitStatusMutex       sync.RWMutex
 	updateCommitStatusArgsForCall []struct {
@@ -99,23 +376,344 @@ type FakeGithub struct {
 	updateCommitStatusReturns struct {
 		result1 error
 	}
-	updateCommitStatusReturnsOnCall map[int]struct {
+	updateCommitStatusReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeGithub) AddLabels(arg1 string, arg2 []string) error {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.addLabelsMutex.Lock()
+	ret, specificReturn := fake.addLabelsReturnsOnCall[len(fake.addLabelsArgsForCall)]
+	fake.addLabelsArgsForCall = append(fake.addLabelsArgsForCall, struct {
+		arg1 string
+		arg2 []string
+	}{arg1, arg2Copy})
+	fake.recordInvocation("AddLabels", []interface{}{arg1, arg2Copy})
+	fake.addLabelsMutex.Unlock()
+	if fake.AddLabelsStub != nil {
+		return fake.AddLabelsStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.addLabelsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGithub) AddLabelsCallCount() int {
+	fake.addLabelsMutex.RLock()
+	defer fake.addLabelsMutex.RUnlock()
+	return len(fake.addLabelsArgsForCall)
+}
+
+func (fake *FakeGithub) AddLabelsCalls(stub func(string, []string) error) {
+	fake.addLabelsMutex.Lock()
+	defer fake.addLabelsMutex.Unlock()
+	fake.AddLabelsStub = stub
+}
+
+func (fake *FakeGithub) AddLabelsArgsForCall(i int) (string, []string) {
+	fake.addLabelsMutex.RLock()
+	defer fake.addLabelsMutex.RUnlock()
+	argsForCall := fake.addLabelsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGithub) AddLabelsReturns(result1 error) {
+	fake.addLabelsMutex.Lock()
+	defer fake.addLabelsMutex.Unlock()
+	fake.AddLabelsStub = nil
+	fake.addLabelsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) AddLabelsReturnsOnCall(i int, result1 error) {
+	fake.addLabelsMutex.Lock()
+	defer fake.addLabelsMutex.Unlock()
+	fake.AddLabelsStub = nil
+	if fake.addLabelsReturnsOnCall == nil {
+		fake.addLabelsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addLabelsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) AddReaction(arg1 string, arg2 string, arg3 string) error {
+	fake.addReactionMutex.Lock()
+	ret, specificReturn := fake.addReactionReturnsOnCall[len(fake.addReactionArgsForCall)]
+	fake.addReactionArgsForCall = append(fake.addReactionArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("AddReaction", []interface{}{arg1, arg2, arg3})
+	fake.addReactionMutex.Unlock()
+	if fake.AddReactionStub != nil {
+		return fake.AddReactionStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.addReactionReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGithub) AddReactionCallCount() int {
+	fake.addReactionMutex.RLock()
+	defer fake.addReactionMutex.RUnlock()
+	return len(fake.addReactionArgsForCall)
+}
+
+func (fake *FakeGithub) AddReactionCalls(stub func(string, string, string) error) {
+	fake.addReactionMutex.Lock()
+	defer fake.addReactionMutex.Unlock()
+	fake.AddReactionStub = stub
+}
+
+func (fake *FakeGithub) AddReactionArgsForCall(i int) (string, string, string) {
+	fake.addReactionMutex.RLock()
+	defer fake.addReactionMutex.RUnlock()
+	argsForCall := fake.addReactionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeGithub) AddReactionReturns(result1 error) {
+	fake.addReactionMutex.Lock()
+	defer fake.addReactionMutex.Unlock()
+	fake.AddReactionStub = nil
+	fake.addReactionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) AddReactionReturnsOnCall(i int, result1 error) {
+	fake.addReactionMutex.Lock()
+	defer fake.addReactionMutex.Unlock()
+	fake.AddReactionStub = nil
+	if fake.addReactionReturnsOnCall == nil {
+		fake.addReactionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addReactionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) ClosePullRequest(arg1 string) error {
+	fake.closePullRequestMutex.Lock()
+	ret, specificReturn := fake.closePullRequestReturnsOnCall[len(fake.closePullRequestArgsForCall)]
+	fake.closePullRequestArgsForCall = append(fake.closePullRequestArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ClosePullRequest", []interface{}{arg1})
+	fake.closePullRequestMutex.Unlock()
+	if fake.ClosePullRequestStub != nil {
+		return fake.ClosePullRequestStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.closePullRequestReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGithub) ClosePullRequestCallCount() int {
+	fake.closePullRequestMutex.RLock()
+	defer fake.closePullRequestMutex.RUnlock()
+	return len(fake.closePullRequestArgsForCall)
+}
+
+func (fake *FakeGithub) ClosePullRequestCalls(stub func(string) error) {
+	fake.closePullRequestMutex.Lock()
+	defer fake.closePullRequestMutex.Unlock()
+	fake.ClosePullRequestStub = stub
+}
+
+func (fake *FakeGithub) ClosePullRequestArgsForCall(i int) string {
+	fake.closePullRequestMutex.RLock()
+	defer fake.closePullRequestMutex.RUnlock()
+	argsForCall := fake.closePullRequestArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGithub) ClosePullRequestReturns(result1 error) {
+	fake.closePullRequestMutex.Lock()
+	defer fake.closePullRequestMutex.Unlock()
+	fake.ClosePullRequestStub = nil
+	fake.closePullRequestReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) ClosePullRequestReturnsOnCall(i int, result1 error) {
+	fake.closePullRequestMutex.Lock()
+	defer fake.closePullRequestMutex.Unlock()
+	fake.ClosePullRequestStub = nil
+	if fake.closePullRequestReturnsOnCall == nil {
+		fake.closePullRequestReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.closePullRequestReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) CreateGist(arg1 string, arg2 map[string]string) (string, error) {
+	fake.createGistMutex.Lock()
+	ret, specificReturn := fake.createGistReturnsOnCall[len(fake.createGistArgsForCall)]
+	fake.createGistArgsForCall = append(fake.createGistArgsForCall, struct {
+		arg1 string
+		arg2 map[string]string
+	}{arg1, arg2})
+	fake.recordInvocation("CreateGist", []interface{}{arg1, arg2})
+	fake.createGistMutex.Unlock()
+	if fake.CreateGistStub != nil {
+		return fake.CreateGistStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.createGistReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGithub) CreateGistCallCount() int {
+	fake.createGistMutex.RLock()
+	defer fake.createGistMutex.RUnlock()
+	return len(fake.createGistArgsForCall)
+}
+
+func (fake *FakeGithub) CreateGistCalls(stub func(string, map[string]string) (string, error)) {
+	fake.createGistMutex.Lock()
+	defer fake.createGistMutex.Unlock()
+	fake.CreateGistStub = stub
+}
+
+func (fake *FakeGithub) CreateGistArgsForCall(i int) (string, map[string]string) {
+	fake.createGistMutex.RLock()
+	defer fake.createGistMutex.RUnlock()
+	argsForCall := fake.createGistArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGithub) CreateGistReturns(result1 string, result2 error) {
+	fake.createGistMutex.Lock()
+	defer fake.createGistMutex.Unlock()
+	fake.CreateGistStub = nil
+	fake.createGistReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) CreateGistReturnsOnCall(i int, result1 string, result2 error) {
+	fake.createGistMutex.Lock()
+	defer fake.createGistMutex.Unlock()
+	fake.CreateGistStub = nil
+	if fake.createGistReturnsOnCall == nil {
+		fake.createGistReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.createGistReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) CreateOrUpdateIssue(arg1 string, arg2 string, arg3 []string) error {
+	var arg3Copy []string
+	if arg3 != nil {
+		arg3Copy = make([]string, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.createOrUpdateIssueMutex.Lock()
+	ret, specificReturn := fake.createOrUpdateIssueReturnsOnCall[len(fake.createOrUpdateIssueArgsForCall)]
+	fake.createOrUpdateIssueArgsForCall = append(fake.createOrUpdateIssueArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 []string
+	}{arg1, arg2, arg3Copy})
+	fake.recordInvocation("CreateOrUpdateIssue", []interface{}{arg1, arg2, arg3Copy})
+	fake.createOrUpdateIssueMutex.Unlock()
+	if fake.CreateOrUpdateIssueStub != nil {
+		return fake.CreateOrUpdateIssueStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.createOrUpdateIssueReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGithub) CreateOrUpdateIssueCallCount() int {
+	fake.createOrUpdateIssueMutex.RLock()
+	defer fake.createOrUpdateIssueMutex.RUnlock()
+	return len(fake.createOrUpdateIssueArgsForCall)
+}
+
+func (fake *FakeGithub) CreateOrUpdateIssueCalls(stub func(string, string, []string) error) {
+	fake.createOrUpdateIssueMutex.Lock()
+	defer fake.createOrUpdateIssueMutex.Unlock()
+	fake.CreateOrUpdateIssueStub = stub
+}
+
+func (fake *FakeGithub) CreateOrUpdateIssueArgsForCall(i int) (string, string, []string) {
+	fake.createOrUpdateIssueMutex.RLock()
+	defer fake.createOrUpdateIssueMutex.RUnlock()
+	argsForCall := fake.createOrUpdateIssueArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeGithub) CreateOrUpdateIssueReturns(result1 error) {
+	fake.createOrUpdateIssueMutex.Lock()
+	defer fake.createOrUpdateIssueMutex.Unlock()
+	fake.CreateOrUpdateIssueStub = nil
+	fake.createOrUpdateIssueReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) CreateOrUpdateIssueReturnsOnCall(i int, result1 error) {
+	fake.createOrUpdateIssueMutex.Lock()
+	defer fake.createOrUpdateIssueMutex.Unlock()
+	fake.CreateOrUpdateIssueStub = nil
+	if fake.createOrUpdateIssueReturnsOnCall == nil {
+		fake.createOrUpdateIssueReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createOrUpdateIssueReturnsOnCall[i] = struct {
 		result1 error
-	}
-	invocations      map[string][][]interface{}
-	invocationsMutex sync.RWMutex
+	}{result1}
 }
 
-func (fake *FakeGithub) DeletePreviousComments(arg1 string) error {
+func (fake *FakeGithub) DeletePreviousComments(arg1 string, arg2 string, arg3 time.Time) error {
 	fake.deletePreviousCommentsMutex.Lock()
 	ret, specificReturn := fake.deletePreviousCommentsReturnsOnCall[len(fake.deletePreviousCommentsArgsForCall)]
 	fake.deletePreviousCommentsArgsForCall = append(fake.deletePreviousCommentsArgsForCall, struct {
 		arg1 string
-	}{arg1})
-	fake.recordInvocation("DeletePreviousComments", []interface{}{arg1})
+		arg2 string
+		arg3 time.Time
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("DeletePreviousComments", []interface{}{arg1, arg2, arg3})
 	fake.deletePreviousCommentsMutex.Unlock()
 	if fake.DeletePreviousCommentsStub != nil {
-		return fake.DeletePreviousCommentsStub(arg1)
+		return fake.DeletePreviousCommentsStub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -130,17 +728,17 @@ func (fake *FakeGithub) DeletePreviousCommentsCallCount() int {
 	return len(fake.deletePreviousCommentsArgsForCall)
 }
 
-func (fake *FakeGithub) DeletePreviousCommentsCalls(stub func(string) error) {
+func (fake *FakeGithub) DeletePreviousCommentsCalls(stub func(string, string, time.Time) error) {
 	fake.deletePreviousCommentsMutex.Lock()
 	defer fake.deletePreviousCommentsMutex.Unlock()
 	fake.DeletePreviousCommentsStub = stub
 }
 
-func (fake *FakeGithub) DeletePreviousCommentsArgsForCall(i int) string {
+func (fake *FakeGithub) DeletePreviousCommentsArgsForCall(i int) (string, string, time.Time) {
 	fake.deletePreviousCommentsMutex.RLock()
 	defer fake.deletePreviousCommentsMutex.RUnlock()
 	argsForCall := fake.deletePreviousCommentsArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeGithub) DeletePreviousCommentsReturns(result1 error) {
@@ -166,6 +764,129 @@ func (fake *FakeGithub) DeletePreviousCommentsReturnsOnCall(i int, result1 error
 	}{result1}
 }
 
+func (fake *FakeGithub) DismissStaleReviews(arg1 string, arg2 string, arg3 string) error {
+	fake.dismissStaleReviewsMutex.Lock()
+	ret, specificReturn := fake.dismissStaleReviewsReturnsOnCall[len(fake.dismissStaleReviewsArgsForCall)]
+	fake.dismissStaleReviewsArgsForCall = append(fake.dismissStaleReviewsArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("DismissStaleReviews", []interface{}{arg1, arg2, arg3})
+	fake.dismissStaleReviewsMutex.Unlock()
+	if fake.DismissStaleReviewsStub != nil {
+		return fake.DismissStaleReviewsStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.dismissStaleReviewsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGithub) DismissStaleReviewsCallCount() int {
+	fake.dismissStaleReviewsMutex.RLock()
+	defer fake.dismissStaleReviewsMutex.RUnlock()
+	return len(fake.dismissStaleReviewsArgsForCall)
+}
+
+func (fake *FakeGithub) DismissStaleReviewsCalls(stub func(string, string, string) error) {
+	fake.dismissStaleReviewsMutex.Lock()
+	defer fake.dismissStaleReviewsMutex.Unlock()
+	fake.DismissStaleReviewsStub = stub
+}
+
+func (fake *FakeGithub) DismissStaleReviewsArgsForCall(i int) (string, string, string) {
+	fake.dismissStaleReviewsMutex.RLock()
+	defer fake.dismissStaleReviewsMutex.RUnlock()
+	argsForCall := fake.dismissStaleReviewsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeGithub) DismissStaleReviewsReturns(result1 error) {
+	fake.dismissStaleReviewsMutex.Lock()
+	defer fake.dismissStaleReviewsMutex.Unlock()
+	fake.DismissStaleReviewsStub = nil
+	fake.dismissStaleReviewsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) DismissStaleReviewsReturnsOnCall(i int, result1 error) {
+	fake.dismissStaleReviewsMutex.Lock()
+	defer fake.dismissStaleReviewsMutex.Unlock()
+	fake.DismissStaleReviewsStub = nil
+	if fake.dismissStaleReviewsReturnsOnCall == nil {
+		fake.dismissStaleReviewsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.dismissStaleReviewsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) DownloadArchive(arg1 string, arg2 io.Writer) error {
+	fake.downloadArchiveMutex.Lock()
+	ret, specificReturn := fake.downloadArchiveReturnsOnCall[len(fake.downloadArchiveArgsForCall)]
+	fake.downloadArchiveArgsForCall = append(fake.downloadArchiveArgsForCall, struct {
+		arg1 string
+		arg2 io.Writer
+	}{arg1, arg2})
+	fake.recordInvocation("DownloadArchive", []interface{}{arg1, arg2})
+	fake.downloadArchiveMutex.Unlock()
+	if fake.DownloadArchiveStub != nil {
+		return fake.DownloadArchiveStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.downloadArchiveReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGithub) DownloadArchiveCallCount() int {
+	fake.downloadArchiveMutex.RLock()
+	defer fake.downloadArchiveMutex.RUnlock()
+	return len(fake.downloadArchiveArgsForCall)
+}
+
+func (fake *FakeGithub) DownloadArchiveCalls(stub func(string, io.Writer) error) {
+	fake.downloadArchiveMutex.Lock()
+	defer fake.downloadArchiveMutex.Unlock()
+	fake.DownloadArchiveStub = stub
+}
+
+func (fake *FakeGithub) DownloadArchiveArgsForCall(i int) (string, io.Writer) {
+	fake.downloadArchiveMutex.RLock()
+	defer fake.downloadArchiveMutex.RUnlock()
+	argsForCall := fake.downloadArchiveArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGithub) DownloadArchiveReturns(result1 error) {
+	fake.downloadArchiveMutex.Lock()
+	defer fake.downloadArchiveMutex.Unlock()
+	fake.DownloadArchiveStub = nil
+	fake.downloadArchiveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) DownloadArchiveReturnsOnCall(i int, result1 error) {
+	fake.downloadArchiveMutex.Lock()
+	defer fake.downloadArchiveMutex.Unlock()
+	fake.DownloadArchiveStub = nil
+	if fake.downloadArchiveReturnsOnCall == nil {
+		fake.downloadArchiveReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.downloadArchiveReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeGithub) GetChangedFiles(arg1 string, arg2 string) ([]resource.ChangedFileObject, error) {
 	fake.getChangedFilesMutex.Lock()
 	ret, specificReturn := fake.getChangedFilesReturnsOnCall[len(fake.getChangedFilesArgsForCall)]
@@ -230,6 +951,70 @@ func (fake *FakeGithub) GetChangedFilesReturnsOnCall(i int, result1 []resource.C
 	}{result1, result2}
 }
 
+func (fake *FakeGithub) GetFileContents(arg1 string, arg2 string) ([]byte, error) {
+	fake.getFileContentsMutex.Lock()
+	ret, specificReturn := fake.getFileContentsReturnsOnCall[len(fake.getFileContentsArgsForCall)]
+	fake.getFileContentsArgsForCall = append(fake.getFileContentsArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetFileContents", []interface{}{arg1, arg2})
+	fake.getFileContentsMutex.Unlock()
+	if fake.GetFileContentsStub != nil {
+		return fake.GetFileContentsStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getFileContentsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGithub) GetFileContentsCallCount() int {
+	fake.getFileContentsMutex.RLock()
+	defer fake.getFileContentsMutex.RUnlock()
+	return len(fake.getFileContentsArgsForCall)
+}
+
+func (fake *FakeGithub) GetFileContentsCalls(stub func(string, string) ([]byte, error)) {
+	fake.getFileContentsMutex.Lock()
+	defer fake.getFileContentsMutex.Unlock()
+	fake.GetFileContentsStub = stub
+}
+
+func (fake *FakeGithub) GetFileContentsArgsForCall(i int) (string, string) {
+	fake.getFileContentsMutex.RLock()
+	defer fake.getFileContentsMutex.RUnlock()
+	argsForCall := fake.getFileContentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGithub) GetFileContentsReturns(result1 []byte, result2 error) {
+	fake.getFileContentsMutex.Lock()
+	defer fake.getFileContentsMutex.Unlock()
+	fake.GetFileContentsStub = nil
+	fake.getFileContentsReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) GetFileContentsReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.getFileContentsMutex.Lock()
+	defer fake.getFileContentsMutex.Unlock()
+	fake.GetFileContentsStub = nil
+	if fake.getFileContentsReturnsOnCall == nil {
+		fake.getFileContentsReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.getFileContentsReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeGithub) GetPullRequest(arg1 string, arg2 string) (*resource.PullRequest, error) {
 	fake.getPullRequestMutex.Lock()
 	ret, specificReturn := fake.getPullRequestReturnsOnCall[len(fake.getPullRequestArgsForCall)]
@@ -294,70 +1079,452 @@ func (fake *FakeGithub) GetPullRequestReturnsOnCall(i int, result1 *resource.Pul
 	}{result1, result2}
 }
 
-func (fake *FakeGithub) ListModifiedFiles(arg1 int) ([]string, error) {
-	fake.listModifiedFilesMutex.Lock()
-	ret, specificReturn := fake.listModifiedFilesReturnsOnCall[len(fake.listModifiedFilesArgsForCall)]
-	fake.listModifiedFilesArgsForCall = append(fake.listModifiedFilesArgsForCall, struct {
-		arg1 int
+func (fake *FakeGithub) GetRateLimit() (*resource.RateLimit, error) {
+	fake.getRateLimitMutex.Lock()
+	ret, specificReturn := fake.getRateLimitReturnsOnCall[len(fake.getRateLimitArgsForCall)]
+	fake.getRateLimitArgsForCall = append(fake.getRateLimitArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetRateLimit", []interface{}{})
+	fake.getRateLimitMutex.Unlock()
+	if fake.GetRateLimitStub != nil {
+		return fake.GetRateLimitStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getRateLimitReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGithub) GetRateLimitCallCount() int {
+	fake.getRateLimitMutex.RLock()
+	defer fake.getRateLimitMutex.RUnlock()
+	return len(fake.getRateLimitArgsForCall)
+}
+
+func (fake *FakeGithub) GetRateLimitCalls(stub func() (*resource.RateLimit, error)) {
+	fake.getRateLimitMutex.Lock()
+	defer fake.getRateLimitMutex.Unlock()
+	fake.GetRateLimitStub = stub
+}
+
+func (fake *FakeGithub) GetRateLimitReturns(result1 *resource.RateLimit, result2 error) {
+	fake.getRateLimitMutex.Lock()
+	defer fake.getRateLimitMutex.Unlock()
+	fake.GetRateLimitStub = nil
+	fake.getRateLimitReturns = struct {
+		result1 *resource.RateLimit
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) GetRateLimitReturnsOnCall(i int, result1 *resource.RateLimit, result2 error) {
+	fake.getRateLimitMutex.Lock()
+	defer fake.getRateLimitMutex.Unlock()
+	fake.GetRateLimitStub = nil
+	if fake.getRateLimitReturnsOnCall == nil {
+		fake.getRateLimitReturnsOnCall = make(map[int]struct {
+			result1 *resource.RateLimit
+			result2 error
+		})
+	}
+	fake.getRateLimitReturnsOnCall[i] = struct {
+		result1 *resource.RateLimit
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) GetSignatureVerification(arg1 string) (*resource.SignatureVerification, error) {
+	fake.getSignatureVerificationMutex.Lock()
+	ret, specificReturn := fake.getSignatureVerificationReturnsOnCall[len(fake.getSignatureVerificationArgsForCall)]
+	fake.getSignatureVerificationArgsForCall = append(fake.getSignatureVerificationArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetSignatureVerification", []interface{}{arg1})
+	fake.getSignatureVerificationMutex.Unlock()
+	if fake.GetSignatureVerificationStub != nil {
+		return fake.GetSignatureVerificationStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getSignatureVerificationReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGithub) GetSignatureVerificationCallCount() int {
+	fake.getSignatureVerificationMutex.RLock()
+	defer fake.getSignatureVerificationMutex.RUnlock()
+	return len(fake.getSignatureVerificationArgsForCall)
+}
+
+func (fake *FakeGithub) GetSignatureVerificationCalls(stub func(string) (*resource.SignatureVerification, error)) {
+	fake.getSignatureVerificationMutex.Lock()
+	defer fake.getSignatureVerificationMutex.Unlock()
+	fake.GetSignatureVerificationStub = stub
+}
+
+func (fake *FakeGithub) GetSignatureVerificationArgsForCall(i int) string {
+	fake.getSignatureVerificationMutex.RLock()
+	defer fake.getSignatureVerificationMutex.RUnlock()
+	argsForCall := fake.getSignatureVerificationArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGithub) GetSignatureVerificationReturns(result1 *resource.SignatureVerification, result2 error) {
+	fake.getSignatureVerificationMutex.Lock()
+	defer fake.getSignatureVerificationMutex.Unlock()
+	fake.GetSignatureVerificationStub = nil
+	fake.getSignatureVerificationReturns = struct {
+		result1 *resource.SignatureVerification
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) GetSignatureVerificationReturnsOnCall(i int, result1 *resource.SignatureVerification, result2 error) {
+	fake.getSignatureVerificationMutex.Lock()
+	defer fake.getSignatureVerificationMutex.Unlock()
+	fake.GetSignatureVerificationStub = nil
+	if fake.getSignatureVerificationReturnsOnCall == nil {
+		fake.getSignatureVerificationReturnsOnCall = make(map[int]struct {
+			result1 *resource.SignatureVerification
+			result2 error
+		})
+	}
+	fake.getSignatureVerificationReturnsOnCall[i] = struct {
+		result1 *resource.SignatureVerification
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) ListMergeQueueEntries(arg1 string) ([]*resource.MergeQueueEntry, error) {
+	fake.listMergeQueueEntriesMutex.Lock()
+	ret, specificReturn := fake.listMergeQueueEntriesReturnsOnCall[len(fake.listMergeQueueEntriesArgsForCall)]
+	fake.listMergeQueueEntriesArgsForCall = append(fake.listMergeQueueEntriesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ListMergeQueueEntries", []interface{}{arg1})
+	fake.listMergeQueueEntriesMutex.Unlock()
+	if fake.ListMergeQueueEntriesStub != nil {
+		return fake.ListMergeQueueEntriesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listMergeQueueEntriesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGithub) ListMergeQueueEntriesCallCount() int {
+	fake.listMergeQueueEntriesMutex.RLock()
+	defer fake.listMergeQueueEntriesMutex.RUnlock()
+	return len(fake.listMergeQueueEntriesArgsForCall)
+}
+
+func (fake *FakeGithub) ListMergeQueueEntriesCalls(stub func(string) ([]*resource.MergeQueueEntry, error)) {
+	fake.listMergeQueueEntriesMutex.Lock()
+	defer fake.listMergeQueueEntriesMutex.Unlock()
+	fake.ListMergeQueueEntriesStub = stub
+}
+
+func (fake *FakeGithub) ListMergeQueueEntriesArgsForCall(i int) string {
+	fake.listMergeQueueEntriesMutex.RLock()
+	defer fake.listMergeQueueEntriesMutex.RUnlock()
+	argsForCall := fake.listMergeQueueEntriesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGithub) ListMergeQueueEntriesReturns(result1 []*resource.MergeQueueEntry, result2 error) {
+	fake.listMergeQueueEntriesMutex.Lock()
+	defer fake.listMergeQueueEntriesMutex.Unlock()
+	fake.ListMergeQueueEntriesStub = nil
+	fake.listMergeQueueEntriesReturns = struct {
+		result1 []*resource.MergeQueueEntry
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) ListMergeQueueEntriesReturnsOnCall(i int, result1 []*resource.MergeQueueEntry, result2 error) {
+	fake.listMergeQueueEntriesMutex.Lock()
+	defer fake.listMergeQueueEntriesMutex.Unlock()
+	fake.ListMergeQueueEntriesStub = nil
+	if fake.listMergeQueueEntriesReturnsOnCall == nil {
+		fake.listMergeQueueEntriesReturnsOnCall = make(map[int]struct {
+			result1 []*resource.MergeQueueEntry
+			result2 error
+		})
+	}
+	fake.listMergeQueueEntriesReturnsOnCall[i] = struct {
+		result1 []*resource.MergeQueueEntry
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) ListModifiedFiles(arg1 int, arg2 func([]resource.ChangedFileObject) bool) ([]resource.ChangedFileObject, error) {
+	fake.listModifiedFilesMutex.Lock()
+	ret, specificReturn := fake.listModifiedFilesReturnsOnCall[len(fake.listModifiedFilesArgsForCall)]
+	fake.listModifiedFilesArgsForCall = append(fake.listModifiedFilesArgsForCall, struct {
+		arg1 int
+		arg2 func([]resource.ChangedFileObject) bool
+	}{arg1, arg2})
+	fake.recordInvocation("ListModifiedFiles", []interface{}{arg1, arg2})
+	fake.listModifiedFilesMutex.Unlock()
+	if fake.ListModifiedFilesStub != nil {
+		return fake.ListModifiedFilesStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listModifiedFilesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGithub) ListModifiedFilesCallCount() int {
+	fake.listModifiedFilesMutex.RLock()
+	defer fake.listModifiedFilesMutex.RUnlock()
+	return len(fake.listModifiedFilesArgsForCall)
+}
+
+func (fake *FakeGithub) ListModifiedFilesCalls(stub func(int, func([]resource.ChangedFileObject) bool) ([]resource.ChangedFileObject, error)) {
+	fake.listModifiedFilesMutex.Lock()
+	defer fake.listModifiedFilesMutex.Unlock()
+	fake.ListModifiedFilesStub = stub
+}
+
+func (fake *FakeGithub) ListModifiedFilesArgsForCall(i int) (int, func([]resource.ChangedFileObject) bool) {
+	fake.listModifiedFilesMutex.RLock()
+	defer fake.listModifiedFilesMutex.RUnlock()
+	argsForCall := fake.listModifiedFilesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGithub) ListModifiedFilesReturns(result1 []resource.ChangedFileObject, result2 error) {
+	fake.listModifiedFilesMutex.Lock()
+	defer fake.listModifiedFilesMutex.Unlock()
+	fake.ListModifiedFilesStub = nil
+	fake.listModifiedFilesReturns = struct {
+		result1 []resource.ChangedFileObject
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) ListModifiedFilesReturnsOnCall(i int, result1 []resource.ChangedFileObject, result2 error) {
+	fake.listModifiedFilesMutex.Lock()
+	defer fake.listModifiedFilesMutex.Unlock()
+	fake.ListModifiedFilesStub = nil
+	if fake.listModifiedFilesReturnsOnCall == nil {
+		fake.listModifiedFilesReturnsOnCall = make(map[int]struct {
+			result1 []resource.ChangedFileObject
+			result2 error
+		})
+	}
+	fake.listModifiedFilesReturnsOnCall[i] = struct {
+		result1 []resource.ChangedFileObject
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) ListOwnComments(arg1 string) ([]resource.CommentObject, error) {
+	fake.listOwnCommentsMutex.Lock()
+	ret, specificReturn := fake.listOwnCommentsReturnsOnCall[len(fake.listOwnCommentsArgsForCall)]
+	fake.listOwnCommentsArgsForCall = append(fake.listOwnCommentsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ListOwnComments", []interface{}{arg1})
+	fake.listOwnCommentsMutex.Unlock()
+	if fake.ListOwnCommentsStub != nil {
+		return fake.ListOwnCommentsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listOwnCommentsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGithub) ListOwnCommentsCallCount() int {
+	fake.listOwnCommentsMutex.RLock()
+	defer fake.listOwnCommentsMutex.RUnlock()
+	return len(fake.listOwnCommentsArgsForCall)
+}
+
+func (fake *FakeGithub) ListOwnCommentsCalls(stub func(string) ([]resource.CommentObject, error)) {
+	fake.listOwnCommentsMutex.Lock()
+	defer fake.listOwnCommentsMutex.Unlock()
+	fake.ListOwnCommentsStub = stub
+}
+
+func (fake *FakeGithub) ListOwnCommentsArgsForCall(i int) string {
+	fake.listOwnCommentsMutex.RLock()
+	defer fake.listOwnCommentsMutex.RUnlock()
+	argsForCall := fake.listOwnCommentsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGithub) ListOwnCommentsReturns(result1 []resource.CommentObject, result2 error) {
+	fake.listOwnCommentsMutex.Lock()
+	defer fake.listOwnCommentsMutex.Unlock()
+	fake.ListOwnCommentsStub = nil
+	fake.listOwnCommentsReturns = struct {
+		result1 []resource.CommentObject
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) ListOwnCommentsReturnsOnCall(i int, result1 []resource.CommentObject, result2 error) {
+	fake.listOwnCommentsMutex.Lock()
+	defer fake.listOwnCommentsMutex.Unlock()
+	fake.ListOwnCommentsStub = nil
+	if fake.listOwnCommentsReturnsOnCall == nil {
+		fake.listOwnCommentsReturnsOnCall = make(map[int]struct {
+			result1 []resource.CommentObject
+			result2 error
+		})
+	}
+	fake.listOwnCommentsReturnsOnCall[i] = struct {
+		result1 []resource.CommentObject
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) ListPullRequestCommits(arg1 string) ([]resource.PullRequestCommit, error) {
+	fake.listPullRequestCommitsMutex.Lock()
+	ret, specificReturn := fake.listPullRequestCommitsReturnsOnCall[len(fake.listPullRequestCommitsArgsForCall)]
+	fake.listPullRequestCommitsArgsForCall = append(fake.listPullRequestCommitsArgsForCall, struct {
+		arg1 string
 	}{arg1})
-	fake.recordInvocation("ListModifiedFiles", []interface{}{arg1})
-	fake.listModifiedFilesMutex.Unlock()
-	if fake.ListModifiedFilesStub != nil {
-		return fake.ListModifiedFilesStub(arg1)
+	fake.recordInvocation("ListPullRequestCommits", []interface{}{arg1})
+	fake.listPullRequestCommitsMutex.Unlock()
+	if fake.ListPullRequestCommitsStub != nil {
+		return fake.ListPullRequestCommitsStub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.listModifiedFilesReturns
+	fakeReturns := fake.listPullRequestCommitsReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeGithub) ListModifiedFilesCallCount() int {
-	fake.listModifiedFilesMutex.RLock()
-	defer fake.listModifiedFilesMutex.RUnlock()
-	return len(fake.listModifiedFilesArgsForCall)
+func (fake *FakeGithub) ListPullRequestCommitsCallCount() int {
+	fake.listPullRequestCommitsMutex.RLock()
+	defer fake.listPullRequestCommitsMutex.RUnlock()
+	return len(fake.listPullRequestCommitsArgsForCall)
 }
 
-func (fake *FakeGithub) ListModifiedFilesCalls(stub func(int) ([]string, error)) {
-	fake.listModifiedFilesMutex.Lock()
-	defer fake.listModifiedFilesMutex.Unlock()
-	fake.ListModifiedFilesStub = stub
+func (fake *FakeGithub) ListPullRequestCommitsCalls(stub func(string) ([]resource.PullRequestCommit, error)) {
+	fake.listPullRequestCommitsMutex.Lock()
+	defer fake.listPullRequestCommitsMutex.Unlock()
+	fake.ListPullRequestCommitsStub = stub
 }
 
-func (fake *FakeGithub) ListModifiedFilesArgsForCall(i int) int {
-	fake.listModifiedFilesMutex.RLock()
-	defer fake.listModifiedFilesMutex.RUnlock()
-	argsForCall := fake.listModifiedFilesArgsForCall[i]
+func (fake *FakeGithub) ListPullRequestCommitsArgsForCall(i int) string {
+	fake.listPullRequestCommitsMutex.RLock()
+	defer fake.listPullRequestCommitsMutex.RUnlock()
+	argsForCall := fake.listPullRequestCommitsArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *FakeGithub) ListModifiedFilesReturns(result1 []string, result2 error) {
-	fake.listModifiedFilesMutex.Lock()
-	defer fake.listModifiedFilesMutex.Unlock()
-	fake.ListModifiedFilesStub = nil
-	fake.listModifiedFilesReturns = struct {
-		result1 []string
+func (fake *FakeGithub) ListPullRequestCommitsReturns(result1 []resource.PullRequestCommit, result2 error) {
+	fake.listPullRequestCommitsMutex.Lock()
+	defer fake.listPullRequestCommitsMutex.Unlock()
+	fake.ListPullRequestCommitsStub = nil
+	fake.listPullRequestCommitsReturns = struct {
+		result1 []resource.PullRequestCommit
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeGithub) ListModifiedFilesReturnsOnCall(i int, result1 []string, result2 error) {
-	fake.listModifiedFilesMutex.Lock()
-	defer fake.listModifiedFilesMutex.Unlock()
-	fake.ListModifiedFilesStub = nil
-	if fake.listModifiedFilesReturnsOnCall == nil {
-		fake.listModifiedFilesReturnsOnCall = make(map[int]struct {
-			result1 []string
+func (fake *FakeGithub) ListPullRequestCommitsReturnsOnCall(i int, result1 []resource.PullRequestCommit, result2 error) {
+	fake.listPullRequestCommitsMutex.Lock()
+	defer fake.listPullRequestCommitsMutex.Unlock()
+	fake.ListPullRequestCommitsStub = nil
+	if fake.listPullRequestCommitsReturnsOnCall == nil {
+		fake.listPullRequestCommitsReturnsOnCall = make(map[int]struct {
+			result1 []resource.PullRequestCommit
 			result2 error
 		})
 	}
-	fake.listModifiedFilesReturnsOnCall[i] = struct {
-		result1 []string
+	fake.listPullRequestCommitsReturnsOnCall[i] = struct {
+		result1 []resource.PullRequestCommit
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeGithub) ListPullRequests(arg1 []githubv4.PullRequestState) ([]*resource.PullRequest, error) {
+func (fake *FakeGithub) ListPullRequestPages(arg1 []githubv4.PullRequestState, arg2 bool, arg3 string, arg4 int) ([]*resource.PullRequest, string, error) {
+	var arg1Copy []githubv4.PullRequestState
+	if arg1 != nil {
+		arg1Copy = make([]githubv4.PullRequestState, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.listPullRequestPagesMutex.Lock()
+	ret, specificReturn := fake.listPullRequestPagesReturnsOnCall[len(fake.listPullRequestPagesArgsForCall)]
+	fake.listPullRequestPagesArgsForCall = append(fake.listPullRequestPagesArgsForCall, struct {
+		arg1 []githubv4.PullRequestState
+		arg2 bool
+		arg3 string
+		arg4 int
+	}{arg1Copy, arg2, arg3, arg4})
+	fake.recordInvocation("ListPullRequestPages", []interface{}{arg1Copy, arg2, arg3, arg4})
+	fake.listPullRequestPagesMutex.Unlock()
+	if fake.ListPullRequestPagesStub != nil {
+		return fake.ListPullRequestPagesStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	fakeReturns := fake.listPullRequestPagesReturns
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeGithub) ListPullRequestPagesCallCount() int {
+	fake.listPullRequestPagesMutex.RLock()
+	defer fake.listPullRequestPagesMutex.RUnlock()
+	return len(fake.listPullRequestPagesArgsForCall)
+}
+
+func (fake *FakeGithub) ListPullRequestPagesCalls(stub func([]githubv4.PullRequestState, bool, string, int) ([]*resource.PullRequest, string, error)) {
+	fake.listPullRequestPagesMutex.Lock()
+	defer fake.listPullRequestPagesMutex.Unlock()
+	fake.ListPullRequestPagesStub = stub
+}
+
+func (fake *FakeGithub) ListPullRequestPagesArgsForCall(i int) ([]githubv4.PullRequestState, bool, string, int) {
+	fake.listPullRequestPagesMutex.RLock()
+	defer fake.listPullRequestPagesMutex.RUnlock()
+	argsForCall := fake.listPullRequestPagesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeGithub) ListPullRequestPagesReturns(result1 []*resource.PullRequest, result2 string, result3 error) {
+	fake.listPullRequestPagesMutex.Lock()
+	defer fake.listPullRequestPagesMutex.Unlock()
+	fake.ListPullRequestPagesStub = nil
+	fake.listPullRequestPagesReturns = struct {
+		result1 []*resource.PullRequest
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeGithub) ListPullRequestPagesReturnsOnCall(i int, result1 []*resource.PullRequest, result2 string, result3 error) {
+	fake.listPullRequestPagesMutex.Lock()
+	defer fake.listPullRequestPagesMutex.Unlock()
+	fake.ListPullRequestPagesStub = nil
+	if fake.listPullRequestPagesReturnsOnCall == nil {
+		fake.listPullRequestPagesReturnsOnCall = make(map[int]struct {
+			result1 []*resource.PullRequest
+			result2 string
+			result3 error
+		})
+	}
+	fake.listPullRequestPagesReturnsOnCall[i] = struct {
+		result1 []*resource.PullRequest
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeGithub) ListPullRequests(arg1 []githubv4.PullRequestState, arg2 bool) ([]*resource.PullRequest, error) {
 	var arg1Copy []githubv4.PullRequestState
 	if arg1 != nil {
 		arg1Copy = make([]githubv4.PullRequestState, len(arg1))
@@ -367,11 +1534,12 @@ func (fake *FakeGithub) ListPullRequests(arg1 []githubv4.PullRequestState) ([]*r
 	ret, specificReturn := fake.listPullRequestsReturnsOnCall[len(fake.listPullRequestsArgsForCall)]
 	fake.listPullRequestsArgsForCall = append(fake.listPullRequestsArgsForCall, struct {
 		arg1 []githubv4.PullRequestState
-	}{arg1Copy})
-	fake.recordInvocation("ListPullRequests", []interface{}{arg1Copy})
+		arg2 bool
+	}{arg1Copy, arg2})
+	fake.recordInvocation("ListPullRequests", []interface{}{arg1Copy, arg2})
 	fake.listPullRequestsMutex.Unlock()
 	if fake.ListPullRequestsStub != nil {
-		return fake.ListPullRequestsStub(arg1)
+		return fake.ListPullRequestsStub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -386,17 +1554,17 @@ func (fake *FakeGithub) ListPullRequestsCallCount() int {
 	return len(fake.listPullRequestsArgsForCall)
 }
 
-func (fake *FakeGithub) ListPullRequestsCalls(stub func([]githubv4.PullRequestState) ([]*resource.PullRequest, error)) {
+func (fake *FakeGithub) ListPullRequestsCalls(stub func([]githubv4.PullRequestState, bool) ([]*resource.PullRequest, error)) {
 	fake.listPullRequestsMutex.Lock()
 	defer fake.listPullRequestsMutex.Unlock()
 	fake.ListPullRequestsStub = stub
 }
 
-func (fake *FakeGithub) ListPullRequestsArgsForCall(i int) []githubv4.PullRequestState {
+func (fake *FakeGithub) ListPullRequestsArgsForCall(i int) ([]githubv4.PullRequestState, bool) {
 	fake.listPullRequestsMutex.RLock()
 	defer fake.listPullRequestsMutex.RUnlock()
 	argsForCall := fake.listPullRequestsArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeGithub) ListPullRequestsReturns(result1 []*resource.PullRequest, result2 error) {
@@ -425,6 +1593,194 @@ func (fake *FakeGithub) ListPullRequestsReturnsOnCall(i int, result1 []*resource
 	}{result1, result2}
 }
 
+func (fake *FakeGithub) ListTeamMembers(arg1 string) ([]string, error) {
+	fake.listTeamMembersMutex.Lock()
+	ret, specificReturn := fake.listTeamMembersReturnsOnCall[len(fake.listTeamMembersArgsForCall)]
+	fake.listTeamMembersArgsForCall = append(fake.listTeamMembersArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ListTeamMembers", []interface{}{arg1})
+	fake.listTeamMembersMutex.Unlock()
+	if fake.ListTeamMembersStub != nil {
+		return fake.ListTeamMembersStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listTeamMembersReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGithub) ListTeamMembersCallCount() int {
+	fake.listTeamMembersMutex.RLock()
+	defer fake.listTeamMembersMutex.RUnlock()
+	return len(fake.listTeamMembersArgsForCall)
+}
+
+func (fake *FakeGithub) ListTeamMembersCalls(stub func(string) ([]string, error)) {
+	fake.listTeamMembersMutex.Lock()
+	defer fake.listTeamMembersMutex.Unlock()
+	fake.ListTeamMembersStub = stub
+}
+
+func (fake *FakeGithub) ListTeamMembersArgsForCall(i int) string {
+	fake.listTeamMembersMutex.RLock()
+	defer fake.listTeamMembersMutex.RUnlock()
+	argsForCall := fake.listTeamMembersArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGithub) ListTeamMembersReturns(result1 []string, result2 error) {
+	fake.listTeamMembersMutex.Lock()
+	defer fake.listTeamMembersMutex.Unlock()
+	fake.ListTeamMembersStub = nil
+	fake.listTeamMembersReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) ListTeamMembersReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.listTeamMembersMutex.Lock()
+	defer fake.listTeamMembersMutex.Unlock()
+	fake.ListTeamMembersStub = nil
+	if fake.listTeamMembersReturnsOnCall == nil {
+		fake.listTeamMembersReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.listTeamMembersReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) ListUserTeams(arg1 string) ([]string, error) {
+	fake.listUserTeamsMutex.Lock()
+	ret, specificReturn := fake.listUserTeamsReturnsOnCall[len(fake.listUserTeamsArgsForCall)]
+	fake.listUserTeamsArgsForCall = append(fake.listUserTeamsArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("ListUserTeams", []interface{}{arg1})
+	fake.listUserTeamsMutex.Unlock()
+	if fake.ListUserTeamsStub != nil {
+		return fake.ListUserTeamsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.listUserTeamsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGithub) ListUserTeamsCallCount() int {
+	fake.listUserTeamsMutex.RLock()
+	defer fake.listUserTeamsMutex.RUnlock()
+	return len(fake.listUserTeamsArgsForCall)
+}
+
+func (fake *FakeGithub) ListUserTeamsCalls(stub func(string) ([]string, error)) {
+	fake.listUserTeamsMutex.Lock()
+	defer fake.listUserTeamsMutex.Unlock()
+	fake.ListUserTeamsStub = stub
+}
+
+func (fake *FakeGithub) ListUserTeamsArgsForCall(i int) string {
+	fake.listUserTeamsMutex.RLock()
+	defer fake.listUserTeamsMutex.RUnlock()
+	argsForCall := fake.listUserTeamsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGithub) ListUserTeamsReturns(result1 []string, result2 error) {
+	fake.listUserTeamsMutex.Lock()
+	defer fake.listUserTeamsMutex.Unlock()
+	fake.ListUserTeamsStub = nil
+	fake.listUserTeamsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) ListUserTeamsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.listUserTeamsMutex.Lock()
+	defer fake.listUserTeamsMutex.Unlock()
+	fake.ListUserTeamsStub = nil
+	if fake.listUserTeamsReturnsOnCall == nil {
+		fake.listUserTeamsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.listUserTeamsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGithub) MinimizePreviousComments(arg1 string, arg2 string, arg3 string) error {
+	fake.minimizePreviousCommentsMutex.Lock()
+	ret, specificReturn := fake.minimizePreviousCommentsReturnsOnCall[len(fake.minimizePreviousCommentsArgsForCall)]
+	fake.minimizePreviousCommentsArgsForCall = append(fake.minimizePreviousCommentsArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("MinimizePreviousComments", []interface{}{arg1, arg2, arg3})
+	fake.minimizePreviousCommentsMutex.Unlock()
+	if fake.MinimizePreviousCommentsStub != nil {
+		return fake.MinimizePreviousCommentsStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.minimizePreviousCommentsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGithub) MinimizePreviousCommentsCallCount() int {
+	fake.minimizePreviousCommentsMutex.RLock()
+	defer fake.minimizePreviousCommentsMutex.RUnlock()
+	return len(fake.minimizePreviousCommentsArgsForCall)
+}
+
+func (fake *FakeGithub) MinimizePreviousCommentsCalls(stub func(string, string, string) error) {
+	fake.minimizePreviousCommentsMutex.Lock()
+	defer fake.minimizePreviousCommentsMutex.Unlock()
+	fake.MinimizePreviousCommentsStub = stub
+}
+
+func (fake *FakeGithub) MinimizePreviousCommentsArgsForCall(i int) (string, string, string) {
+	fake.minimizePreviousCommentsMutex.RLock()
+	defer fake.minimizePreviousCommentsMutex.RUnlock()
+	argsForCall := fake.minimizePreviousCommentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeGithub) MinimizePreviousCommentsReturns(result1 error) {
+	fake.minimizePreviousCommentsMutex.Lock()
+	defer fake.minimizePreviousCommentsMutex.Unlock()
+	fake.MinimizePreviousCommentsStub = nil
+	fake.minimizePreviousCommentsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) MinimizePreviousCommentsReturnsOnCall(i int, result1 error) {
+	fake.minimizePreviousCommentsMutex.Lock()
+	defer fake.minimizePreviousCommentsMutex.Unlock()
+	fake.MinimizePreviousCommentsStub = nil
+	if fake.minimizePreviousCommentsReturnsOnCall == nil {
+		fake.minimizePreviousCommentsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.minimizePreviousCommentsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeGithub) PostComment(arg1 string, arg2 string) error {
 	fake.postCommentMutex.Lock()
 	ret, specificReturn := fake.postCommentReturnsOnCall[len(fake.postCommentArgsForCall)]
@@ -486,6 +1842,250 @@ func (fake *FakeGithub) PostCommentReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeGithub) RemoveLabel(arg1 string, arg2 string) error {
+	fake.removeLabelMutex.Lock()
+	ret, specificReturn := fake.removeLabelReturnsOnCall[len(fake.removeLabelArgsForCall)]
+	fake.removeLabelArgsForCall = append(fake.removeLabelArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("RemoveLabel", []interface{}{arg1, arg2})
+	fake.removeLabelMutex.Unlock()
+	if fake.RemoveLabelStub != nil {
+		return fake.RemoveLabelStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.removeLabelReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGithub) RemoveLabelCallCount() int {
+	fake.removeLabelMutex.RLock()
+	defer fake.removeLabelMutex.RUnlock()
+	return len(fake.removeLabelArgsForCall)
+}
+
+func (fake *FakeGithub) RemoveLabelCalls(stub func(string, string) error) {
+	fake.removeLabelMutex.Lock()
+	defer fake.removeLabelMutex.Unlock()
+	fake.RemoveLabelStub = stub
+}
+
+func (fake *FakeGithub) RemoveLabelArgsForCall(i int) (string, string) {
+	fake.removeLabelMutex.RLock()
+	defer fake.removeLabelMutex.RUnlock()
+	argsForCall := fake.removeLabelArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGithub) RemoveLabelReturns(result1 error) {
+	fake.removeLabelMutex.Lock()
+	defer fake.removeLabelMutex.Unlock()
+	fake.RemoveLabelStub = nil
+	fake.removeLabelReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) RemoveLabelReturnsOnCall(i int, result1 error) {
+	fake.removeLabelMutex.Lock()
+	defer fake.removeLabelMutex.Unlock()
+	fake.RemoveLabelStub = nil
+	if fake.removeLabelReturnsOnCall == nil {
+		fake.removeLabelReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeLabelReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) RerequestCheckSuites(arg1 string) error {
+	fake.rerequestCheckSuitesMutex.Lock()
+	ret, specificReturn := fake.rerequestCheckSuitesReturnsOnCall[len(fake.rerequestCheckSuitesArgsForCall)]
+	fake.rerequestCheckSuitesArgsForCall = append(fake.rerequestCheckSuitesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("RerequestCheckSuites", []interface{}{arg1})
+	fake.rerequestCheckSuitesMutex.Unlock()
+	if fake.RerequestCheckSuitesStub != nil {
+		return fake.RerequestCheckSuitesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.rerequestCheckSuitesReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGithub) RerequestCheckSuitesCallCount() int {
+	fake.rerequestCheckSuitesMutex.RLock()
+	defer fake.rerequestCheckSuitesMutex.RUnlock()
+	return len(fake.rerequestCheckSuitesArgsForCall)
+}
+
+func (fake *FakeGithub) RerequestCheckSuitesCalls(stub func(string) error) {
+	fake.rerequestCheckSuitesMutex.Lock()
+	defer fake.rerequestCheckSuitesMutex.Unlock()
+	fake.RerequestCheckSuitesStub = stub
+}
+
+func (fake *FakeGithub) RerequestCheckSuitesArgsForCall(i int) string {
+	fake.rerequestCheckSuitesMutex.RLock()
+	defer fake.rerequestCheckSuitesMutex.RUnlock()
+	argsForCall := fake.rerequestCheckSuitesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGithub) RerequestCheckSuitesReturns(result1 error) {
+	fake.rerequestCheckSuitesMutex.Lock()
+	defer fake.rerequestCheckSuitesMutex.Unlock()
+	fake.RerequestCheckSuitesStub = nil
+	fake.rerequestCheckSuitesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) RerequestCheckSuitesReturnsOnCall(i int, result1 error) {
+	fake.rerequestCheckSuitesMutex.Lock()
+	defer fake.rerequestCheckSuitesMutex.Unlock()
+	fake.RerequestCheckSuitesStub = nil
+	if fake.rerequestCheckSuitesReturnsOnCall == nil {
+		fake.rerequestCheckSuitesReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.rerequestCheckSuitesReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) SetLocked(arg1 string, arg2 bool, arg3 string) error {
+	fake.setLockedMutex.Lock()
+	ret, specificReturn := fake.setLockedReturnsOnCall[len(fake.setLockedArgsForCall)]
+	fake.setLockedArgsForCall = append(fake.setLockedArgsForCall, struct {
+		arg1 string
+		arg2 bool
+		arg3 string
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("SetLocked", []interface{}{arg1, arg2, arg3})
+	fake.setLockedMutex.Unlock()
+	if fake.SetLockedStub != nil {
+		return fake.SetLockedStub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.setLockedReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGithub) SetLockedCallCount() int {
+	fake.setLockedMutex.RLock()
+	defer fake.setLockedMutex.RUnlock()
+	return len(fake.setLockedArgsForCall)
+}
+
+func (fake *FakeGithub) SetLockedCalls(stub func(string, bool, string) error) {
+	fake.setLockedMutex.Lock()
+	defer fake.setLockedMutex.Unlock()
+	fake.SetLockedStub = stub
+}
+
+func (fake *FakeGithub) SetLockedArgsForCall(i int) (string, bool, string) {
+	fake.setLockedMutex.RLock()
+	defer fake.setLockedMutex.RUnlock()
+	argsForCall := fake.setLockedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeGithub) SetLockedReturns(result1 error) {
+	fake.setLockedMutex.Lock()
+	defer fake.setLockedMutex.Unlock()
+	fake.SetLockedStub = nil
+	fake.setLockedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) SetLockedReturnsOnCall(i int, result1 error) {
+	fake.setLockedMutex.Lock()
+	defer fake.setLockedMutex.Unlock()
+	fake.SetLockedStub = nil
+	if fake.setLockedReturnsOnCall == nil {
+		fake.setLockedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setLockedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) UpdateComment(arg1 string, arg2 string) error {
+	fake.updateCommentMutex.Lock()
+	ret, specificReturn := fake.updateCommentReturnsOnCall[len(fake.updateCommentArgsForCall)]
+	fake.updateCommentArgsForCall = append(fake.updateCommentArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("UpdateComment", []interface{}{arg1, arg2})
+	fake.updateCommentMutex.Unlock()
+	if fake.UpdateCommentStub != nil {
+		return fake.UpdateCommentStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.updateCommentReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeGithub) UpdateCommentCallCount() int {
+	fake.updateCommentMutex.RLock()
+	defer fake.updateCommentMutex.RUnlock()
+	return len(fake.updateCommentArgsForCall)
+}
+
+func (fake *FakeGithub) UpdateCommentCalls(stub func(string, string) error) {
+	fake.updateCommentMutex.Lock()
+	defer fake.updateCommentMutex.Unlock()
+	fake.UpdateCommentStub = stub
+}
+
+func (fake *FakeGithub) UpdateCommentArgsForCall(i int) (string, string) {
+	fake.updateCommentMutex.RLock()
+	defer fake.updateCommentMutex.RUnlock()
+	argsForCall := fake.updateCommentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGithub) UpdateCommentReturns(result1 error) {
+	fake.updateCommentMutex.Lock()
+	defer fake.updateCommentMutex.Unlock()
+	fake.UpdateCommentStub = nil
+	fake.updateCommentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGithub) UpdateCommentReturnsOnCall(i int, result1 error) {
+	fake.updateCommentMutex.Lock()
+	defer fake.updateCommentMutex.Unlock()
+	fake.UpdateCommentStub = nil
+	if fake.updateCommentReturnsOnCall == nil {
+		fake.updateCommentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateCommentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeGithub) UpdateCommitStatus(arg1 string, arg2 string, arg3 string, arg4 string, arg5 string, arg6 string) error {
 	fake.updateCommitStatusMutex.Lock()
 	ret, specificReturn := fake.updateCommitStatusReturnsOnCall[len(fake.updateCommitStatusArgsForCall)]
@@ -554,18 +2154,60 @@ func (fake *FakeGithub) UpdateCommitStatusReturnsOnCall(i int, result1 error) {
 func (fake *FakeGithub) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.addLabelsMutex.RLock()
+	defer fake.addLabelsMutex.RUnlock()
+	fake.addReactionMutex.RLock()
+	defer fake.addReactionMutex.RUnlock()
+	fake.closePullRequestMutex.RLock()
+	defer fake.closePullRequestMutex.RUnlock()
+	fake.createGistMutex.RLock()
+	defer fake.createGistMutex.RUnlock()
+	fake.createOrUpdateIssueMutex.RLock()
+	defer fake.createOrUpdateIssueMutex.RUnlock()
 	fake.deletePreviousCommentsMutex.RLock()
 	defer fake.deletePreviousCommentsMutex.RUnlock()
+	fake.dismissStaleReviewsMutex.RLock()
+	defer fake.dismissStaleReviewsMutex.RUnlock()
+	fake.downloadArchiveMutex.RLock()
+	defer fake.downloadArchiveMutex.RUnlock()
 	fake.getChangedFilesMutex.RLock()
 	defer fake.getChangedFilesMutex.RUnlock()
+	fake.getFileContentsMutex.RLock()
+	defer fake.getFileContentsMutex.RUnlock()
 	fake.getPullRequestMutex.RLock()
 	defer fake.getPullRequestMutex.RUnlock()
+	fake.getRateLimitMutex.RLock()
+	defer fake.getRateLimitMutex.RUnlock()
+	fake.getSignatureVerificationMutex.RLock()
+	defer fake.getSignatureVerificationMutex.RUnlock()
+	fake.listMergeQueueEntriesMutex.RLock()
+	defer fake.listMergeQueueEntriesMutex.RUnlock()
 	fake.listModifiedFilesMutex.RLock()
 	defer fake.listModifiedFilesMutex.RUnlock()
+	fake.listOwnCommentsMutex.RLock()
+	defer fake.listOwnCommentsMutex.RUnlock()
+	fake.listPullRequestCommitsMutex.RLock()
+	defer fake.listPullRequestCommitsMutex.RUnlock()
+	fake.listPullRequestPagesMutex.RLock()
+	defer fake.listPullRequestPagesMutex.RUnlock()
 	fake.listPullRequestsMutex.RLock()
 	defer fake.listPullRequestsMutex.RUnlock()
+	fake.listTeamMembersMutex.RLock()
+	defer fake.listTeamMembersMutex.RUnlock()
+	fake.listUserTeamsMutex.RLock()
+	defer fake.listUserTeamsMutex.RUnlock()
+	fake.minimizePreviousCommentsMutex.RLock()
+	defer fake.minimizePreviousCommentsMutex.RUnlock()
 	fake.postCommentMutex.RLock()
 	defer fake.postCommentMutex.RUnlock()
+	fake.removeLabelMutex.RLock()
+	defer fake.removeLabelMutex.RUnlock()
+	fake.rerequestCheckSuitesMutex.RLock()
+	defer fake.rerequestCheckSuitesMutex.RUnlock()
+	fake.setLockedMutex.RLock()
+	defer fake.setLockedMutex.RUnlock()
+	fake.updateCommentMutex.RLock()
+	defer fake.updateCommentMutex.RUnlock()
 	fake.updateCommitStatusMutex.RLock()
 	defer fake.updateCommitStatusMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
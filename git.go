@@ -1,7 +1,10 @@
 package resource
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,6 +14,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Git interface for testing purposes.
@@ -19,11 +23,19 @@ type Git interface {
 	Init(string) error
 	Pull(string, string, int, bool, bool) error
 	RevParse(string) (string, error)
+	MergeBase(string, string) (string, error)
+	CheckoutTree(string, string) error
 	Fetch(string, int, int, bool) error
+	FetchFork(string, string, int, bool) error
 	Checkout(string, string, bool) error
 	Merge(string, bool) error
 	Rebase(string, string, bool) error
+	Reset(string, int) error
 	GitCryptUnlock(string) error
+	AddReference(string) error
+	Dissociate() error
+	Bundle(string) error
+	VerifyCommit(string, []string) error
 }
 
 // NewGitClient ...
@@ -35,66 +47,154 @@ func NewGitClient(source *Source, dir string, output io.Writer) (*GitClient, err
 		os.Setenv("GIT_LFS_SKIP_SMUDGE", "true")
 	}
 	return &GitClient{
-		AccessToken: source.AccessToken,
-		Directory:   dir,
-		Output:      output,
+		AccessToken:     source.AccessToken,
+		ForkAccessToken: source.ForkAccessToken,
+		Directory:       dir,
+		Output:          output,
+		Timeout:         time.Duration(source.GitTimeout),
+		Trace:           source.GitTrace,
+		Args:            source.GitArgs,
 	}, nil
 }
 
 // GitClient ...
 type GitClient struct {
-	AccessToken string
-	Directory   string
-	Output      io.Writer
+	AccessToken     string
+	ForkAccessToken string
+	Directory       string
+	Output          io.Writer
+	Timeout         time.Duration
+	Trace           bool
+	Args            []string
 }
 
 func (g *GitClient) command(name string, arg ...string) *exec.Cmd {
+	if name == "git" {
+		arg = append(append([]string{}, g.Args...), arg...)
+	}
+	logger.Debug("running command", "command", name, "args", strings.Join(arg, " "))
 	cmd := exec.Command(name, arg...)
 	cmd.Dir = g.Directory
 	cmd.Stdout = g.Output
 	cmd.Stderr = g.Output
 	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env,
-		"X_OAUTH_BASIC_TOKEN="+g.AccessToken,
-		"GIT_ASKPASS=/usr/local/bin/askpass.sh")
+	cmd.Env = append(cmd.Env, "X_OAUTH_BASIC_TOKEN="+g.AccessToken)
+	cmd.Env = append(cmd.Env, askpassEnv()...)
+	if g.Trace {
+		cmd.Env = append(cmd.Env, "GIT_TRACE=1", "GIT_CURL_VERBOSE=1")
+	}
 	return cmd
 }
 
+// redact the access tokens (which are part of URLs and authorization headers) from output.
+func (g *GitClient) redact(b []byte) []byte {
+	for _, token := range []string{g.AccessToken, g.ForkAccessToken} {
+		if token == "" {
+			continue
+		}
+		basic := base64.StdEncoding.EncodeToString([]byte("x-oauth-basic:" + token))
+		for _, secret := range []string{basic, token} {
+			b = bytes.Replace(b, []byte(secret), []byte("<redacted>"), -1)
+		}
+	}
+	return b
+}
+
+// run the command, killing it if it does not finish within the configured timeout (if any).
+func (g *GitClient) run(cmd *exec.Cmd) (err error) {
+	name := filepath.Base(cmd.Args[0])
+	if len(cmd.Args) > 1 {
+		name += " " + cmd.Args[1]
+	}
+	span := StartSpan(name)
+	defer func() { span.End(err) }()
+
+	// Errors can contain the arguments of the command (e.g. URLs with the access token).
+	defer func() {
+		if err != nil {
+			if msg := string(g.redact([]byte(err.Error()))); msg != err.Error() {
+				err = errors.New(msg)
+			}
+		}
+	}()
+
+	// Trace output is written once the command has finished, with the access token
+	// redacted, including for commands whose output is otherwise discarded.
+	if g.Trace && (cmd.Stderr == ioutil.Discard || cmd.Stderr == g.Output) {
+		var trace bytes.Buffer
+		cmd.Stderr = &trace
+		defer func() { g.Output.Write(g.redact(trace.Bytes())) }()
+	}
+
+	if g.Timeout <= 0 {
+		return cmd.Run()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), g.Timeout)
+	defer cancel()
+
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+
+		// Helpers which escaped the process group can keep the output open, so do not
+		// wait for it to be closed for long.
+		select {
+		case <-done:
+		case <-time.After(gitKillDelay):
+		}
+		return fmt.Errorf("timed out after %s", g.Timeout)
+	}
+}
+
+// gitKillDelay is how long to wait for the output of a killed git command to be closed.
+const gitKillDelay = 5 * time.Second
+
 // Init ...
 func (g *GitClient) Init(branch string) error {
-	if err := g.command("git", "init").Run(); err != nil {
+	if err := g.run(g.command("git", "init")); err != nil {
 		return fmt.Errorf("init failed: %s", err)
 	}
-	if err := g.command("git", "checkout", "-b", branch).Run(); err != nil {
+	if err := g.run(g.command("git", "checkout", "-b", branch)); err != nil {
 		return fmt.Errorf("checkout to '%s' failed: %s", branch, err)
 	}
-	if err := g.command("git", "config", "user.name", "concourse-ci").Run(); err != nil {
+	if err := g.run(g.command("git", "config", "user.name", "concourse-ci")); err != nil {
 		return fmt.Errorf("failed to configure git user: %s", err)
 	}
-	if err := g.command("git", "config", "user.email", "concourse@local").Run(); err != nil {
+	if err := g.run(g.command("git", "config", "user.email", "concourse@local")); err != nil {
 		return fmt.Errorf("failed to configure git email: %s", err)
 	}
-	if err := g.command("git", "config", "url.https://x-oauth-basic@github.com/.insteadOf", "git@github.com:").Run(); err != nil {
+	if err := g.run(g.command("git", "config", "url.https://x-oauth-basic@github.com/.insteadOf", "git@github.com:")); err != nil {
 		return fmt.Errorf("failed to configure github url: %s", err)
 	}
-	if err := g.command("git", "config", "url.https://.insteadOf", "git://").Run(); err != nil {
+	if err := g.run(g.command("git", "config", "url.https://.insteadOf", "git://")); err != nil {
 		return fmt.Errorf("failed to configure github url: %s", err)
 	}
 	return nil
 }
 
-// Pull ...
+// Pull the branch into the current branch. The branch is fetched into its remote-tracking
+// branch without writing FETCH_HEAD, so that pull requests can be fetched at the same time.
 func (g *GitClient) Pull(uri, branch string, depth int, submodules bool, fetchTags bool) error {
 	endpoint, err := g.Endpoint(uri)
 	if err != nil {
 		return err
 	}
 
-	if err := g.command("git", "remote", "add", "origin", endpoint).Run(); err != nil {
-		return fmt.Errorf("setting 'origin' remote to '%s' failed: %s", endpoint, err)
+	if err := g.run(g.command("git", "remote", "add", "origin", endpoint)); err != nil {
+		return fmt.Errorf("setting 'origin' remote to '%s' failed: %s", uri, err)
 	}
 
-	args := []string{"pull", "origin", branch}
+	args := []string{"fetch", "--no-write-fetch-head", "origin", fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branch, branch)}
 	if depth > 0 {
 		args = append(args, "--depth", strconv.Itoa(depth))
 	}
@@ -110,12 +210,15 @@ func (g *GitClient) Pull(uri, branch string, depth int, submodules bool, fetchTa
 	cmd.Stdout = ioutil.Discard
 	cmd.Stderr = ioutil.Discard
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("pull failed: %s", cmd)
+	if err := g.run(cmd); err != nil {
+		return fmt.Errorf("pull failed: %w", err)
+	}
+	if err := g.run(g.command("git", "reset", "--hard", "origin/"+branch)); err != nil {
+		return fmt.Errorf("pull failed: %w", err)
 	}
 	if submodules {
 		submodulesGet := g.command("git", "submodule", "update", "--init", "--recursive")
-		if err := submodulesGet.Run(); err != nil {
+		if err := g.run(submodulesGet); err != nil {
 			return fmt.Errorf("submodule update failed: %s", err)
 		}
 	}
@@ -124,23 +227,60 @@ func (g *GitClient) Pull(uri, branch string, depth int, submodules bool, fetchTa
 
 // RevParse retrieves the SHA of the given branch.
 func (g *GitClient) RevParse(branch string) (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--verify", branch)
-	cmd.Dir = g.Directory
-	sha, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("rev-parse '%s' failed: %s: %s", branch, err, string(sha))
+	var sha, stderr bytes.Buffer
+	cmd := g.command("git", "rev-parse", "--verify", branch)
+	cmd.Stdout = &sha
+	cmd.Stderr = &stderr
+	if err := g.run(cmd); err != nil {
+		return "", fmt.Errorf("rev-parse '%s' failed: %s: %s", branch, err, stderr.String())
+	}
+	return strings.TrimSpace(sha.String()), nil
+}
+
+// MergeBase returns the best common ancestor of two commits.
+func (g *GitClient) MergeBase(a, b string) (string, error) {
+	var sha bytes.Buffer
+	cmd := g.command("git", "merge-base", a, b)
+	cmd.Stdout = &sha
+	if err := g.run(cmd); err != nil {
+		return "", fmt.Errorf("merge-base '%s' '%s' failed: %s", a, b, err)
 	}
-	return strings.TrimSpace(string(sha)), nil
+	return strings.TrimSpace(sha.String()), nil
 }
 
-// Fetch ...
+// CheckoutTree writes the tree of a commit to a directory outside of the working tree,
+// without touching the index or the working tree of the repository itself. Files are
+// checked out with the filters of the repository (e.g. git-crypt, once unlocked), and
+// the directory is replaced if it already exists.
+func (g *GitClient) CheckoutTree(sha, dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove %s: %s", dir, err)
+	}
+	index := filepath.Join(g.Directory, ".git", "index."+filepath.Base(dir))
+	defer os.Remove(index)
+
+	read := g.command("git", "read-tree", sha)
+	read.Env = append(read.Env, "GIT_INDEX_FILE="+index)
+	if err := g.run(read); err != nil {
+		return fmt.Errorf("read-tree '%s' failed: %s", sha, err)
+	}
+	checkout := g.command("git", "checkout-index", "--all", "--force", "--prefix="+dir+"/")
+	checkout.Env = append(checkout.Env, "GIT_INDEX_FILE="+index)
+	if err := g.run(checkout); err != nil {
+		return fmt.Errorf("checkout of '%s' to %s failed: %s", sha, dir, err)
+	}
+	return nil
+}
+
+// Fetch the head of a pull request into refs/remotes/origin/pull/<number>, without writing
+// FETCH_HEAD, so that the base can be pulled at the same time.
 func (g *GitClient) Fetch(uri string, prNumber int, depth int, submodules bool) error {
 	endpoint, err := g.Endpoint(uri)
 	if err != nil {
 		return err
 	}
 
-	args := []string{"fetch", endpoint, fmt.Sprintf("pull/%s/head", strconv.Itoa(prNumber))}
+	args := []string{"fetch", "--no-write-fetch-head", endpoint, fmt.Sprintf("+refs/pull/%d/head:refs/remotes/origin/pull/%d", prNumber, prNumber)}
 	if depth > 0 {
 		args = append(args, "--depth", strconv.Itoa(depth))
 	}
@@ -153,20 +293,50 @@ func (g *GitClient) Fetch(uri string, prNumber int, depth int, submodules bool)
 	cmd.Stdout = ioutil.Discard
 	cmd.Stderr = ioutil.Discard
 
-	if err := cmd.Run(); err != nil {
+	if err := g.run(cmd); err != nil {
 		return fmt.Errorf("fetch failed: %s", err)
 	}
 	return nil
 }
 
+// FetchFork fetches the head branch of a pull request from its fork with the fork access
+// token, for forks which cannot be read with the access token. Like Fetch, it does not
+// write FETCH_HEAD.
+func (g *GitClient) FetchFork(uri string, branch string, depth int, submodules bool) error {
+	endpoint, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("failed to parse fork url: %s", err)
+	}
+	endpoint.User = url.UserPassword("x-oauth-basic", g.ForkAccessToken)
+
+	args := []string{"fetch", "--no-write-fetch-head", endpoint.String(), fmt.Sprintf("+refs/heads/%s:refs/remotes/fork/%s", branch, branch)}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	if submodules {
+		args = append(args, "--recurse-submodules")
+	}
+	cmd := g.command("git", args...)
+	cmd.Env = append(cmd.Env, "X_OAUTH_BASIC_TOKEN="+g.ForkAccessToken)
+
+	// Discard output to have zero chance of logging the access token.
+	cmd.Stdout = ioutil.Discard
+	cmd.Stderr = ioutil.Discard
+
+	if err := g.run(cmd); err != nil {
+		return fmt.Errorf("fetch from fork failed: %s", err)
+	}
+	return nil
+}
+
 // CheckOut
 func (g *GitClient) Checkout(branch, sha string, submodules bool) error {
-	if err := g.command("git", "checkout", "-b", branch, sha).Run(); err != nil {
+	if err := g.run(g.command("git", "checkout", "-b", branch, sha)); err != nil {
 		return fmt.Errorf("checkout failed: %s", err)
 	}
 
 	if submodules {
-		if err := g.command("git", "submodule", "update", "--init", "--recursive", "--checkout").Run(); err != nil {
+		if err := g.run(g.command("git", "submodule", "update", "--init", "--recursive", "--checkout")); err != nil {
 			return fmt.Errorf("submodule update failed: %s", err)
 		}
 	}
@@ -174,14 +344,38 @@ func (g *GitClient) Checkout(branch, sha string, submodules bool) error {
 	return nil
 }
 
+// Reset the current branch to the given commit, fetching it first if it is
+// not part of the (shallow) history that was pulled.
+func (g *GitClient) Reset(sha string, depth int) error {
+	if err := g.run(g.command("git", "cat-file", "-e", sha+"^{commit}")); err != nil {
+		args := []string{"fetch", "origin", sha}
+		if depth > 0 {
+			args = append(args, "--depth", strconv.Itoa(depth))
+		}
+		cmd := g.command("git", args...)
+
+		// Discard output to have zero chance of logging the access token.
+		cmd.Stdout = ioutil.Discard
+		cmd.Stderr = ioutil.Discard
+
+		if err := g.run(cmd); err != nil {
+			return fmt.Errorf("fetch of base commit '%s' failed: %s", sha, err)
+		}
+	}
+	if err := g.run(g.command("git", "reset", "--hard", sha)); err != nil {
+		return fmt.Errorf("reset to '%s' failed: %s", sha, err)
+	}
+	return nil
+}
+
 // Merge ...
 func (g *GitClient) Merge(sha string, submodules bool) error {
-	if err := g.command("git", "merge", sha, "--no-stat").Run(); err != nil {
+	if err := g.run(g.command("git", "merge", sha, "--no-stat")); err != nil {
 		return fmt.Errorf("merge failed: %s", err)
 	}
 
 	if submodules {
-		if err := g.command("git", "submodule", "update", "--init", "--recursive", "--merge").Run(); err != nil {
+		if err := g.run(g.command("git", "submodule", "update", "--init", "--recursive", "--merge")); err != nil {
 			return fmt.Errorf("submodule update failed: %s", err)
 		}
 	}
@@ -191,12 +385,12 @@ func (g *GitClient) Merge(sha string, submodules bool) error {
 
 // Rebase ...
 func (g *GitClient) Rebase(baseRef string, headSha string, submodules bool) error {
-	if err := g.command("git", "rebase", baseRef, headSha).Run(); err != nil {
+	if err := g.run(g.command("git", "rebase", baseRef, headSha)); err != nil {
 		return fmt.Errorf("rebase failed: %s", err)
 	}
 
 	if submodules {
-		if err := g.command("git", "submodule", "update", "--init", "--recursive", "--rebase").Run(); err != nil {
+		if err := g.run(g.command("git", "submodule", "update", "--init", "--recursive", "--rebase")); err != nil {
 			return fmt.Errorf("submodule update failed: %s", err)
 		}
 	}
@@ -219,12 +413,107 @@ func (g *GitClient) GitCryptUnlock(base64key string) error {
 	if err := ioutil.WriteFile(keyPath, decodedKey, os.FileMode(0600)); err != nil {
 		return fmt.Errorf("failed to write git-crypt key to file: %s", err)
 	}
-	if err := g.command("git-crypt", "unlock", keyPath).Run(); err != nil {
+	if err := g.run(g.command("git-crypt", "unlock", keyPath)); err != nil {
 		return fmt.Errorf("git-crypt unlock failed: %s", err)
 	}
 	return nil
 }
 
+// VerifyCommit verifies the GPG signature of a commit against the given (armored) public
+// keys, which are imported into a temporary keyring.
+func (g *GitClient) VerifyCommit(sha string, keys []string) error {
+	home, err := ioutil.TempDir("", "")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory")
+	}
+	defer os.RemoveAll(home)
+
+	for _, key := range keys {
+		cmd := g.command("gpg", "--batch", "--import")
+		cmd.Env = append(cmd.Env, "GNUPGHOME="+home)
+		cmd.Stdin = strings.NewReader(key)
+		if err := g.run(cmd); err != nil {
+			return fmt.Errorf("failed to import gpg key: %s", err)
+		}
+	}
+
+	var out bytes.Buffer
+	cmd := g.command("git", "verify-commit", sha)
+	cmd.Env = append(cmd.Env, "GNUPGHOME="+home)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := g.run(cmd); err != nil {
+		reason := strings.TrimSpace(out.String())
+		if reason == "" {
+			reason = "the commit is not signed"
+		}
+		return fmt.Errorf("signature of commit '%s' is not valid: %s: %s", sha, err, reason)
+	}
+	return nil
+}
+
+// AddReference borrows objects from a local repository (e.g. a mirror mounted on the
+// worker), like git clone --reference, so that only missing objects are fetched.
+func (g *GitClient) AddReference(path string) error {
+	objects := filepath.Join(path, "objects")
+	if _, err := os.Stat(filepath.Join(path, ".git", "objects")); err == nil {
+		objects = filepath.Join(path, ".git", "objects")
+	}
+	if info, err := os.Stat(objects); err != nil || !info.IsDir() {
+		return fmt.Errorf("reference repository '%s' does not exist", path)
+	}
+	objects, err := filepath.Abs(objects)
+	if err != nil {
+		return err
+	}
+	alternates := filepath.Join(g.Directory, ".git", "objects", "info", "alternates")
+	if err := os.MkdirAll(filepath.Dir(alternates), 0755); err != nil {
+		return fmt.Errorf("failed to add reference repository: %s", err)
+	}
+	if err := ioutil.WriteFile(alternates, []byte(filepath.ToSlash(objects)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to add reference repository: %s", err)
+	}
+	return nil
+}
+
+// Dissociate from the reference repository (if any) by copying the borrowed objects,
+// like git clone --dissociate, so that the repository can be used on other workers.
+func (g *GitClient) Dissociate() error {
+	alternates := filepath.Join(g.Directory, ".git", "objects", "info", "alternates")
+	if _, err := os.Stat(alternates); os.IsNotExist(err) {
+		return nil
+	}
+	if err := g.run(g.command("git", "repack", "-a", "-d", "-q")); err != nil {
+		return fmt.Errorf("repack failed: %s", err)
+	}
+	if err := os.Remove(alternates); err != nil {
+		return fmt.Errorf("failed to remove reference repository: %s", err)
+	}
+	return nil
+}
+
+// Bundle all refs (and HEAD) of the repository into a file, from which the repository
+// can be recreated with git clone.
+func (g *GitClient) Bundle(path string) error {
+	if err := g.run(g.command("git", "bundle", "create", path, "HEAD", "--all")); err != nil {
+		return fmt.Errorf("bundle failed: %s", err)
+	}
+	return nil
+}
+
+// Askpass answers the prompt for the access token and returns true if the resource was
+// run by Git as its askpass program (see askpassEnv), instead of by Concourse.
+func Askpass() bool {
+	if os.Getenv(askpassVariable) == "" {
+		return false
+	}
+	fmt.Println(os.Getenv("X_OAUTH_BASIC_TOKEN"))
+	return true
+}
+
+// askpassVariable is set when Git runs the resource itself as its askpass program.
+const askpassVariable = "GITHUB_PR_RESOURCE_ASKPASS"
+
 // Endpoint takes an uri and produces an endpoint with the login information baked in.
 func (g *GitClient) Endpoint(uri string) (string, error) {
 	endpoint, err := url.Parse(uri)
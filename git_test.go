@@ -0,0 +1,208 @@
+package resource_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resource "github.com/telia-oss/github-pr-resource"
+)
+
+func TestGitRedactsAccessTokens(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	var output bytes.Buffer
+	source := &resource.Source{AccessToken: "oauthtoken", ForkAccessToken: "forktoken", GitTrace: true}
+	git, err := resource.NewGitClient(source, dir, &output)
+	require.NoError(t, err)
+	require.NoError(t, git.Init("master"))
+
+	// Nothing listens on the port, so fetching fails after the URLs have been traced.
+	uri := "http://127.0.0.1:1/itsdalmo/test-repository.git"
+	var errs []string
+	if err := git.Pull(uri, "master", 0, false, false); assert.Error(t, err) {
+		errs = append(errs, err.Error())
+	}
+	if err := git.Fetch(uri, 1, 0, false); assert.Error(t, err) {
+		errs = append(errs, err.Error())
+	}
+	if err := git.FetchFork(uri, "pr1", 0, false); assert.Error(t, err) {
+		errs = append(errs, err.Error())
+	}
+
+	assert.Contains(t, output.String(), "<redacted>")
+	for _, out := range append(errs, output.String()) {
+		for _, secret := range []string{
+			"oauthtoken",
+			"forktoken",
+			base64.StdEncoding.EncodeToString([]byte("x-oauth-basic:oauthtoken")),
+			base64.StdEncoding.EncodeToString([]byte("x-oauth-basic:forktoken")),
+		} {
+			assert.NotContains(t, out, secret)
+		}
+	}
+}
+
+func TestGitArgs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tests := []struct {
+		description string
+		source      resource.Source
+		wantErr     string
+		wantOutput  string
+	}{
+		{
+			description: "git_args are passed to every git command",
+			source:      resource.Source{GitArgs: []string{"--no-such-option"}},
+			wantErr:     "init failed: exit status 129",
+			wantOutput:  "unknown option: --no-such-option",
+		},
+		{
+			description: "git_trace writes the trace of git commands to the output",
+			source:      resource.Source{GitTrace: true},
+			wantOutput:  "trace: built-in: git init",
+		},
+		{
+			description: "git commands are not traced by default",
+			source:      resource.Source{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			var output bytes.Buffer
+			git, err := resource.NewGitClient(&tc.source, dir, &output)
+			require.NoError(t, err)
+
+			err = git.Init("master")
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tc.wantOutput != "" {
+				assert.Contains(t, output.String(), tc.wantOutput)
+			} else {
+				assert.NotContains(t, output.String(), "trace:")
+			}
+		})
+	}
+}
+
+func TestGitErrors(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	git, err := resource.NewGitClient(&resource.Source{AccessToken: "oauthtoken"}, dir, ioutil.Discard)
+	require.NoError(t, err)
+	require.NoError(t, git.Init("master"))
+
+	// The error of the failed command is wrapped.
+	err = git.Pull("http://127.0.0.1:1/itsdalmo/test-repository.git", "master", 0, false, false)
+	var exitErr *exec.ExitError
+	if assert.True(t, errors.As(err, &exitErr), "unexpected error: %v", err) {
+		assert.Equal(t, "pull failed: "+exitErr.Error(), err.Error())
+	}
+
+	// rev-parse runs with the git_args, like every other git command.
+	git, err = resource.NewGitClient(&resource.Source{GitArgs: []string{"--no-such-option"}}, dir, ioutil.Discard)
+	require.NoError(t, err)
+	_, err = git.RevParse("HEAD")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "exit status 129")
+		assert.Contains(t, err.Error(), "unknown option: --no-such-option")
+	}
+}
+
+func TestGitConcurrentFetch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	origin := createTestDirectory(t)
+	defer os.RemoveAll(origin)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"checkout", "-q", "-b", "master"},
+		{"-c", "user.name=test", "-c", "user.email=test@local", "commit", "-q", "--allow-empty", "-m", "base"},
+		{"-c", "user.name=test", "-c", "user.email=test@local", "commit", "-q", "--allow-empty", "-m", "pr"},
+		{"update-ref", "refs/pull/1/head", "HEAD"},
+		{"reset", "-q", "--hard", "HEAD~1"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", origin}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+	git, err := resource.NewGitClient(&resource.Source{AccessToken: "oauthtoken"}, dir, ioutil.Discard)
+	require.NoError(t, err)
+	require.NoError(t, git.Init("master"))
+
+	// The pull request is fetched while the base is pulled, like in get.
+	uri := "file://localhost/" + strings.TrimPrefix(filepath.ToSlash(origin), "/")
+	fetched := make(chan error, 1)
+	go func() { fetched <- git.Fetch(uri, 1, 0, false) }()
+	require.NoError(t, git.Pull(uri, "master", 0, false, false))
+	require.NoError(t, <-fetched)
+
+	// Both are fetched into refs of their own, and neither writes FETCH_HEAD.
+	base, err := git.RevParse("master")
+	require.NoError(t, err)
+	remote, err := git.RevParse("refs/remotes/origin/master")
+	require.NoError(t, err)
+	assert.Equal(t, remote, base)
+	_, err = git.RevParse("refs/remotes/origin/pull/1")
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, ".git", "FETCH_HEAD"))
+	assert.True(t, os.IsNotExist(err), "FETCH_HEAD was written")
+}
+
+func TestGitTimeoutKillsHelpers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake git is a shell script")
+	}
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	// The fake git starts a helper which keeps the output open, like git-remote-https.
+	bin := filepath.Join(dir, "bin")
+	require.NoError(t, os.MkdirAll(bin, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bin, "git"), []byte("#!/bin/sh\nsleep 20 &\nsleep 20\n"), 0755))
+
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+path)
+
+	source := &resource.Source{AccessToken: "oauthtoken", GitTimeout: resource.Duration(time.Second)}
+	git, err := resource.NewGitClient(source, dir, ioutil.Discard)
+	require.NoError(t, err)
+
+	started := time.Now()
+	err = git.Fetch("https://github.com/itsdalmo/test-repository.git", 1, 0, false)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "timed out after 1s")
+	}
+	assert.True(t, time.Since(started) < 5*time.Second, "fetch returned after %s", time.Since(started))
+}
@@ -0,0 +1,25 @@
+// +build !windows
+
+package resource
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// askpassEnv for Git, which runs the askpass script installed in the image to ask
+// for the access token.
+func askpassEnv() []string {
+	return []string{"GIT_ASKPASS=/usr/local/bin/askpass.sh"}
+}
+
+// setProcessGroup starts the command in its own process group, so that the helpers it
+// runs (e.g. git-remote-https) can be killed together with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the command and the helpers it runs.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
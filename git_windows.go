@@ -0,0 +1,25 @@
+package resource
+
+import (
+	"os"
+	"os/exec"
+)
+
+// askpassEnv for Git, which runs the resource itself to ask for the access token,
+// since Windows workers cannot run the askpass script.
+func askpassEnv() []string {
+	exe, err := os.Executable()
+	if err != nil {
+		logger.Warn("failed to locate the resource executable for askpass", "error", err)
+		return nil
+	}
+	return []string{"GIT_ASKPASS=" + exe, askpassVariable + "=true"}
+}
+
+// setProcessGroup does nothing on Windows, where process groups are not used.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the command, since Windows has no process groups to kill.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -5,12 +5,16 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v28/github"
 	"github.com/shurcooL/githubv4"
@@ -20,21 +24,75 @@ import (
 // Github for testing purposes.
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fakes/fake_github.go . Github
 type Github interface {
-	ListPullRequests([]githubv4.PullRequestState) ([]*PullRequest, error)
-	ListModifiedFiles(int) ([]string, error)
+	ListPullRequests([]githubv4.PullRequestState, bool) ([]*PullRequest, error)
+	ListPullRequestPages([]githubv4.PullRequestState, bool, string, int) ([]*PullRequest, string, error)
+	ListModifiedFiles(int, func([]ChangedFileObject) bool) ([]ChangedFileObject, error)
 	PostComment(string, string) error
 	GetPullRequest(string, string) (*PullRequest, error)
 	GetChangedFiles(string, string) ([]ChangedFileObject, error)
 	UpdateCommitStatus(string, string, string, string, string, string) error
-	DeletePreviousComments(string) error
+	UpdateComment(string, string) error
+	DeletePreviousComments(string, string, time.Time) error
+	MinimizePreviousComments(string, string, string) error
+	ListOwnComments(string) ([]CommentObject, error)
+	AddReaction(string, string, string) error
+	DismissStaleReviews(string, string, string) error
+	CreateOrUpdateIssue(string, string, []string) error
+	CreateGist(string, map[string]string) (string, error)
+	RerequestCheckSuites(string) error
+	ListTeamMembers(string) ([]string, error)
+	ListUserTeams(string) ([]string, error)
+	ListMergeQueueEntries(string) ([]*MergeQueueEntry, error)
+	GetFileContents(string, string) ([]byte, error)
+	DownloadArchive(string, io.Writer) error
+	GetSignatureVerification(string) (*SignatureVerification, error)
+	SetLocked(string, bool, string) error
+	AddLabels(string, []string) error
+	RemoveLabel(string, string) error
+	ListPullRequestCommits(string) ([]PullRequestCommit, error)
+	ClosePullRequest(string) error
+	GetRateLimit() (*RateLimit, error)
 }
 
 // GithubClient for handling requests to the Github V3 and V4 APIs.
 type GithubClient struct {
-	V3         *github.Client
-	V4         *githubv4.Client
-	Repository string
-	Owner      string
+	V3               *github.Client
+	V4               *githubv4.Client
+	Repository       string
+	Owner            string
+	Timeout          time.Duration
+	SearchQueryExtra string
+	PageSize         int
+	MaxPRs           int
+	StateLookback    time.Duration
+	IncludeComments  bool
+	Number           int
+
+	// StrictStatuses fails status updates when the current status of the context cannot be
+	// read, rather than setting it anyway, so that a retried put never sets a status twice.
+	StrictStatuses bool
+
+	// IncludeLabelEvents and IncludeReviewEvents list when the labels and approvals of pull
+	// requests last changed, which are updates if they are part of the version key.
+	IncludeLabelEvents  bool
+	IncludeReviewEvents bool
+
+	// IncludeReviewers lists who approved pull requests, IncludeForcePushes whether their tip
+	// was force pushed and IncludeReadyEvents when they were last marked as ready for review.
+	IncludeReviewers   bool
+	IncludeForcePushes bool
+	IncludeReadyEvents bool
+
+	// IncludeChangedLines fetches the number of changed lines of pull requests listed with the
+	// V3 API, which are not part of listed pull requests.
+	IncludeChangedLines bool
+
+	// ContextNamespace identifies the comments posted by releases which did not yet mark
+	// comments with the job that posted them (see legacyComment).
+	ContextNamespace string
+
+	budget *budgetTransport
+	cache  *PullRequestCache
 }
 
 // NewGithubClient ...
@@ -45,41 +103,51 @@ func NewGithubClient(s *Source) (*GithubClient, error) {
 	}
 
 	// Skip SSL verification for self-signed certificates
-	// source: https://github.com/google/go-github/pull/598#issuecomment-333039238
-	var ctx context.Context
+	var base http.RoundTripper
 	if s.SkipSSLVerification {
-		insecureClient := &http.Client{Transport: &http.Transport{
+		base = &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
 		}
-		ctx = context.WithValue(context.TODO(), oauth2.HTTPClient, insecureClient)
-	} else {
-		ctx = context.TODO()
 	}
 
-	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: s.AccessToken},
-	))
+	// Requests are authenticated with the access token, and fall back to the tokens in
+	// access_tokens when it is rate limited or rejected.
+	tokens := &tokenRotation{tokens: append([]string{s.AccessToken}, s.AccessTokens...)}
+	client := &http.Client{Transport: &oauth2.Transport{Base: base, Source: tokens}}
+	if len(s.AccessTokens) > 0 {
+		client.Transport = &rotatingTransport{base: client.Transport, tokens: tokens}
+	}
+	budget := &budgetTransport{base: client.Transport}
+	client.Transport = &loggingTransport{base: budget, dumpBodies: s.Debug}
+	if s.CacheDir != "" {
+		client.Transport = NewCacheTransport(client.Transport, filepath.Join(s.CacheDir, "http"), s.AccessToken)
+	}
+
+	// Preview media types are only sent to the V3 API.
+	v3Client := client
+	if len(s.V3AcceptHeaders) > 0 {
+		v3Client = &http.Client{Transport: &acceptTransport{base: client.Transport, accept: s.V3AcceptHeaders}}
+	}
 
 	var v3 *github.Client
 	if s.V3Endpoint != "" {
 		endpoint, err := url.Parse(s.V3Endpoint)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse v3 endpoint: %s", err)
+			return nil, fmt.Errorf("failed to parse v3 endpoint: %w", err)
 		}
-		v3, err = github.NewEnterpriseClient(endpoint.String(), endpoint.String(), client)
+		v3, err = github.NewEnterpriseClient(endpoint.String(), endpoint.String(), v3Client)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		v3 = github.NewClient(client)
+		v3 = github.NewClient(v3Client)
 	}
 
 	var v4 *githubv4.Client
 	if s.V4Endpoint != "" {
 		endpoint, err := url.Parse(s.V4Endpoint)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse v4 endpoint: %s", err)
+			return nil, fmt.Errorf("failed to parse v4 endpoint: %w", err)
 		}
 		v4 = githubv4.NewEnterpriseClient(endpoint.String(), client)
 		if err != nil {
@@ -89,112 +157,575 @@ func NewGithubClient(s *Source) (*GithubClient, error) {
 		v4 = githubv4.NewClient(client)
 	}
 
+	var cache *PullRequestCache
+	if s.QueryCacheTTL > 0 {
+		dir := s.QueryCacheDir
+		if dir == "" {
+			dir = DefaultQueryCacheDir
+		}
+		cache = NewPullRequestCache(dir, s.Repository+"\x00"+s.AccessToken, time.Duration(s.QueryCacheTTL))
+	}
+
 	return &GithubClient{
-		V3:         v3,
-		V4:         v4,
-		Owner:      owner,
-		Repository: repository,
+		V3:               v3,
+		V4:               v4,
+		Owner:            owner,
+		Repository:       repository,
+		Timeout:          time.Duration(s.APITimeout),
+		SearchQueryExtra: s.SearchQueryExtra,
+		PageSize:         s.PageSize,
+		MaxPRs:           s.MaxPRs,
+		StateLookback:    time.Duration(s.StateLookback),
+		IncludeComments:  s.TriggerPhrase != "",
+		Number:           s.Number,
+
+		IncludeLabelEvents:  inVersionKey(*s, "labels"),
+		IncludeReviewEvents: inVersionKey(*s, "approvals"),
+
+		IncludeReviewers:   len(s.RequiredReviewApprovals.Teams) > 0 || s.ExcludeAuthorTeamApprovals || inVersionKey(*s, "approvals"),
+		IncludeForcePushes: s.DetectForcePushes,
+		IncludeReadyEvents: s.TriggerOnReady,
+
+		IncludeChangedLines: s.MinChangedLines > 0 || s.MaxChangedLines > 0,
+		ContextNamespace:    s.ContextNamespace,
+		budget:              budget,
+		cache:               cache,
 	}, nil
 }
 
-// ListPullRequests gets the last commit on all pull requests with the matching state.
-func (m *GithubClient) ListPullRequests(prStates []githubv4.PullRequestState) ([]*PullRequest, error) {
+// SetAPIBudget bounds the number of requests made to the API from now on (0 means no bound).
+// Requests beyond the budget fail with ErrAPIBudgetExhausted.
+func (m *GithubClient) SetAPIBudget(calls int) {
+	m.budget.mu.Lock()
+	defer m.budget.mu.Unlock()
+	m.budget.budget, m.budget.calls = calls, 0
+}
+
+// context for a single request to the API, which is cancelled after the
+// configured timeout (if any).
+func (m *GithubClient) context() (context.Context, context.CancelFunc) {
+	if m.Timeout > 0 {
+		return context.WithTimeout(context.Background(), m.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// pullRequestNode is the part of the GraphQL query that is shared between
+// listing and searching for pull requests.
+type pullRequestNode struct {
+	PullRequestObject
+	Reviews struct {
+		TotalCount int
+	} `graphql:"reviews(states:$prReviewStates)"`
+	Reviewers struct {
+		Nodes []struct {
+			Author struct {
+				Login string
+			}
+			SubmittedAt githubv4.DateTime
+		}
+	} `graphql:"reviewers: reviews(first:$reviewsFirst,states:$prReviewStates) @include(if:$includeReviewers)"`
+	Commits struct {
+		Edges []struct {
+			Node struct {
+				Commit CommitObject
+			}
+		}
+	} `graphql:"commits(last:$commitsLast)"`
+	Labels struct {
+		Edges []struct {
+			Node struct {
+				LabelObject
+			}
+		}
+	} `graphql:"labels(first:$labelsFirst)"`
+	Files struct {
+		TotalCount int
+		Nodes      []ChangedFileObject
+	} `graphql:"files(first:$filesFirst) @include(if:$includeFiles)"`
+	Comments struct {
+		Nodes []CommentObject
+	} `graphql:"comments(last:$commentsLast) @include(if:$includeComments)"`
+	ForcePushes struct {
+		Nodes []struct {
+			HeadRefForcePushedEvent struct {
+				AfterCommit struct {
+					OID string
+				}
+			} `graphql:"... on HeadRefForcePushedEvent"`
+		}
+	} `graphql:"forcePushes: timelineItems(last:1,itemTypes:[HEAD_REF_FORCE_PUSHED_EVENT]) @include(if:$includeForcePushes)"`
+	Reopens struct {
+		Nodes []struct {
+			ReopenedEvent struct {
+				CreatedAt githubv4.DateTime
+			} `graphql:"... on ReopenedEvent"`
+		}
+	} `graphql:"reopens: timelineItems(last:1,itemTypes:[REOPENED_EVENT])"`
+	ReadyForReview struct {
+		Nodes []struct {
+			ReadyForReviewEvent struct {
+				CreatedAt githubv4.DateTime
+			} `graphql:"... on ReadyForReviewEvent"`
+		}
+	} `graphql:"readyForReview: timelineItems(last:1,itemTypes:[READY_FOR_REVIEW_EVENT]) @include(if:$includeReadyEvents)"`
+	LabelEvents struct {
+		Nodes []struct {
+			LabeledEvent struct {
+				CreatedAt githubv4.DateTime
+			} `graphql:"... on LabeledEvent"`
+			UnlabeledEvent struct {
+				CreatedAt githubv4.DateTime
+			} `graphql:"... on UnlabeledEvent"`
+		}
+	} `graphql:"labelEvents: timelineItems(last:1,itemTypes:[LABELED_EVENT,UNLABELED_EVENT]) @include(if:$includeLabelEvents)"`
+	ReviewDismissals struct {
+		Nodes []struct {
+			ReviewDismissedEvent struct {
+				CreatedAt githubv4.DateTime
+			} `graphql:"... on ReviewDismissedEvent"`
+		}
+	} `graphql:"reviewDismissals: timelineItems(last:1,itemTypes:[REVIEW_DISMISSED_EVENT]) @include(if:$includeReviewEvents)"`
+}
+
+// pullRequestVars returns the variables used by pullRequestNode.
+func (m *GithubClient) pullRequestVars(includeFiles bool) map[string]interface{} {
+	pageSize := m.PageSize
+	if pageSize == 0 {
+		pageSize = 100
+	}
+	vars := map[string]interface{}{
+		"prFirst":         githubv4.Int(pageSize),
+		"prCursor":        (*githubv4.String)(nil),
+		"commitsLast":     githubv4.Int(1),
+		"prReviewStates":  []githubv4.PullRequestReviewState{githubv4.PullRequestReviewStateApproved},
+		"reviewsFirst":    githubv4.Int(100),
+		"labelsFirst":     githubv4.Int(100),
+		"filesFirst":      githubv4.Int(100),
+		"includeFiles":    githubv4.Boolean(includeFiles),
+		"commentsLast":    githubv4.Int(100),
+		"includeComments": githubv4.Boolean(m.IncludeComments),
+
+		"includeLabelEvents":  githubv4.Boolean(m.IncludeLabelEvents),
+		"includeReviewEvents": githubv4.Boolean(m.IncludeReviewEvents),
+		"includeReviewers":    githubv4.Boolean(m.IncludeReviewers),
+		"includeForcePushes":  githubv4.Boolean(m.IncludeForcePushes),
+		"includeReadyEvents":  githubv4.Boolean(m.IncludeReadyEvents),
+	}
+	return vars
+}
+
+// queryCost is the rate limit information returned together with a query.
+type queryCost struct {
+	Cost      int
+	Remaining int
+	NodeCount int
+}
+
+func (c queryCost) log() {
+	logger.Debug("query cost", "cost", c.Cost, "remaining", c.Remaining, "nodes", c.NodeCount)
+	if c.Cost > 0 && c.Remaining < c.Cost {
+		logger.Warn("graphql rate limit nearly exhausted", "cost", c.Cost, "remaining", c.Remaining)
+	}
+}
+
+// queryPullRequests runs the query for a page of pull requests. The page size is halved when
+// the query exceeds the node limit of the API or times out, so that oversized queries are
+// split into smaller pages instead of failing.
+func (m *GithubClient) queryPullRequests(query interface{}, vars map[string]interface{}) error {
+	for {
+		ctx, cancel := m.context()
+		err := m.V4.Query(ctx, query, vars)
+		cancel()
+		first := vars["prFirst"].(githubv4.Int)
+		if err == nil || first <= 1 || !queryTooLarge(err) {
+			return err
+		}
+		vars["prFirst"] = first / 2
+		logger.Warn("query too large, reducing the page size", "page_size", first/2, "error", err)
+	}
+}
+
+// queryTooLarge returns true if a query failed because it requested too many nodes,
+// or took too long to execute.
+func queryTooLarge(err error) bool {
+	msg := err.Error()
+	for _, s := range []string{
+		"MAX_NODE_LIMIT_EXCEEDED",
+		"exceeds the maximum limit",
+		"may be the result of a timeout",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *pullRequestNode) pullRequests(includeFiles bool) []*PullRequest {
+	labels := make([]LabelObject, 0, len(n.Labels.Edges))
+	for _, l := range n.Labels.Edges {
+		labels = append(labels, l.Node.LabelObject)
+	}
+
+	var forcePushed string
+	for _, e := range n.ForcePushes.Nodes {
+		forcePushed = e.HeadRefForcePushedEvent.AfterCommit.OID
+	}
+
+	var reopenedAt githubv4.DateTime
+	for _, e := range n.Reopens.Nodes {
+		reopenedAt = e.ReopenedEvent.CreatedAt
+	}
+
+	var approvedBy []string
+	var approvedAt githubv4.DateTime
+	for _, r := range n.Reviewers.Nodes {
+		approvedBy = append(approvedBy, r.Author.Login)
+		if r.SubmittedAt.After(approvedAt.Time) {
+			approvedAt = r.SubmittedAt
+		}
+	}
+	for _, e := range n.ReviewDismissals.Nodes {
+		if e.ReviewDismissedEvent.CreatedAt.After(approvedAt.Time) {
+			approvedAt = e.ReviewDismissedEvent.CreatedAt
+		}
+	}
+
+	var labeledAt githubv4.DateTime
+	for _, e := range n.LabelEvents.Nodes {
+		labeledAt = e.LabeledEvent.CreatedAt
+		if e.UnlabeledEvent.CreatedAt.After(labeledAt.Time) {
+			labeledAt = e.UnlabeledEvent.CreatedAt
+		}
+	}
+
+	var readyAt githubv4.DateTime
+	for _, e := range n.ReadyForReview.Nodes {
+		readyAt = e.ReadyForReviewEvent.CreatedAt
+	}
+
+	var response []*PullRequest
+	for _, c := range n.Commits.Edges {
+		response = append(response, &PullRequest{
+			PullRequestObject:   n.PullRequestObject,
+			Tip:                 c.Node.Commit,
+			ApprovedReviewCount: n.Reviews.TotalCount,
+			ApprovedBy:          approvedBy,
+			Labels:              labels,
+			Files:               n.Files.Nodes,
+			FilesComplete:       includeFiles && len(n.Files.Nodes) >= n.Files.TotalCount,
+			ForcePushed:         forcePushed != "" && forcePushed == c.Node.Commit.OID,
+			ReopenedAt:          reopenedAt,
+			ReadyAt:             readyAt,
+			LabeledAt:           labeledAt,
+			ApprovedAt:          approvedAt,
+			Comments:            n.Comments.Nodes,
+		})
+	}
+	return response
+}
+
+// ListPullRequests gets the last commit on all pull requests with the matching state,
+// and optionally the first page of modified files. Only the pull request with the
+// configured number is listed if the source is pinned to one.
+func (m *GithubClient) ListPullRequests(prStates []githubv4.PullRequestState, includeFiles bool) (listed []*PullRequest, err error) {
+	defer func() {
+		if err == nil {
+			m.cachePullRequests(listed)
+		}
+	}()
+	if m.Number > 0 {
+		return m.listPullRequest(m.Number, prStates, includeFiles)
+	}
+	var open, closed []githubv4.PullRequestState
+	for _, s := range prStates {
+		if s == githubv4.PullRequestStateOpen {
+			open = append(open, s)
+		} else {
+			closed = append(closed, s)
+		}
+	}
+	if m.StateLookback == 0 || len(closed) == 0 {
+		if m.SearchQueryExtra != "" {
+			return m.searchPullRequests(prStates, includeFiles)
+		}
+		return m.listPullRequests(prStates, includeFiles)
+	}
+
+	// Only look for merged/closed pull requests which were updated recently,
+	// instead of going through the entire history of the repository.
+	var response []*PullRequest
+	if len(open) > 0 {
+		var err error
+		if m.SearchQueryExtra != "" {
+			response, err = m.searchPullRequests(open, includeFiles)
+		} else {
+			response, err = m.listPullRequests(open, includeFiles)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	since := time.Now().Add(-m.StateLookback).UTC().Format("2006-01-02T15:04:05Z")
+	recent, err := m.searchPullRequests(closed, includeFiles, "updated:>="+since)
+	if err != nil {
+		return nil, err
+	}
+	response = append(response, recent...)
+
+	// Both lists are bounded by max_prs, so keep the most recently updated of the two.
+	if m.MaxPRs > 0 && len(response) > m.MaxPRs {
+		sort.SliceStable(response, func(i, j int) bool {
+			return response[i].UpdatedAt.After(response[j].UpdatedAt.Time)
+		})
+		logger.Warn("pull requests truncated by max_prs", "max_prs", m.MaxPRs)
+		response = response[:m.MaxPRs]
+	}
+	return response, nil
+}
+
+// listPullRequest lists a single pull request (if it has a matching state), the same way as
+// the pull requests listed by listPullRequests.
+func (m *GithubClient) listPullRequest(number int, prStates []githubv4.PullRequestState, includeFiles bool) ([]*PullRequest, error) {
+	var query struct {
+		Repository struct {
+			PullRequest pullRequestNode `graphql:"pullRequest(number:$prNumber)"`
+		} `graphql:"repository(owner:$repositoryOwner,name:$repositoryName)"`
+		RateLimit queryCost
+	}
+
+	// The variables for paginating the pull requests are not used by the query.
+	vars := m.pullRequestVars(includeFiles)
+	delete(vars, "prFirst")
+	delete(vars, "prCursor")
+	vars["repositoryOwner"] = githubv4.String(m.Owner)
+	vars["repositoryName"] = githubv4.String(m.Repository)
+	vars["prNumber"] = githubv4.Int(number)
+
+	ctx, cancel := m.context()
+	defer cancel()
+	err := m.V4.Query(ctx, &query, vars)
+	if v4Unavailable(err) {
+		logger.Warn("falling back to the V3 API", "error", err)
+		pulls, err := m.listPullRequestsV3(prStates)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pulls {
+			if p.Number == number {
+				return []*PullRequest{p}, nil
+			}
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	query.RateLimit.log()
+	if !containsState(prStates, query.Repository.PullRequest.State) {
+		return nil, nil
+	}
+	return query.Repository.PullRequest.pullRequests(includeFiles), nil
+}
+
+// ListPullRequestPages lists at most the given number of pages of pull requests with the
+// matching state, starting after the cursor (from the first page if it is empty), and
+// returns the cursor to continue from, which is empty when the last page was listed.
+func (m *GithubClient) ListPullRequestPages(prStates []githubv4.PullRequestState, includeFiles bool, cursor string, pages int) ([]*PullRequest, string, error) {
+	response, cursor, err := m.listPullRequestPages(prStates, includeFiles, cursor, pages)
+	if err == nil {
+		m.cachePullRequests(response)
+	}
+	return response, cursor, err
+}
+
+// cachePullRequests listed with their head commit (if query_cache_ttl is set), for get.
+func (m *GithubClient) cachePullRequests(pulls []*PullRequest) {
+	for _, p := range pulls {
+		m.cache.Put(p)
+	}
+}
+
+func (m *GithubClient) listPullRequests(prStates []githubv4.PullRequestState, includeFiles bool) ([]*PullRequest, error) {
+	response, _, err := m.listPullRequestPages(prStates, includeFiles, "", 0)
+	return response, err
+}
+
+func (m *GithubClient) listPullRequestPages(prStates []githubv4.PullRequestState, includeFiles bool, cursor string, pages int) ([]*PullRequest, string, error) {
 	var query struct {
 		Repository struct {
 			PullRequests struct {
 				Edges []struct {
-					Node struct {
-						PullRequestObject
-						Reviews struct {
-							TotalCount int
-						} `graphql:"reviews(states: $prReviewStates)"`
-						Commits struct {
-							Edges []struct {
-								Node struct {
-									Commit CommitObject
-								}
-							}
-						} `graphql:"commits(last:$commitsLast)"`
-						Labels struct {
-							Edges []struct {
-								Node struct {
-									LabelObject
-								}
-							}
-						} `graphql:"labels(first:$labelsFirst)"`
-					}
+					Node pullRequestNode
 				}
 				PageInfo struct {
 					EndCursor   githubv4.String
 					HasNextPage bool
 				}
-			} `graphql:"pullRequests(first:$prFirst,states:$prStates,after:$prCursor)"`
+			} `graphql:"pullRequests(first:$prFirst,states:$prStates,after:$prCursor,orderBy:$prOrder)"`
 		} `graphql:"repository(owner:$repositoryOwner,name:$repositoryName)"`
+		RateLimit queryCost
 	}
 
-	vars := map[string]interface{}{
-		"repositoryOwner": githubv4.String(m.Owner),
-		"repositoryName":  githubv4.String(m.Repository),
-		"prFirst":         githubv4.Int(100),
-		"prStates":        prStates,
-		"prCursor":        (*githubv4.String)(nil),
-		"commitsLast":     githubv4.Int(1),
-		"prReviewStates":  []githubv4.PullRequestReviewState{githubv4.PullRequestReviewStateApproved},
-		"labelsFirst":     githubv4.Int(100),
+	vars := m.pullRequestVars(includeFiles)
+	vars["repositoryOwner"] = githubv4.String(m.Owner)
+	vars["repositoryName"] = githubv4.String(m.Repository)
+	vars["prStates"] = prStates
+	vars["prOrder"] = githubv4.IssueOrder{Field: githubv4.IssueOrderFieldCreatedAt, Direction: githubv4.OrderDirectionAsc}
+	if m.MaxPRs > 0 {
+		// Make sure the most recently updated pull requests are kept.
+		vars["prOrder"] = githubv4.IssueOrder{Field: githubv4.IssueOrderFieldUpdatedAt, Direction: githubv4.OrderDirectionDesc}
+	}
+	if cursor != "" {
+		vars["prCursor"] = githubv4.String(cursor)
 	}
 
 	var response []*PullRequest
-	for {
-		if err := m.V4.Query(context.TODO(), &query, vars); err != nil {
-			return nil, err
+	for page := 1; ; page++ {
+		err := m.queryPullRequests(&query, vars)
+		if v4Unavailable(err) {
+			logger.Warn("falling back to the V3 API", "error", err)
+			response, err := m.listPullRequestsV3(prStates)
+			return response, "", err
+		}
+		if err != nil {
+			return nil, "", err
 		}
+		query.RateLimit.log()
 		for _, p := range query.Repository.PullRequests.Edges {
-			labels := make([]LabelObject, len(p.Node.Labels.Edges))
-			for _, l := range p.Node.Labels.Edges {
-				labels = append(labels, l.Node.LabelObject)
-			}
-
-			for _, c := range p.Node.Commits.Edges {
-				response = append(response, &PullRequest{
-					PullRequestObject:   p.Node.PullRequestObject,
-					Tip:                 c.Node.Commit,
-					ApprovedReviewCount: p.Node.Reviews.TotalCount,
-					Labels:              labels,
-				})
+			response = append(response, p.Node.pullRequests(includeFiles)...)
+		}
+		if m.MaxPRs > 0 && len(response) >= m.MaxPRs {
+			if len(response) > m.MaxPRs || query.Repository.PullRequests.PageInfo.HasNextPage {
+				logger.Warn("pull requests truncated by max_prs", "max_prs", m.MaxPRs)
 			}
+			return response[:m.MaxPRs], "", nil
 		}
 		if !query.Repository.PullRequests.PageInfo.HasNextPage {
 			break
 		}
+		if pages > 0 && page >= pages {
+			return response, string(query.Repository.PullRequests.PageInfo.EndCursor), nil
+		}
 		vars["prCursor"] = query.Repository.PullRequests.PageInfo.EndCursor
 	}
+	return response, "", nil
+}
+
+// searchPullRequests lists pull requests using the search API, which allows the
+// query to be extended with search_query_extra. Search results are limited to 1000.
+func (m *GithubClient) searchPullRequests(prStates []githubv4.PullRequestState, includeFiles bool, qualifiers ...string) ([]*PullRequest, error) {
+	var query struct {
+		Search struct {
+			Nodes []struct {
+				PullRequest pullRequestNode `graphql:"... on PullRequest"`
+			}
+			PageInfo struct {
+				EndCursor   githubv4.String
+				HasNextPage bool
+			}
+		} `graphql:"search(query:$searchQuery,type:ISSUE,first:$prFirst,after:$prCursor)"`
+		RateLimit queryCost
+	}
+
+	q := []string{fmt.Sprintf("repo:%s/%s", m.Owner, m.Repository), "is:pr"}
+	if len(prStates) == 1 {
+		switch prStates[0] {
+		case githubv4.PullRequestStateOpen:
+			q = append(q, "is:open")
+		case githubv4.PullRequestStateMerged:
+			q = append(q, "is:merged")
+		case githubv4.PullRequestStateClosed:
+			q = append(q, "is:closed", "is:unmerged")
+		}
+	} else if !containsState(prStates, githubv4.PullRequestStateOpen) {
+		// Merged pull requests are also closed.
+		q = append(q, "is:closed")
+	}
+	q = append(q, qualifiers...)
+	if m.MaxPRs > 0 {
+		// Make sure the most recently updated pull requests are kept.
+		q = append(q, "sort:updated-desc")
+	}
+	if m.SearchQueryExtra != "" {
+		q = append(q, m.SearchQueryExtra)
+	}
+
+	vars := m.pullRequestVars(includeFiles)
+	vars["searchQuery"] = githubv4.String(strings.Join(q, " "))
+
+	var response []*PullRequest
+	for {
+		err := m.queryPullRequests(&query, vars)
+		if v4Unavailable(err) {
+			logger.Warn("falling back to the V3 API", "error", err)
+			if m.SearchQueryExtra == "" {
+				// Only used for the state lookback, which the V3 list applies itself.
+				return m.listPullRequestsV3(prStates)
+			}
+			return m.searchPullRequestsV3(prStates, q)
+		}
+		if err != nil {
+			return nil, err
+		}
+		query.RateLimit.log()
+		for _, n := range query.Search.Nodes {
+			if !containsState(prStates, n.PullRequest.State) {
+				continue
+			}
+			response = append(response, n.PullRequest.pullRequests(includeFiles)...)
+		}
+		if m.MaxPRs > 0 && len(response) >= m.MaxPRs {
+			if len(response) > m.MaxPRs || query.Search.PageInfo.HasNextPage {
+				logger.Warn("pull requests truncated by max_prs", "max_prs", m.MaxPRs)
+			}
+			return response[:m.MaxPRs], nil
+		}
+		if !query.Search.PageInfo.HasNextPage {
+			break
+		}
+		vars["prCursor"] = query.Search.PageInfo.EndCursor
+	}
 	return response, nil
 }
 
-// ListModifiedFiles in a pull request (not supported by V4 API).
-func (m *GithubClient) ListModifiedFiles(prNumber int) ([]string, error) {
-	var files []string
+// ListModifiedFiles in a pull request (not supported by V4 API). If stop is not nil,
+// it is called with the files listed so far after each page, and no more pages are
+// fetched once it returns true.
+func (m *GithubClient) ListModifiedFiles(prNumber int, stop func([]ChangedFileObject) bool) ([]ChangedFileObject, error) {
+	var files []ChangedFileObject
 
 	opt := &github.ListOptions{
 		PerPage: 100,
 	}
 	for {
+		ctx, cancel := m.context()
 		result, response, err := m.V3.PullRequests.ListFiles(
-			context.TODO(),
+			ctx,
 			m.Owner,
 			m.Repository,
 			prNumber,
 			opt,
 		)
+		cancel()
 		if err != nil {
 			return nil, err
 		}
 		for _, f := range result {
-			files = append(files, *f.Filename)
+			files = append(files, ChangedFileObject{
+				Path:       f.GetFilename(),
+				ChangeType: v3ChangeTypes[f.GetStatus()],
+				Additions:  f.GetAdditions(),
+				Deletions:  f.GetDeletions(),
+			})
 		}
 		if response.NextPage == 0 {
 			break
 		}
+		if stop != nil && stop(files) {
+			break
+		}
 		opt.Page = response.NextPage
 	}
 	return files, nil
@@ -204,11 +735,14 @@ func (m *GithubClient) ListModifiedFiles(prNumber int) ([]string, error) {
 func (m *GithubClient) PostComment(prNumber, comment string) error {
 	pr, err := strconv.Atoi(prNumber)
 	if err != nil {
-		return fmt.Errorf("failed to convert pull request number to int: %s", err)
+		return fmt.Errorf("failed to convert pull request number to int: %w", err)
 	}
 
+	ctx, cancel := m.context()
+	defer cancel()
+
 	_, _, err = m.V3.Issues.CreateComment(
-		context.TODO(),
+		ctx,
 		m.Owner,
 		m.Repository,
 		pr,
@@ -219,11 +753,27 @@ func (m *GithubClient) PostComment(prNumber, comment string) error {
 	return err
 }
 
+// UpdateComment replaces the body of a comment, given by its database ID.
+func (m *GithubClient) UpdateComment(commentID, comment string) error {
+	id, err := strconv.ParseInt(commentID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to convert comment id to int: %w", err)
+	}
+
+	ctx, cancel := m.context()
+	defer cancel()
+
+	_, _, err = m.V3.Issues.EditComment(ctx, m.Owner, m.Repository, id, &github.IssueComment{
+		Body: github.String(comment),
+	})
+	return err
+}
+
 // GetChangedFiles ...
 func (m *GithubClient) GetChangedFiles(prNumber string, commitRef string) ([]ChangedFileObject, error) {
 	pr, err := strconv.Atoi(prNumber)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert pull request number to int: %s", err)
+		return nil, fmt.Errorf("failed to convert pull request number to int: %w", err)
 	}
 
 	var cfo []ChangedFileObject
@@ -257,12 +807,19 @@ func (m *GithubClient) GetChangedFiles(prNumber string, commitRef string) ([]Cha
 			"changedFilesEndCursor": githubv4.String(offset),
 		}
 
-		if err := m.V4.Query(context.TODO(), &filequery, vars); err != nil {
+		ctx, cancel := m.context()
+		err := m.V4.Query(ctx, &filequery, vars)
+		cancel()
+		if v4Unavailable(err) {
+			logger.Warn("falling back to the V3 API", "error", err)
+			return m.getChangedFilesV3(pr)
+		}
+		if err != nil {
 			return nil, err
 		}
 
 		for _, f := range filequery.Repository.PullRequest.Files.Edges {
-			cfo = append(cfo, ChangedFileObject{Path: f.Node.Path})
+			cfo = append(cfo, f.Node.ChangedFileObject)
 		}
 
 		if !filequery.Repository.PullRequest.Files.PageInfo.HasNextPage {
@@ -279,21 +836,36 @@ func (m *GithubClient) GetChangedFiles(prNumber string, commitRef string) ([]Cha
 func (m *GithubClient) GetPullRequest(prNumber, commitRef string) (*PullRequest, error) {
 	pr, err := strconv.Atoi(prNumber)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert pull request number to int: %s", err)
+		return nil, fmt.Errorf("failed to convert pull request number to int: %w", err)
+	}
+	if p, ok := m.cache.Get(pr, commitRef); ok {
+		logger.Debug("using cached pull request", "pr", pr, "commit", commitRef)
+		return p, nil
 	}
 
+	// Look up the commit directly instead of going through the commits of the
+	// pull request, which can be long and no longer contain force pushed commits.
 	var query struct {
 		Repository struct {
 			PullRequest struct {
 				PullRequestObject
-				Commits struct {
-					Edges []struct {
-						Node struct {
-							Commit CommitObject
-						}
-					}
-				} `graphql:"commits(last:$commitsLast)"`
+				HeadRefOID       string `graphql:"headRefOid"`
+				MergeStateStatus string
+				ReviewDecision   string
+				Labels           struct {
+					Nodes []LabelObject
+				} `graphql:"labels(first:$labelsFirst)"`
 			} `graphql:"pullRequest(number:$prNumber)"`
+			Object struct {
+				Commit struct {
+					CommitObject
+					AssociatedPullRequests struct {
+						Nodes []struct {
+							Number int
+						}
+					} `graphql:"associatedPullRequests(first:$associatedFirst)"`
+				} `graphql:"... on Commit"`
+			} `graphql:"object(oid:$commitOID)"`
 		} `graphql:"repository(owner:$repositoryOwner,name:$repositoryName)"`
 	}
 
@@ -301,32 +873,52 @@ func (m *GithubClient) GetPullRequest(prNumber, commitRef string) (*PullRequest,
 		"repositoryOwner": githubv4.String(m.Owner),
 		"repositoryName":  githubv4.String(m.Repository),
 		"prNumber":        githubv4.Int(pr),
-		"commitsLast":     githubv4.Int(100),
+		"commitOID":       githubv4.GitObjectID(commitRef),
+		"associatedFirst": githubv4.Int(100),
+		"labelsFirst":     githubv4.Int(100),
 	}
 
-	// TODO: Pagination - in case someone pushes > 100 commits before the build has time to start :p
-	if err := m.V4.Query(context.TODO(), &query, vars); err != nil {
+	ctx, cancel := m.context()
+	defer cancel()
+
+	err = m.V4.Query(ctx, &query, vars)
+	if v4Unavailable(err) {
+		logger.Warn("falling back to the V3 API", "error", err)
+		return m.getPullRequestV3(pr, commitRef)
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	for _, c := range query.Repository.PullRequest.Commits.Edges {
-		if c.Node.Commit.OID == commitRef {
-			// Return as soon as we find the correct ref.
-			return &PullRequest{
-				PullRequestObject: query.Repository.PullRequest.PullRequestObject,
-				Tip:               c.Node.Commit,
-			}, nil
+	commit := query.Repository.Object.Commit
+	if commit.OID == "" {
+		return nil, fmt.Errorf("commit with ref '%s' does not exist", commitRef)
+	}
+	associated := commit.OID == query.Repository.PullRequest.HeadRefOID
+	for _, p := range commit.AssociatedPullRequests.Nodes {
+		if p.Number == pr {
+			associated = true
 		}
 	}
-
-	// Return an error if the commit was not found
-	return nil, fmt.Errorf("commit with ref '%s' does not exist", commitRef)
+	if !associated {
+		// Commits of squash or rebase merged and closed pull requests, and commits which have
+		// been force pushed away, are no longer associated with the pull request, but the
+		// versions which refer to them can still be fetched.
+		logger.Warn("commit is not associated with the pull request", "pr", pr, "commit", commit.OID, "state", query.Repository.PullRequest.State)
+	}
+	return &PullRequest{
+		PullRequestObject: query.Repository.PullRequest.PullRequestObject,
+		Tip:               commit.CommitObject,
+		Labels:            query.Repository.PullRequest.Labels.Nodes,
+		MergeStateStatus:  query.Repository.PullRequest.MergeStateStatus,
+		ReviewDecision:    query.Repository.PullRequest.ReviewDecision,
+	}, nil
 }
 
 // UpdateCommitStatus for a given commit (not supported by V4 API).
 func (m *GithubClient) UpdateCommitStatus(commitRef, baseContext, statusContext, status, targetURL, description string) error {
 	if baseContext == "" {
-		baseContext = "concourse-ci"
+		baseContext = DefaultBaseContext
 	}
 
 	if statusContext == "" {
@@ -341,69 +933,820 @@ func (m *GithubClient) UpdateCommitStatus(commitRef, baseContext, statusContext,
 		description = fmt.Sprintf("Concourse CI build %s", status)
 	}
 
-	_, _, err := m.V3.Repositories.CreateStatus(
-		context.TODO(),
+	repoStatus := &github.RepoStatus{
+		State:       github.String(strings.ToLower(status)),
+		TargetURL:   github.String(targetURL),
+		Description: github.String(description),
+		Context:     github.String(path.Join(baseContext, statusContext)),
+	}
+
+	// Skip the update (and the notifications it triggers) if nothing has changed.
+	current, err := m.getCommitStatus(commitRef, repoStatus.GetContext())
+	if err != nil {
+		if m.StrictStatuses {
+			return fmt.Errorf("failed to get current status: %w", err)
+		}
+		logger.Debug("failed to get current status", "commit", commitRef, "error", err)
+	} else if current != nil &&
+		current.GetState() == repoStatus.GetState() &&
+		current.GetDescription() == repoStatus.GetDescription() &&
+		current.GetTargetURL() == repoStatus.GetTargetURL() {
+		logger.Info("status is unchanged", "commit", commitRef, "context", repoStatus.GetContext(), "status", repoStatus.GetState())
+		return nil
+	}
+
+	ctx, cancel := m.context()
+	defer cancel()
+
+	_, _, err = m.V3.Repositories.CreateStatus(
+		ctx,
 		m.Owner,
 		m.Repository,
 		commitRef,
-		&github.RepoStatus{
-			State:       github.String(strings.ToLower(status)),
-			TargetURL:   github.String(targetURL),
-			Description: github.String(description),
-			Context:     github.String(path.Join(baseContext, statusContext)),
-		},
+		repoStatus,
 	)
 	return err
 }
 
-func (m *GithubClient) DeletePreviousComments(prNumber string) error {
-	pr, err := strconv.Atoi(prNumber)
-	if err != nil {
-		return fmt.Errorf("failed to convert pull request number to int: %s", err)
+// ListTeamMembers returns the logins of the members of a team, which is given by its
+// slug in the organization of the repository, or as "organization/slug".
+func (m *GithubClient) ListTeamMembers(team string) ([]string, error) {
+	org, slug := m.Owner, team
+	if i := strings.Index(team, "/"); i >= 0 {
+		org, slug = team[:i], team[i+1:]
 	}
 
-	var getComments struct {
-		Viewer struct {
-			Login string
-		}
-		Repository struct {
-			PullRequest struct {
-				Id       string
-				Comments struct {
-					Edges []struct {
-						Node struct {
-							DatabaseId int64
-							Author     struct {
-								Login string
-							}
-						}
-					}
-				} `graphql:"comments(last:$commentsLast)"`
-			} `graphql:"pullRequest(number:$prNumber)"`
-		} `graphql:"repository(owner:$repositoryOwner,name:$repositoryName)"`
+	ctx, cancel := m.context()
+	t, _, err := m.V3.Teams.GetTeamBySlug(ctx, org, slug)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team %s/%s: %w", org, slug, err)
 	}
 
-	vars := map[string]interface{}{
-		"repositoryOwner": githubv4.String(m.Owner),
-		"repositoryName":  githubv4.String(m.Repository),
-		"prNumber":        githubv4.Int(pr),
-		"commentsLast":    githubv4.Int(100),
+	var members []string
+	opt := &github.TeamListTeamMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		ctx, cancel := m.context()
+		result, response, err := m.V3.Teams.ListTeamMembers(ctx, t.GetID(), opt)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list members of team %s/%s: %w", org, slug, err)
+		}
+		for _, u := range result {
+			members = append(members, u.GetLogin())
+		}
+		if response.NextPage == 0 {
+			break
+		}
+		opt.Page = response.NextPage
 	}
+	return members, nil
+}
 
-	if err := m.V4.Query(context.TODO(), &getComments, vars); err != nil {
-		return err
+// ListPullRequestCommits returns the commits of a pull request (at most 250, which is the
+// limit of the API).
+func (m *GithubClient) ListPullRequestCommits(prNumber string) ([]PullRequestCommit, error) {
+	pr, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert pull request number to int: %w", err)
 	}
 
-	for _, e := range getComments.Repository.PullRequest.Comments.Edges {
-		if e.Node.Author.Login == getComments.Viewer.Login {
-			_, err := m.V3.Issues.DeleteComment(context.TODO(), m.Owner, m.Repository, e.Node.DatabaseId)
-			if err != nil {
-				return err
-			}
+	var commits []PullRequestCommit
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		ctx, cancel := m.context()
+		result, response, err := m.V3.PullRequests.ListCommits(ctx, m.Owner, m.Repository, pr, opt)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range result {
+			commits = append(commits, PullRequestCommit{
+				OID:           c.GetSHA(),
+				Message:       c.GetCommit().GetMessage(),
+				AuthorName:    c.GetCommit().GetAuthor().GetName(),
+				AuthorEmail:   c.GetCommit().GetAuthor().GetEmail(),
+				Parents:       len(c.Parents),
+				CommittedDate: c.GetCommit().GetCommitter().GetDate(),
+			})
+		}
+		if response.NextPage == 0 {
+			break
 		}
+		opt.Page = response.NextPage
 	}
+	return commits, nil
+}
 
-	return nil
+// ListUserTeams returns the slugs of the teams in the organization of the repository
+// which the user is a member of.
+func (m *GithubClient) ListUserTeams(login string) ([]string, error) {
+	var query struct {
+		Organization struct {
+			Teams struct {
+				Nodes []struct {
+					Slug string
+				}
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+			} `graphql:"teams(first:100,after:$teamsCursor,userLogins:$userLogins)"`
+		} `graphql:"organization(login:$organization)"`
+	}
+
+	vars := map[string]interface{}{
+		"organization": githubv4.String(m.Owner),
+		"userLogins":   []githubv4.String{githubv4.String(login)},
+		"teamsCursor":  (*githubv4.String)(nil),
+	}
+
+	var teams []string
+	for {
+		ctx, cancel := m.context()
+		err := m.V4.Query(ctx, &query, vars)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list teams of %s: %w", login, err)
+		}
+		for _, t := range query.Organization.Teams.Nodes {
+			teams = append(teams, t.Slug)
+		}
+		if !query.Organization.Teams.PageInfo.HasNextPage {
+			break
+		}
+		vars["teamsCursor"] = githubv4.NewString(query.Organization.Teams.PageInfo.EndCursor)
+	}
+	return teams, nil
+}
+
+// ListMergeQueueEntries returns the pull requests in the merge queue of a branch which
+// have a merge group, or nothing if the branch does not use a merge queue.
+func (m *GithubClient) ListMergeQueueEntries(branch string) ([]*MergeQueueEntry, error) {
+	var query struct {
+		Repository struct {
+			MergeQueue *struct {
+				Entries struct {
+					Nodes    []MergeQueueEntry
+					PageInfo struct {
+						EndCursor   githubv4.String
+						HasNextPage bool
+					}
+				} `graphql:"entries(first:100,after:$entriesCursor)"`
+			} `graphql:"mergeQueue(branch:$branch)"`
+		} `graphql:"repository(owner:$repositoryOwner,name:$repositoryName)"`
+	}
+
+	vars := map[string]interface{}{
+		"repositoryOwner": githubv4.String(m.Owner),
+		"repositoryName":  githubv4.String(m.Repository),
+		"branch":          githubv4.String(branch),
+		"entriesCursor":   (*githubv4.String)(nil),
+	}
+
+	var entries []*MergeQueueEntry
+	for {
+		ctx, cancel := m.context()
+		err := m.V4.Query(ctx, &query, vars)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list merge queue of %s: %w", branch, err)
+		}
+		queue := query.Repository.MergeQueue
+		if queue == nil {
+			logger.Debug("branch does not use a merge queue", "branch", branch)
+			return nil, nil
+		}
+		for i := range queue.Entries.Nodes {
+			// Entries only have a merge group once they are being tested.
+			if e := queue.Entries.Nodes[i]; e.HeadCommit.OID != "" {
+				entries = append(entries, &e)
+			}
+		}
+		if !queue.Entries.PageInfo.HasNextPage {
+			break
+		}
+		vars["entriesCursor"] = githubv4.NewString(queue.Entries.PageInfo.EndCursor)
+	}
+	return entries, nil
+}
+
+// GetFileContents returns the contents of a file in the repository at the given ref
+// (e.g. a branch), or nil if the file does not exist.
+func (m *GithubClient) GetFileContents(path, ref string) ([]byte, error) {
+	ctx, cancel := m.context()
+	defer cancel()
+
+	file, _, response, err := m.V3.Repositories.GetContents(ctx, m.Owner, m.Repository, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+// DownloadArchive writes the tarball of a commit to w. The tarball honors export-ignore
+// in .gitattributes, and contains a single top-level directory.
+func (m *GithubClient) DownloadArchive(commitRef string, w io.Writer) error {
+	ctx, cancel := m.context()
+	defer cancel()
+
+	link, _, err := m.V3.Repositories.GetArchiveLink(ctx, m.Owner, m.Repository, github.Tarball, &github.RepositoryContentGetOptions{Ref: commitRef})
+	if err != nil {
+		return fmt.Errorf("failed to get archive link: %w", err)
+	}
+	req, err := m.V3.NewRequest(http.MethodGet, link.String(), nil)
+	if err != nil {
+		return err
+	}
+	if _, err := m.V3.Do(ctx, req, w); err != nil {
+		return fmt.Errorf("failed to download archive: %w", err)
+	}
+	return nil
+}
+
+// GetSignatureVerification returns whether Github verified the signature of a commit.
+func (m *GithubClient) GetSignatureVerification(commitRef string) (*SignatureVerification, error) {
+	ctx, cancel := m.context()
+	defer cancel()
+
+	c, _, err := m.V3.Repositories.GetCommit(ctx, m.Owner, m.Repository, commitRef)
+	if err != nil {
+		return nil, err
+	}
+	verification := c.GetCommit().GetVerification()
+	return &SignatureVerification{Verified: verification.GetVerified(), Reason: verification.GetReason()}, nil
+}
+
+// RerequestCheckSuites of a commit, which makes Github run them again. Check suites can
+// only be re-requested by the Github App which created them, so the rest are skipped.
+func (m *GithubClient) RerequestCheckSuites(commitRef string) error {
+	opt := &github.ListCheckSuiteOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	var suites []*github.CheckSuite
+	for {
+		ctx, cancel := m.context()
+		result, response, err := m.V3.Checks.ListCheckSuitesForRef(ctx, m.Owner, m.Repository, commitRef, opt)
+		cancel()
+		if err != nil {
+			return err
+		}
+		suites = append(suites, result.CheckSuites...)
+		if response.NextPage == 0 {
+			break
+		}
+		opt.Page = response.NextPage
+	}
+
+	for _, s := range suites {
+		ctx, cancel := m.context()
+		response, err := m.V3.Checks.ReRequestCheckSuite(ctx, m.Owner, m.Repository, s.GetID())
+		cancel()
+		if err != nil {
+			if response != nil && (response.StatusCode == http.StatusForbidden || response.StatusCode == http.StatusUnprocessableEntity) {
+				logger.Warn("cannot re-request check suite", "app", s.GetApp().GetName(), "error", err)
+				continue
+			}
+			return err
+		}
+		logger.Info("re-requested check suite", "app", s.GetApp().GetName(), "commit", commitRef)
+	}
+	return nil
+}
+
+// getCommitStatus returns the latest status of a commit for the context, or nil if there is none.
+func (m *GithubClient) getCommitStatus(commitRef, statusContext string) (*github.RepoStatus, error) {
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		ctx, cancel := m.context()
+		combined, response, err := m.V3.Repositories.GetCombinedStatus(ctx, m.Owner, m.Repository, commitRef, opt)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range combined.Statuses {
+			if s.GetContext() == statusContext {
+				return &s, nil
+			}
+		}
+		if response.NextPage == 0 {
+			return nil, nil
+		}
+		opt.Page = response.NextPage
+	}
+}
+
+// previousComment is a comment returned by previousComments.
+type previousComment struct {
+	ID          string
+	DatabaseId  int64
+	IsMinimized bool
+}
+
+// legacyComment returns true for comments posted by releases which did not mark comments with
+// the job that posted them. Those releases deleted all comments of the user of the access token,
+// or only those with the marker of the context namespace (if set). They are still deleted by
+// delete_previous_comments until the next major release, so that upgrading leaves none behind.
+func legacyComment(body, namespace string) bool {
+	if namespace != "" {
+		return strings.Contains(body, fmt.Sprintf("<!-- github-pr-resource: %s -->", namespace))
+	}
+	return !strings.Contains(body, "<!-- github-pr-resource")
+}
+
+// previousComments lists the comments made by the user of the access token which contain the
+// marker (or are legacy comments, if set), and were created before the given time (if not zero).
+// All comments of the pull request are paginated.
+func (m *GithubClient) previousComments(prNumber, marker string, legacy bool, before time.Time) ([]previousComment, error) {
+	pr, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert pull request number to int: %w", err)
+	}
+
+	var getComments struct {
+		Viewer struct {
+			Login string
+		}
+		Repository struct {
+			PullRequest struct {
+				Id       string
+				Comments struct {
+					Edges []struct {
+						Node struct {
+							previousComment
+							Body      string
+							CreatedAt githubv4.DateTime
+							Author    struct {
+								Login string
+							}
+						}
+					}
+					PageInfo struct {
+						EndCursor   githubv4.String
+						HasNextPage bool
+					}
+				} `graphql:"comments(first:$commentsFirst,after:$commentsCursor)"`
+			} `graphql:"pullRequest(number:$prNumber)"`
+		} `graphql:"repository(owner:$repositoryOwner,name:$repositoryName)"`
+	}
+
+	vars := map[string]interface{}{
+		"repositoryOwner": githubv4.String(m.Owner),
+		"repositoryName":  githubv4.String(m.Repository),
+		"prNumber":        githubv4.Int(pr),
+		"commentsFirst":   githubv4.Int(100),
+		"commentsCursor":  (*githubv4.String)(nil),
+	}
+
+	var comments []previousComment
+	for {
+		ctx, cancel := m.context()
+		err = m.V4.Query(ctx, &getComments, vars)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range getComments.Repository.PullRequest.Comments.Edges {
+			if e.Node.Author.Login != getComments.Viewer.Login {
+				continue
+			}
+			if !strings.Contains(e.Node.Body, marker) && !(legacy && legacyComment(e.Node.Body, m.ContextNamespace)) {
+				continue
+			}
+			if !before.IsZero() && !e.Node.CreatedAt.Before(before) {
+				continue
+			}
+			comments = append(comments, e.Node.previousComment)
+		}
+		if !getComments.Repository.PullRequest.Comments.PageInfo.HasNextPage {
+			return comments, nil
+		}
+		vars["commentsCursor"] = githubv4.NewString(getComments.Repository.PullRequest.Comments.PageInfo.EndCursor)
+	}
+}
+
+// inBatches calls f for all comments, running up to commentsBatchSize calls concurrently,
+// and returns the first error of a batch.
+func inBatches(comments []previousComment, f func(previousComment) error) error {
+	for len(comments) > 0 {
+		batch := comments
+		if len(batch) > commentsBatchSize {
+			batch = batch[:commentsBatchSize]
+		}
+		comments = comments[len(batch):]
+
+		errs := make(chan error, len(batch))
+		for _, c := range batch {
+			go func(c previousComment) {
+				errs <- f(c)
+			}(c)
+		}
+		var err error
+		for range batch {
+			if e := <-errs; e != nil && err == nil {
+				err = e
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commentsBatchSize is the number of comments which are deleted or minimized concurrently.
+const commentsBatchSize = 10
+
+// DeletePreviousComments made by the user of the access token which contain the marker (or were
+// posted by earlier releases, see legacyComment), and were created before the given time (if not zero).
+func (m *GithubClient) DeletePreviousComments(prNumber, marker string, before time.Time) error {
+	comments, err := m.previousComments(prNumber, marker, true, before)
+	if err != nil {
+		return err
+	}
+	logger.Debug("deleting previous comments", "pr", prNumber, "comments", len(comments))
+	return inBatches(comments, func(c previousComment) error {
+		ctx, cancel := m.context()
+		defer cancel()
+		response, err := m.V3.Issues.DeleteComment(ctx, m.Owner, m.Repository, c.DatabaseId)
+		if err != nil && response != nil && response.StatusCode == http.StatusNotFound {
+			// Deleted by an earlier attempt (or concurrently by another build).
+			logger.Debug("comment is already deleted", "pr", prNumber, "comment_id", c.DatabaseId)
+			return nil
+		}
+		return err
+	})
+}
+
+// MinimizePreviousComments made by the user of the access token which contain the marker, so
+// that they are collapsed (but kept) in the conversation. Classifier is the reason shown on the
+// minimized comments (e.g. OUTDATED or RESOLVED). Comments which are already minimized are skipped.
+func (m *GithubClient) MinimizePreviousComments(prNumber, marker, classifier string) error {
+	comments, err := m.previousComments(prNumber, marker, false, time.Time{})
+	if err != nil {
+		return err
+	}
+	var minimize []previousComment
+	for _, c := range comments {
+		if !c.IsMinimized {
+			minimize = append(minimize, c)
+		}
+	}
+	logger.Debug("minimizing previous comments", "pr", prNumber, "comments", len(minimize))
+	return inBatches(minimize, func(c previousComment) error {
+		var mutation struct {
+			MinimizeComment struct {
+				ClientMutationID string
+			} `graphql:"minimizeComment(input: $input)"`
+		}
+		input := githubv4.MinimizeCommentInput{
+			SubjectID:  githubv4.ID(c.ID),
+			Classifier: githubv4.ReportedContentClassifiers(strings.ToUpper(classifier)),
+		}
+		ctx, cancel := m.context()
+		defer cancel()
+		return m.V4.Mutate(ctx, &mutation, input, nil)
+	})
+}
+
+// ListOwnComments returns the comments made on the pull request by the user of the
+// access token, oldest first. All comments of the pull request are paginated.
+func (m *GithubClient) ListOwnComments(prNumber string) ([]CommentObject, error) {
+	pr, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert pull request number to int: %w", err)
+	}
+
+	var query struct {
+		Viewer struct {
+			Login string
+		}
+		Repository struct {
+			PullRequest struct {
+				Comments struct {
+					Nodes    []CommentObject
+					PageInfo struct {
+						EndCursor   githubv4.String
+						HasNextPage bool
+					}
+				} `graphql:"comments(first:$commentsFirst,after:$commentsCursor)"`
+			} `graphql:"pullRequest(number:$prNumber)"`
+		} `graphql:"repository(owner:$repositoryOwner,name:$repositoryName)"`
+	}
+
+	vars := map[string]interface{}{
+		"repositoryOwner": githubv4.String(m.Owner),
+		"repositoryName":  githubv4.String(m.Repository),
+		"prNumber":        githubv4.Int(pr),
+		"commentsFirst":   githubv4.Int(100),
+		"commentsCursor":  (*githubv4.String)(nil),
+	}
+
+	var comments []CommentObject
+	for {
+		ctx, cancel := m.context()
+		err = m.V4.Query(ctx, &query, vars)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range query.Repository.PullRequest.Comments.Nodes {
+			if c.Author.Login == query.Viewer.Login {
+				comments = append(comments, c)
+			}
+		}
+		if !query.Repository.PullRequest.Comments.PageInfo.HasNextPage {
+			return comments, nil
+		}
+		vars["commentsCursor"] = githubv4.NewString(query.Repository.PullRequest.Comments.PageInfo.EndCursor)
+	}
+}
+
+// AddReaction to a pull request, or to one of its comments if a comment ID is given (not supported by V4 API).
+func (m *GithubClient) AddReaction(prNumber, commentID, reaction string) error {
+	pr, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to convert pull request number to int: %w", err)
+	}
+
+	u := fmt.Sprintf("repos/%s/%s/issues/%d/reactions", m.Owner, m.Repository, pr)
+	if commentID != "" {
+		id, err := strconv.ParseInt(commentID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to convert comment id to int: %w", err)
+		}
+		u = fmt.Sprintf("repos/%s/%s/issues/comments/%d/reactions", m.Owner, m.Repository, id)
+	}
+
+	req, err := m.V3.NewRequest("POST", u, &github.Reaction{Content: github.String(reaction)})
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github.squirrel-girl-preview+json")
+
+	ctx, cancel := m.context()
+	defer cancel()
+
+	_, err = m.V3.Do(ctx, req, nil)
+	return err
+}
+
+// DismissStaleReviews dismisses approving reviews that were not made on the given commit.
+func (m *GithubClient) DismissStaleReviews(prNumber, commitRef, message string) error {
+	pr, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to convert pull request number to int: %w", err)
+	}
+
+	var stale []*github.PullRequestReview
+
+	opt := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		ctx, cancel := m.context()
+		result, response, err := m.V3.PullRequests.ListReviews(
+			ctx,
+			m.Owner,
+			m.Repository,
+			pr,
+			opt,
+		)
+		cancel()
+		if err != nil {
+			return err
+		}
+		for _, r := range result {
+			if r.GetState() == "APPROVED" && r.GetCommitID() != commitRef {
+				stale = append(stale, r)
+			}
+		}
+		if response.NextPage == 0 {
+			break
+		}
+		opt.Page = response.NextPage
+	}
+
+	for _, r := range stale {
+		ctx, cancel := m.context()
+		_, _, err := m.V3.PullRequests.DismissReview(
+			ctx,
+			m.Owner,
+			m.Repository,
+			pr,
+			r.GetID(),
+			&github.PullRequestReviewDismissalRequest{
+				Message: github.String(message),
+			},
+		)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateOrUpdateIssue opens an issue with the given title, or comments on it if
+// it has already been opened by the authenticated user.
+func (m *GithubClient) CreateOrUpdateIssue(title, body string, labels []string) error {
+	ctx, cancel := m.context()
+	viewer, _, err := m.V3.Users.Get(ctx, "")
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	opt := &github.IssueListByRepoOptions{
+		State:   "open",
+		Creator: viewer.GetLogin(),
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+	for {
+		ctx, cancel := m.context()
+		result, response, err := m.V3.Issues.ListByRepo(
+			ctx,
+			m.Owner,
+			m.Repository,
+			opt,
+		)
+		cancel()
+		if err != nil {
+			return err
+		}
+		for _, i := range result {
+			if i.IsPullRequest() || i.GetTitle() != title {
+				continue
+			}
+			ctx, cancel := m.context()
+			defer cancel()
+
+			_, _, err := m.V3.Issues.CreateComment(
+				ctx,
+				m.Owner,
+				m.Repository,
+				i.GetNumber(),
+				&github.IssueComment{
+					Body: github.String(body),
+				},
+			)
+			return err
+		}
+		if response.NextPage == 0 {
+			break
+		}
+		opt.Page = response.NextPage
+	}
+
+	ctx, cancel = m.context()
+	defer cancel()
+
+	_, _, err = m.V3.Issues.Create(
+		ctx,
+		m.Owner,
+		m.Repository,
+		&github.IssueRequest{
+			Title:  github.String(title),
+			Body:   github.String(body),
+			Labels: &labels,
+		},
+	)
+	return err
+}
+
+// CreateGist with the given description and files (name to content), which is
+// secret, and returns its URL.
+func (m *GithubClient) CreateGist(description string, files map[string]string) (string, error) {
+	gist := &github.Gist{
+		Description: github.String(description),
+		Public:      github.Bool(false),
+		Files:       make(map[github.GistFilename]github.GistFile),
+	}
+	for name, content := range files {
+		gist.Files[github.GistFilename(name)] = github.GistFile{Content: github.String(content)}
+	}
+
+	ctx, cancel := m.context()
+	defer cancel()
+
+	result, _, err := m.V3.Gists.Create(ctx, gist)
+	if err != nil {
+		return "", err
+	}
+	return result.GetHTMLURL(), nil
+}
+
+// SetLocked locks or unlocks the conversation on a pull request (not supported by V4 API).
+func (m *GithubClient) SetLocked(prNumber string, locked bool, reason string) error {
+	pr, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to convert pull request number to int: %w", err)
+	}
+
+	ctx, cancel := m.context()
+	defer cancel()
+
+	if !locked {
+		_, err = m.V3.Issues.Unlock(ctx, m.Owner, m.Repository, pr)
+		return err
+	}
+
+	var opt *github.LockIssueOptions
+	if reason != "" {
+		opt = &github.LockIssueOptions{LockReason: reason}
+	}
+	_, err = m.V3.Issues.Lock(ctx, m.Owner, m.Repository, pr, opt)
+	return err
+}
+
+// AddLabels to a pull request (not supported by V4 API without the IDs of the labels).
+func (m *GithubClient) AddLabels(prNumber string, labels []string) error {
+	pr, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to convert pull request number to int: %w", err)
+	}
+
+	ctx, cancel := m.context()
+	defer cancel()
+
+	_, _, err = m.V3.Issues.AddLabelsToIssue(ctx, m.Owner, m.Repository, pr, labels)
+	return err
+}
+
+// RemoveLabel from a pull request. Labels which the pull request does not have are skipped.
+func (m *GithubClient) RemoveLabel(prNumber string, label string) error {
+	pr, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to convert pull request number to int: %w", err)
+	}
+
+	ctx, cancel := m.context()
+	defer cancel()
+
+	response, err := m.V3.Issues.RemoveLabelForIssue(ctx, m.Owner, m.Repository, pr, label)
+	if err != nil && response != nil && response.StatusCode == http.StatusNotFound {
+		// Removed by an earlier attempt (or concurrently by someone else).
+		logger.Debug("label is already removed", "pr", prNumber, "label", label)
+		return nil
+	}
+	return err
+}
+
+// ClosePullRequest without merging it.
+func (m *GithubClient) ClosePullRequest(prNumber string) error {
+	pr, err := strconv.Atoi(prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to convert pull request number to int: %w", err)
+	}
+
+	ctx, cancel := m.context()
+	defer cancel()
+
+	_, _, err = m.V3.PullRequests.Edit(ctx, m.Owner, m.Repository, pr, &github.PullRequest{State: github.String("closed")})
+	return err
+}
+
+// GetRateLimit returns the current GraphQL rate limit for the access token.
+func (m *GithubClient) GetRateLimit() (*RateLimit, error) {
+	var query struct {
+		RateLimit RateLimit
+	}
+	ctx, cancel := m.context()
+	defer cancel()
+
+	if err := m.V4.Query(ctx, &query, nil); err != nil {
+		return nil, err
+	}
+	return &query.RateLimit, nil
+}
+
+// reportRateLimit logs the remaining rate limit, warning when it is about to
+// be exhausted. Failing to get the rate limit is not considered an error.
+func reportRateLimit(manager Github) *RateLimit {
+	rl, err := manager.GetRateLimit()
+	if err != nil {
+		logger.Warn("failed to get rate limit", "error", err)
+		return nil
+	}
+	if rl == nil {
+		return nil
+	}
+	if rl.Remaining < rl.Limit/10 {
+		logger.Warn("rate limit is about to be exhausted", "remaining", rl.Remaining, "limit", rl.Limit, "reset_at", rl.ResetAt.Time)
+	} else {
+		logger.Info("rate limit", "remaining", rl.Remaining, "limit", rl.Limit, "reset_at", rl.ResetAt.Time)
+	}
+	return rl
 }
 
 func parseRepository(s string) (string, string, error) {
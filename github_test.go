@@ -0,0 +1,1221 @@
+package resource_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resource "github.com/telia-oss/github-pr-resource"
+)
+
+func TestSearchQueryExtra(t *testing.T) {
+	tests := []struct {
+		description string
+		states      []githubv4.PullRequestState
+		expected    string
+		numbers     []int
+	}{
+		{
+			description: "search is restricted to open pull requests",
+			states:      []githubv4.PullRequestState{githubv4.PullRequestStateOpen},
+			expected:    "repo:itsdalmo/test-repository is:pr is:open -label:hold",
+			numbers:     []int{1},
+		},
+		{
+			description: "search results are filtered by state",
+			states:      []githubv4.PullRequestState{githubv4.PullRequestStateOpen, githubv4.PullRequestStateMerged},
+			expected:    "repo:itsdalmo/test-repository is:pr -label:hold",
+			numbers:     []int{1, 2},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var searchQuery string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Variables map[string]interface{} `json:"variables"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				searchQuery, _ = body.Variables["searchQuery"].(string)
+
+				w.Write([]byte(`{"data": {"search": {"nodes": [
+					{"number": 1, "state": "OPEN", "commits": {"edges": [{"node": {"commit": {"oid": "oid1"}}}]}},
+					{"number": 2, "state": "MERGED", "commits": {"edges": [{"node": {"commit": {"oid": "oid2"}}}]}},
+					{"number": 3, "state": "CLOSED", "commits": {"edges": [{"node": {"commit": {"oid": "oid3"}}}]}}
+				], "pageInfo": {"hasNextPage": false}}}}`))
+			}))
+			defer server.Close()
+
+			github, err := resource.NewGithubClient(&resource.Source{
+				Repository:       "itsdalmo/test-repository",
+				AccessToken:      "oauthtoken",
+				V3Endpoint:       server.URL + "/",
+				V4Endpoint:       server.URL + "/graphql",
+				SearchQueryExtra: "-label:hold",
+			})
+			require.NoError(t, err)
+
+			pulls, err := github.ListPullRequests(tc.states, false)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, searchQuery)
+
+			var numbers []int
+			for _, p := range pulls {
+				numbers = append(numbers, p.Number)
+			}
+			assert.Equal(t, tc.numbers, numbers)
+		})
+	}
+}
+
+func TestPageSizeAndMaxPRs(t *testing.T) {
+	tests := []struct {
+		description string
+		pageSize    int
+		maxPRs      int
+		hasNextPage bool
+		expectFirst float64
+		expectOrder map[string]interface{}
+		numbers     []int
+	}{
+		{
+			description: "lists all pull requests by default",
+			expectFirst: 100,
+			expectOrder: map[string]interface{}{"field": "CREATED_AT", "direction": "ASC"},
+			numbers:     []int{1, 2, 3},
+		},
+		{
+			description: "uses the configured page size",
+			pageSize:    10,
+			expectFirst: 10,
+			expectOrder: map[string]interface{}{"field": "CREATED_AT", "direction": "ASC"},
+			numbers:     []int{1, 2, 3},
+		},
+		{
+			description: "stops at max_prs most recently updated pull requests",
+			maxPRs:      2,
+			hasNextPage: true,
+			expectFirst: 100,
+			expectOrder: map[string]interface{}{"field": "UPDATED_AT", "direction": "DESC"},
+			numbers:     []int{1, 2},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var variables map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Variables map[string]interface{} `json:"variables"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				variables = body.Variables
+
+				w.Write([]byte(`{"data": {"repository": {"pullRequests": {"edges": [
+					{"node": {"number": 1, "state": "OPEN", "headRepository": {"isArchived": false}, "commits": {"edges": [{"node": {"commit": {"oid": "oid1"}}}]}}},
+					{"node": {"number": 2, "state": "OPEN", "headRepository": null, "commits": {"edges": [{"node": {"commit": {"oid": "oid2"}}}]}}},
+					{"node": {"number": 3, "state": "OPEN", "commits": {"edges": [{"node": {"commit": {"oid": "oid3"}}}]}}}
+				], "pageInfo": {"hasNextPage": ` + strconv.FormatBool(tc.hasNextPage) + `, "endCursor": "cursor"}}}}}`))
+			}))
+			defer server.Close()
+
+			github, err := resource.NewGithubClient(&resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+				V3Endpoint:  server.URL + "/",
+				V4Endpoint:  server.URL + "/graphql",
+				PageSize:    tc.pageSize,
+				MaxPRs:      tc.maxPRs,
+			})
+			require.NoError(t, err)
+
+			pulls, err := github.ListPullRequests([]githubv4.PullRequestState{githubv4.PullRequestStateOpen}, false)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectFirst, variables["prFirst"])
+			assert.Equal(t, tc.expectOrder, variables["prOrder"])
+
+			var numbers []int
+			for _, p := range pulls {
+				numbers = append(numbers, p.Number)
+			}
+			assert.Equal(t, tc.numbers, numbers)
+
+			// Deleted head repositories are null.
+			assert.NotNil(t, pulls[0].HeadRepository)
+			assert.Nil(t, pulls[1].HeadRepository)
+		})
+	}
+}
+
+func TestListPullRequestPages(t *testing.T) {
+	var cursors []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		cursors = append(cursors, body.Variables["prCursor"])
+		n := len(cursors)
+
+		w.Write([]byte(fmt.Sprintf(`{"data": {"repository": {"pullRequests": {"edges": [
+			{"node": {"number": %d, "state": "OPEN", "commits": {"edges": [{"node": {"commit": {"oid": "oid%d"}}}]}}}
+		], "pageInfo": {"hasNextPage": true, "endCursor": "cursor%d"}}}}}`, n, n, n)))
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		V3Endpoint:  server.URL + "/",
+		V4Endpoint:  server.URL + "/graphql",
+	})
+	require.NoError(t, err)
+
+	pulls, cursor, err := github.ListPullRequestPages([]githubv4.PullRequestState{githubv4.PullRequestStateOpen}, false, "resume", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"resume", "cursor1"}, cursors)
+	assert.Equal(t, "cursor2", cursor)
+	if assert.Len(t, pulls, 2) {
+		assert.Equal(t, 1, pulls[0].Number)
+		assert.Equal(t, 2, pulls[1].Number)
+	}
+}
+
+func TestListPullRequestsNumber(t *testing.T) {
+	tests := []struct {
+		description string
+		state       string
+		numbers     []int
+	}{
+		{
+			description: "lists the pull request with the number",
+			state:       "OPEN",
+			numbers:     []int{123},
+		},
+		{
+			description: "does not list the pull request if its state does not match",
+			state:       "MERGED",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var body struct {
+				Query     string                 `json:"query"`
+				Variables map[string]interface{} `json:"variables"`
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				w.Write([]byte(`{"data": {"repository": {"pullRequest":
+					{"number": 123, "state": "` + tc.state + `", "commits": {"edges": [{"node": {"commit": {"oid": "oid123"}}}]}}
+				}}}`))
+			}))
+			defer server.Close()
+
+			github, err := resource.NewGithubClient(&resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+				V3Endpoint:  server.URL + "/",
+				V4Endpoint:  server.URL + "/graphql",
+				Number:      123,
+			})
+			require.NoError(t, err)
+
+			pulls, err := github.ListPullRequests([]githubv4.PullRequestState{githubv4.PullRequestStateOpen}, false)
+			require.NoError(t, err)
+			assert.Contains(t, body.Query, "pullRequest(number:$prNumber)")
+			assert.Equal(t, float64(123), body.Variables["prNumber"])
+			assert.NotContains(t, body.Variables, "prFirst")
+
+			var numbers []int
+			for _, p := range pulls {
+				numbers = append(numbers, p.Number)
+			}
+			assert.Equal(t, tc.numbers, numbers)
+		})
+	}
+}
+
+func TestListPullRequestsVersionKeyEvents(t *testing.T) {
+	var body struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Write([]byte(`{"data": {"repository": {"pullRequest": {
+			"number": 1, "state": "OPEN", "commits": {"edges": [{"node": {"commit": {"oid": "oid1"}}}]},
+			"reviews": {"totalCount": 1},
+			"reviewers": {"nodes": [{"author": {"login": "reviewer"}, "submittedAt": "2020-01-02T00:00:00Z"}]},
+			"reviewDismissals": {"nodes": [{"createdAt": "2020-01-03T00:00:00Z"}]},
+			"labelEvents": {"nodes": [{"createdAt": "2020-01-04T00:00:00Z"}]}
+		}}}}`))
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		V3Endpoint:  server.URL + "/",
+		V4Endpoint:  server.URL + "/graphql",
+		Number:      1,
+		VersionKey:  []string{"commit", "labels", "approvals"},
+	})
+	require.NoError(t, err)
+
+	pulls, err := github.ListPullRequests([]githubv4.PullRequestState{githubv4.PullRequestStateOpen}, false)
+	require.NoError(t, err)
+	assert.Contains(t, body.Query, "labelEvents: timelineItems(last:1,itemTypes:[LABELED_EVENT,UNLABELED_EVENT]) @include(if:$includeLabelEvents)")
+	assert.Equal(t, true, body.Variables["includeLabelEvents"])
+	assert.Equal(t, true, body.Variables["includeReviewEvents"])
+	assert.Equal(t, true, body.Variables["includeReviewers"])
+	if assert.Len(t, pulls, 1) {
+		assert.Equal(t, 1, pulls[0].ApprovedReviewCount)
+		assert.Equal(t, []string{"reviewer"}, pulls[0].ApprovedBy)
+		assert.Equal(t, time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC), pulls[0].LabeledAt.UTC())
+		assert.Equal(t, time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), pulls[0].ApprovedAt.UTC())
+	}
+}
+
+func TestListPullRequestsOptionalConnections(t *testing.T) {
+	tests := []struct {
+		description string
+		source      resource.Source
+		included    []string
+	}{
+		{
+			description: "optional connections are not queried by default",
+			source:      resource.Source{},
+		},
+		{
+			description: "reviewers are queried for team approvals",
+			source: resource.Source{
+				RequiredReviewApprovals: resource.ReviewApprovals{Teams: map[string]int{"org/team": 1}},
+			},
+			included: []string{"includeReviewers"},
+		},
+		{
+			description: "reviewers are queried to exclude approvals from the team of the author",
+			source:      resource.Source{ExcludeAuthorTeamApprovals: true},
+			included:    []string{"includeReviewers"},
+		},
+		{
+			description: "force pushes are queried if they are detected",
+			source:      resource.Source{DetectForcePushes: true},
+			included:    []string{"includeForcePushes"},
+		},
+		{
+			description: "ready for review events are queried if they trigger",
+			source:      resource.Source{TriggerOnReady: true},
+			included:    []string{"includeReadyEvents"},
+		},
+		{
+			description: "comments are queried for a trigger phrase",
+			source:      resource.Source{TriggerPhrase: "test this"},
+			included:    []string{"includeComments"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var body struct {
+				Query     string                 `json:"query"`
+				Variables map[string]interface{} `json:"variables"`
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				w.Write([]byte(`{"data": {"repository": {"pullRequests": {"edges": [], "pageInfo": {"hasNextPage": false}}}}}`))
+			}))
+			defer server.Close()
+
+			source := tc.source
+			source.Repository = "itsdalmo/test-repository"
+			source.AccessToken = "oauthtoken"
+			source.V3Endpoint = server.URL + "/"
+			source.V4Endpoint = server.URL + "/graphql"
+			github, err := resource.NewGithubClient(&source)
+			require.NoError(t, err)
+
+			_, err = github.ListPullRequests([]githubv4.PullRequestState{githubv4.PullRequestStateOpen}, false)
+			require.NoError(t, err)
+
+			for _, v := range []string{"includeReviewers", "includeForcePushes", "includeReadyEvents", "includeComments"} {
+				assert.Contains(t, body.Query, "@include(if:$"+v+")")
+				included := false
+				for _, i := range tc.included {
+					included = included || i == v
+				}
+				assert.Equal(t, included, body.Variables[v], v)
+			}
+		})
+	}
+}
+
+func TestStateLookback(t *testing.T) {
+	var searchQuery string
+	var listedStates []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		if q, ok := body.Variables["searchQuery"].(string); ok {
+			searchQuery = q
+			w.Write([]byte(`{"data": {"search": {"nodes": [
+				{"number": 2, "state": "MERGED", "commits": {"edges": [{"node": {"commit": {"oid": "oid2"}}}]}},
+				{"number": 3, "state": "CLOSED", "commits": {"edges": [{"node": {"commit": {"oid": "oid3"}}}]}}
+			], "pageInfo": {"hasNextPage": false}}}}`))
+			return
+		}
+		listedStates, _ = body.Variables["prStates"].([]interface{})
+		w.Write([]byte(`{"data": {"repository": {"pullRequests": {"edges": [
+			{"node": {"number": 1, "state": "OPEN", "commits": {"edges": [{"node": {"commit": {"oid": "oid1"}}}]}}}
+		], "pageInfo": {"hasNextPage": false}}}}}`))
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:    "itsdalmo/test-repository",
+		AccessToken:   "oauthtoken",
+		V3Endpoint:    server.URL + "/",
+		V4Endpoint:    server.URL + "/graphql",
+		StateLookback: resource.Duration(24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	pulls, err := github.ListPullRequests([]githubv4.PullRequestState{githubv4.PullRequestStateOpen, githubv4.PullRequestStateMerged}, false)
+	require.NoError(t, err)
+
+	since := time.Now().Add(-24 * time.Hour).UTC().Format("2006-01-02")
+	assert.Equal(t, []interface{}{"OPEN"}, listedStates)
+	assert.Contains(t, searchQuery, "repo:itsdalmo/test-repository is:pr is:merged updated:>="+since)
+
+	var numbers []int
+	for _, p := range pulls {
+		numbers = append(numbers, p.Number)
+	}
+	assert.Equal(t, []int{1, 2}, numbers)
+}
+
+func TestStateLookbackMaxPRs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		if _, ok := body.Variables["searchQuery"]; ok {
+			w.Write([]byte(`{"data": {"search": {"nodes": [
+				{"number": 3, "state": "MERGED", "updatedAt": "2020-01-03T00:00:00Z", "commits": {"edges": [{"node": {"commit": {"oid": "oid3"}}}]}},
+				{"number": 4, "state": "MERGED", "updatedAt": "2020-01-01T00:00:00Z", "commits": {"edges": [{"node": {"commit": {"oid": "oid4"}}}]}}
+			], "pageInfo": {"hasNextPage": false}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"repository": {"pullRequests": {"edges": [
+			{"node": {"number": 1, "state": "OPEN", "updatedAt": "2020-01-04T00:00:00Z", "commits": {"edges": [{"node": {"commit": {"oid": "oid1"}}}]}}},
+			{"node": {"number": 2, "state": "OPEN", "updatedAt": "2020-01-02T00:00:00Z", "commits": {"edges": [{"node": {"commit": {"oid": "oid2"}}}]}}}
+		], "pageInfo": {"hasNextPage": false}}}}}`))
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:    "itsdalmo/test-repository",
+		AccessToken:   "oauthtoken",
+		V3Endpoint:    server.URL + "/",
+		V4Endpoint:    server.URL + "/graphql",
+		StateLookback: resource.Duration(24 * time.Hour),
+		MaxPRs:        2,
+	})
+	require.NoError(t, err)
+
+	pulls, err := github.ListPullRequests([]githubv4.PullRequestState{githubv4.PullRequestStateOpen, githubv4.PullRequestStateMerged}, false)
+	require.NoError(t, err)
+
+	var numbers []int
+	for _, p := range pulls {
+		numbers = append(numbers, p.Number)
+	}
+	assert.Equal(t, []int{1, 3}, numbers)
+}
+
+func TestGetPullRequest(t *testing.T) {
+	tests := []struct {
+		description string
+		state       string
+		head        string
+		object      string
+		wantErr     string
+	}{
+		{
+			description: "returns the commit when it is part of the pull request",
+			object:      `{"oid": "oid1", "associatedPullRequests": {"nodes": [{"number": 2}, {"number": 1}]}}`,
+		},
+		{
+			description: "returns the commit when it is the head of the pull request",
+			head:        "oid1",
+			object:      `{"oid": "oid1", "associatedPullRequests": {"nodes": []}}`,
+		},
+		{
+			description: "returns commits which are no longer associated with the pull request",
+			state:       "MERGED",
+			object:      `{"oid": "oid1", "associatedPullRequests": {"nodes": [{"number": 2}]}}`,
+		},
+		{
+			description: "fails when the commit does not exist",
+			object:      `null`,
+			wantErr:     "commit with ref 'oid1' does not exist",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var commitOID interface{}
+			var query string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Query     string                 `json:"query"`
+					Variables map[string]interface{} `json:"variables"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				commitOID, query = body.Variables["commitOID"], body.Query
+
+				w.Write([]byte(`{"data": {"repository": {"pullRequest": {"number": 1, "state": "` + tc.state + `", "headRefOid": "` + tc.head + `", "labels": {"nodes": [{"name": "size/S"}]}}, "object": ` + tc.object + `}}}`))
+			}))
+			defer server.Close()
+
+			github, err := resource.NewGithubClient(&resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+				V3Endpoint:  server.URL + "/",
+				V4Endpoint:  server.URL + "/graphql",
+			})
+			require.NoError(t, err)
+
+			pull, err := github.GetPullRequest("1", "oid1")
+			assert.Equal(t, "oid1", commitOID)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, pull.Number)
+			assert.Equal(t, "oid1", pull.Tip.OID)
+
+			// Labels are queried together with the pull request (e.g. to replace size labels).
+			assert.Contains(t, query, "labels(first:$labelsFirst){nodes{name}}")
+			assert.Equal(t, []resource.LabelObject{{Name: "size/S"}}, pull.Labels)
+		})
+	}
+}
+
+// v3Server serves the V3 API of a repository with an open pull request (1), a recently merged
+// one (2) and one closed long ago (3), and a V4 API which fails with the given status code.
+func v3Server(t *testing.T, v4Status int, requested *[]string) *httptest.Server {
+	const (
+		repo    = `"repo": {"full_name": "itsdalmo/test-repository"}`
+		open    = `{"number": 1, "state": "open", "title": "pr1 title", "updated_at": "%s", "head": {"ref": "feature", "sha": "oid1", ` + repo + `}, "base": {"ref": "master", "sha": "sha", ` + repo + `}%s}`
+		merged  = `{"number": 2, "state": "closed", "merged_at": "%s", "updated_at": "%s", "head": {"sha": "oid2", ` + repo + `}, "base": {` + repo + `}}`
+		closed  = `{"number": 3, "state": "closed", "updated_at": "2010-01-01T00:00:00Z", "head": {"sha": "oid3", ` + repo + `}, "base": {` + repo + `}}`
+		details = `, "additions": 10, "deletions": 5, "mergeable_state": "clean"`
+	)
+	recent := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requested = append(*requested, r.URL.Path+"?"+r.URL.Query().Get("state")+r.URL.Query().Get("page"))
+		switch r.URL.Path {
+		case "/graphql":
+			w.WriteHeader(v4Status)
+		case "/repos/itsdalmo/test-repository/pulls":
+			switch {
+			case r.URL.Query().Get("state") == "open":
+				w.Write([]byte(`[` + fmt.Sprintf(open, recent, "") + `]`))
+			case r.URL.Query().Get("page") == "":
+				// Closed pull requests are listed most recently updated first.
+				w.Header().Set("Link", `<`+server.URL+`/repos/itsdalmo/test-repository/pulls?state=closed&page=2>; rel="next"`)
+				w.Write([]byte(`[` + fmt.Sprintf(merged, recent, recent) + `, ` + closed + `]`))
+			default:
+				w.Write([]byte(`[]`))
+			}
+		case "/repos/itsdalmo/test-repository/pulls/1":
+			w.Write([]byte(fmt.Sprintf(open, recent, details)))
+		case "/repos/itsdalmo/test-repository/pulls/1/reviews":
+			w.Write([]byte(`[{"user": {"login": "approver"}, "state": "APPROVED"}, {"user": {"login": "commenter"}, "state": "COMMENTED"}]`))
+		case "/repos/itsdalmo/test-repository/pulls/2/reviews":
+			w.Write([]byte(`[]`))
+		case "/repos/itsdalmo/test-repository/commits/oid1", "/repos/itsdalmo/test-repository/commits/oid2":
+			w.Write([]byte(`{"sha": "` + path.Base(r.URL.Path) + `", "author": {"login": "login1"}, "commit": {"message": "commit message1", "committer": {"date": "2020-01-01T00:00:00Z"}}}`))
+		case "/search/issues":
+			w.Write([]byte(`{"total_count": 1, "items": [{"number": 1}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func TestV3Fallback(t *testing.T) {
+	tests := []struct {
+		description string
+		source      resource.Source
+		states      []githubv4.PullRequestState
+		v4Status    int
+		numbers     []int
+		lines       int
+		wantErr     string
+		requested   []string
+		unrequested []string
+	}{
+		{
+			description: "falls back when the V4 API does not exist",
+			states:      []githubv4.PullRequestState{githubv4.PullRequestStateOpen},
+			v4Status:    http.StatusNotFound,
+			numbers:     []int{1},
+			unrequested: []string{"/repos/itsdalmo/test-repository/pulls/1?", "/repos/itsdalmo/test-repository/pulls?closed"},
+		},
+		{
+			description: "fetches the changed lines if they are filtered on",
+			source:      resource.Source{MinChangedLines: 1},
+			states:      []githubv4.PullRequestState{githubv4.PullRequestStateOpen},
+			v4Status:    http.StatusNotFound,
+			numbers:     []int{1},
+			lines:       15,
+			requested:   []string{"/repos/itsdalmo/test-repository/pulls/1?"},
+		},
+		{
+			description: "stops listing closed pull requests at the state lookback",
+			source:      resource.Source{StateLookback: resource.Duration(24 * time.Hour)},
+			states:      []githubv4.PullRequestState{githubv4.PullRequestStateOpen, githubv4.PullRequestStateMerged},
+			v4Status:    http.StatusNotFound,
+			numbers:     []int{1, 2},
+			requested:   []string{"/repos/itsdalmo/test-repository/pulls?closed"},
+			unrequested: []string{"/repos/itsdalmo/test-repository/pulls?closed2"},
+		},
+		{
+			description: "lists at most max_prs pull requests",
+			source:      resource.Source{MaxPRs: 1},
+			states:      []githubv4.PullRequestState{githubv4.PullRequestStateMerged},
+			v4Status:    http.StatusNotFound,
+			numbers:     []int{2},
+			unrequested: []string{"/repos/itsdalmo/test-repository/pulls?closed2"},
+		},
+		{
+			description: "searches with search_query_extra",
+			source:      resource.Source{SearchQueryExtra: "label:ci"},
+			states:      []githubv4.PullRequestState{githubv4.PullRequestStateOpen},
+			v4Status:    http.StatusNotFound,
+			numbers:     []int{1},
+			lines:       15,
+			requested:   []string{"/search/issues?"},
+		},
+		{
+			description: "does not fall back on server errors",
+			states:      []githubv4.PullRequestState{githubv4.PullRequestStateOpen},
+			v4Status:    http.StatusBadGateway,
+			wantErr:     "non-200 OK status code: 502 Bad Gateway",
+			unrequested: []string{"/repos/itsdalmo/test-repository/pulls?open"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var requested []string
+			server := v3Server(t, tc.v4Status, &requested)
+			defer server.Close()
+
+			source := tc.source
+			source.Repository = "itsdalmo/test-repository"
+			source.AccessToken = "oauthtoken"
+			source.V3Endpoint = server.URL + "/"
+			source.V4Endpoint = server.URL + "/graphql"
+			github, err := resource.NewGithubClient(&source)
+			require.NoError(t, err)
+
+			pulls, err := github.ListPullRequests(tc.states, false)
+			if tc.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.wantErr)
+				}
+			} else {
+				require.NoError(t, err)
+			}
+
+			var numbers []int
+			for _, p := range pulls {
+				numbers = append(numbers, p.Number)
+				if p.Number == 1 {
+					assert.Equal(t, "oid1", p.Tip.OID)
+					assert.Equal(t, []string{"approver"}, p.ApprovedBy)
+					assert.Equal(t, 1, p.ApprovedReviewCount)
+					assert.Equal(t, tc.lines, p.Additions+p.Deletions)
+				}
+			}
+			assert.Equal(t, tc.numbers, numbers)
+			for _, r := range tc.requested {
+				assert.Contains(t, requested, r)
+			}
+			for _, r := range tc.unrequested {
+				assert.NotContains(t, requested, r)
+			}
+		})
+	}
+}
+
+func TestGetPullRequestV3Fallback(t *testing.T) {
+	var requested []string
+	server := v3Server(t, http.StatusNotFound, &requested)
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		V3Endpoint:  server.URL + "/",
+		V4Endpoint:  server.URL + "/graphql",
+	})
+	require.NoError(t, err)
+
+	pull, err := github.GetPullRequest("1", "oid1")
+	require.NoError(t, err)
+	assert.Equal(t, "pr1 title", pull.Title)
+	assert.Equal(t, "master", pull.BaseRefName)
+	assert.Equal(t, "oid1", pull.Tip.OID)
+	assert.Equal(t, 15, pull.Additions+pull.Deletions)
+	assert.Equal(t, "CLEAN", pull.MergeStateStatus)
+}
+
+func TestUpdateCommitStatus(t *testing.T) {
+	tests := []struct {
+		description string
+		current     string
+		strict      bool
+		expectPost  bool
+		expectError bool
+	}{
+		{
+			description: "creates a status when there is none for the context",
+			current:     `{"context": "concourse-ci/other", "state": "success", "description": "Concourse CI build SUCCESS", "target_url": "https://ci/builds/1"}`,
+			expectPost:  true,
+		},
+		{
+			description: "creates a status when the state has changed",
+			current:     `{"context": "concourse-ci/status", "state": "pending", "description": "Concourse CI build SUCCESS", "target_url": "https://ci/builds/1"}`,
+			expectPost:  true,
+		},
+		{
+			description: "creates a status when the target url has changed",
+			current:     `{"context": "concourse-ci/status", "state": "success", "description": "Concourse CI build SUCCESS", "target_url": "https://ci/builds/0"}`,
+			expectPost:  true,
+		},
+		{
+			description: "skips the status when nothing has changed",
+			current:     `{"context": "concourse-ci/status", "state": "success", "description": "Concourse CI build SUCCESS", "target_url": "https://ci/builds/1"}`,
+		},
+		{
+			description: "creates a status when the current status cannot be read",
+			expectPost:  true,
+		},
+		{
+			description: "fails when the current status cannot be read with strict statuses",
+			strict:      true,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var posted bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/repos/itsdalmo/test-repository/commits/commit1/status":
+					if tc.current == "" {
+						w.WriteHeader(http.StatusBadGateway)
+						return
+					}
+					w.Write([]byte(`{"statuses": [` + tc.current + `]}`))
+				case r.Method == http.MethodPost && r.URL.Path == "/repos/itsdalmo/test-repository/statuses/commit1":
+					posted = true
+					w.Write([]byte(`{}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			github, err := resource.NewGithubClient(&resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+				V3Endpoint:  server.URL + "/",
+				V4Endpoint:  server.URL + "/graphql",
+			})
+			require.NoError(t, err)
+			github.StrictStatuses = tc.strict
+
+			err = github.UpdateCommitStatus("commit1", "", "", "SUCCESS", "https://ci/builds/1", "")
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectPost, posted)
+		})
+	}
+}
+
+func TestRerequestCheckSuites(t *testing.T) {
+	var rerequested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/itsdalmo/test-repository/commits/commit1/check-suites":
+			w.Write([]byte(`{"total_count": 2, "check_suites": [{"id": 1, "app": {"name": "concourse"}}, {"id": 2, "app": {"name": "other"}}]}`))
+		case "/repos/itsdalmo/test-repository/check-suites/1/rerequest":
+			rerequested = append(rerequested, "1")
+			w.WriteHeader(http.StatusCreated)
+		case "/repos/itsdalmo/test-repository/check-suites/2/rerequest":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "Resource not accessible by integration"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		V3Endpoint:  server.URL + "/",
+		V4Endpoint:  server.URL + "/graphql",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, github.RerequestCheckSuites("commit1"))
+	assert.Equal(t, []string{"1"}, rerequested)
+}
+
+func TestRemoveLabel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/itsdalmo/test-repository/issues/1/labels/size/M":
+			w.Write([]byte(`[]`))
+		case "/repos/itsdalmo/test-repository/issues/1/labels/size/L":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Label does not exist"}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "Resource not accessible by integration"}`))
+		}
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		V3Endpoint:  server.URL + "/",
+		V4Endpoint:  server.URL + "/graphql",
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, github.RemoveLabel("1", "size/M"))
+	// Labels which the pull request does not have are skipped.
+	assert.NoError(t, github.RemoveLabel("1", "size/L"))
+	assert.Error(t, github.RemoveLabel("2", "size/M"))
+}
+
+func TestDownloadArchive(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/itsdalmo/test-repository/tarball/commit1":
+			w.Header().Set("Location", server.URL+"/codeload/commit1.tar.gz")
+			w.WriteHeader(http.StatusFound)
+		case "/codeload/commit1.tar.gz":
+			w.Write([]byte("tarball"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		V3Endpoint:  server.URL + "/",
+		V4Endpoint:  server.URL + "/graphql",
+	})
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	require.NoError(t, github.DownloadArchive("commit1", &archive))
+	assert.Equal(t, "tarball", archive.String())
+	assert.Error(t, github.DownloadArchive("commit2", &archive))
+}
+
+func TestDeletePreviousComments(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	recent := time.Now().UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		description string
+		namespace   string
+		expected    []string
+	}{
+		{
+			description: "deletes the comments of earlier releases without a marker",
+			expected:    []string{"1", "2", "4"},
+		},
+		{
+			description: "deletes the comments of earlier releases with the marker of the namespace",
+			namespace:   "ns",
+			expected:    []string{"1", "4", "6"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var cursors []interface{}
+			var deleted []string
+			var mu sync.Mutex
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodDelete {
+					mu.Lock()
+					deleted = append(deleted, path.Base(r.URL.Path))
+					mu.Unlock()
+					// Comments which are already deleted are skipped.
+					if path.Base(r.URL.Path) == "4" {
+						w.WriteHeader(http.StatusNotFound)
+						w.Write([]byte(`{"message": "Not Found"}`))
+						return
+					}
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+
+				var body struct {
+					Variables map[string]interface{} `json:"variables"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				cursors = append(cursors, body.Variables["commentsCursor"])
+
+				if body.Variables["commentsCursor"] == nil {
+					w.Write([]byte(`{"data": {"viewer": {"login": "bot"}, "repository": {"pullRequest": {"comments": {"edges": [
+						{"node": {"databaseId": 1, "body": "a <!-- github-pr-resource job=test -->", "createdAt": "` + old + `", "author": {"login": "bot"}}},
+						{"node": {"databaseId": 2, "body": "b", "createdAt": "` + old + `", "author": {"login": "bot"}}},
+						{"node": {"databaseId": 3, "body": "c <!-- github-pr-resource job=test -->", "createdAt": "` + old + `", "author": {"login": "someone"}}}
+					], "pageInfo": {"endCursor": "page2", "hasNextPage": true}}}}}}`))
+					return
+				}
+				w.Write([]byte(`{"data": {"viewer": {"login": "bot"}, "repository": {"pullRequest": {"comments": {"edges": [
+					{"node": {"databaseId": 4, "body": "d <!-- github-pr-resource job=test -->", "createdAt": "` + old + `", "author": {"login": "bot"}}},
+					{"node": {"databaseId": 5, "body": "e <!-- github-pr-resource job=test -->", "createdAt": "` + recent + `", "author": {"login": "bot"}}},
+					{"node": {"databaseId": 6, "body": "f\n\n<!-- github-pr-resource: ns -->", "createdAt": "` + old + `", "author": {"login": "bot"}}},
+					{"node": {"databaseId": 7, "body": "g <!-- github-pr-resource job=other -->", "createdAt": "` + old + `", "author": {"login": "bot"}}}
+				], "pageInfo": {"endCursor": "page3", "hasNextPage": false}}}}}}`))
+			}))
+			defer server.Close()
+
+			github, err := resource.NewGithubClient(&resource.Source{
+				Repository:       "itsdalmo/test-repository",
+				AccessToken:      "oauthtoken",
+				V3Endpoint:       server.URL + "/",
+				V4Endpoint:       server.URL + "/graphql",
+				ContextNamespace: tc.namespace,
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, github.DeletePreviousComments("1", "<!-- github-pr-resource job=test -->", time.Now().Add(-24*time.Hour)))
+			assert.Equal(t, []interface{}{nil, "page2"}, cursors)
+			sort.Strings(deleted)
+			assert.Equal(t, tc.expected, deleted)
+		})
+	}
+}
+
+func TestListOwnComments(t *testing.T) {
+	var cursors []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		cursors = append(cursors, body.Variables["commentsCursor"])
+
+		if body.Variables["commentsCursor"] == nil {
+			w.Write([]byte(`{"data": {"viewer": {"login": "bot"}, "repository": {"pullRequest": {"comments": {"nodes": [
+				{"databaseId": 1, "body": "a", "author": {"login": "bot"}},
+				{"databaseId": 2, "body": "b", "author": {"login": "someone"}}
+			], "pageInfo": {"endCursor": "page2", "hasNextPage": true}}}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"viewer": {"login": "bot"}, "repository": {"pullRequest": {"comments": {"nodes": [
+			{"databaseId": 3, "body": "c", "author": {"login": "bot"}}
+		], "pageInfo": {"hasNextPage": false}}}}}}`))
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		V3Endpoint:  server.URL + "/",
+		V4Endpoint:  server.URL + "/graphql",
+	})
+	require.NoError(t, err)
+
+	comments, err := github.ListOwnComments("1")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{nil, "page2"}, cursors)
+	var ids []int64
+	for _, c := range comments {
+		ids = append(ids, c.DatabaseID)
+	}
+	assert.Equal(t, []int64{1, 3}, ids)
+}
+
+func TestMinimizePreviousComments(t *testing.T) {
+	var minimized []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		if input, ok := body.Variables["input"]; ok {
+			minimized = append(minimized, input)
+			w.Write([]byte(`{"data": {"minimizeComment": {"clientMutationId": null}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"viewer": {"login": "bot"}, "repository": {"pullRequest": {"comments": {"edges": [
+			{"node": {"id": "c1", "databaseId": 1, "isMinimized": false, "body": "a <!-- marker -->", "author": {"login": "bot"}}},
+			{"node": {"id": "c2", "databaseId": 2, "isMinimized": true, "body": "b <!-- marker -->", "author": {"login": "bot"}}},
+			{"node": {"id": "c3", "databaseId": 3, "isMinimized": false, "body": "c", "author": {"login": "bot"}}}
+		], "pageInfo": {"hasNextPage": false}}}}}}`))
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		V3Endpoint:  server.URL + "/",
+		V4Endpoint:  server.URL + "/graphql",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, github.MinimizePreviousComments("1", "<!-- marker -->", "outdated"))
+	assert.Equal(t, []interface{}{map[string]interface{}{"subjectId": "c1", "classifier": "OUTDATED"}}, minimized)
+}
+
+func TestAPIBudget(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"total_count": 0, "check_suites": []}`))
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		V3Endpoint:  server.URL + "/",
+		V4Endpoint:  server.URL + "/graphql",
+	})
+	require.NoError(t, err)
+
+	github.SetAPIBudget(2)
+	require.NoError(t, github.RerequestCheckSuites("commit1"))
+	require.NoError(t, github.RerequestCheckSuites("commit1"))
+	err = github.RerequestCheckSuites("commit1")
+	assert.True(t, errors.Is(err, resource.ErrAPIBudgetExhausted), "unexpected error: %v", err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestListPullRequestsNodeLimit(t *testing.T) {
+	var pageSizes []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		pageSizes = append(pageSizes, body.Variables["prFirst"])
+
+		if body.Variables["prFirst"].(float64) > 25 {
+			w.Write([]byte(`{"errors": [{"type": "MAX_NODE_LIMIT_EXCEEDED", "message": "This query requests up to 1,010,100 possible nodes which exceeds the maximum limit of 500,000."}]}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"repository": {"pullRequests": {"edges": [
+			{"node": {"number": 1, "state": "OPEN", "commits": {"edges": [{"node": {"commit": {"oid": "oid1"}}}]}}}
+		], "pageInfo": {"hasNextPage": false}}}, "rateLimit": {"cost": 1, "remaining": 4999, "nodeCount": 10100}}}`))
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:  "itsdalmo/test-repository",
+		AccessToken: "oauthtoken",
+		V3Endpoint:  server.URL + "/",
+		V4Endpoint:  server.URL + "/graphql",
+	})
+	require.NoError(t, err)
+
+	pulls, err := github.ListPullRequests([]githubv4.PullRequestState{githubv4.PullRequestStateOpen}, true)
+	require.NoError(t, err)
+	assert.Len(t, pulls, 1)
+	assert.Equal(t, []interface{}{float64(100), float64(50), float64(25)}, pageSizes)
+}
+
+func TestAccessTokensFallback(t *testing.T) {
+	var used []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		used = append(used, auth)
+		switch auth {
+		case "Bearer primary":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+		case "Bearer secondary":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message": "Bad credentials"}`))
+		default:
+			w.Write([]byte(`{"total_count": 0, "check_suites": []}`))
+		}
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:   "itsdalmo/test-repository",
+		AccessToken:  "primary",
+		AccessTokens: []string{"secondary", "tertiary"},
+		V3Endpoint:   server.URL + "/",
+		V4Endpoint:   server.URL + "/graphql",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, github.RerequestCheckSuites("commit1"))
+	require.NoError(t, github.RerequestCheckSuites("commit1"))
+	assert.Equal(t, []string{"Bearer primary", "Bearer secondary", "Bearer tertiary", "Bearer tertiary"}, used)
+}
+
+func TestV3AcceptHeaders(t *testing.T) {
+	accept := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept[r.URL.Path] = r.Header.Get("Accept")
+		if r.URL.Path == "/graphql" {
+			w.Write([]byte(`{"data": {"repository": {"pullRequests": {"edges": [], "pageInfo": {"hasNextPage": false}}}}}`))
+			return
+		}
+		w.Write([]byte(`{"total_count": 0, "check_suites": []}`))
+	}))
+	defer server.Close()
+
+	github, err := resource.NewGithubClient(&resource.Source{
+		Repository:      "itsdalmo/test-repository",
+		AccessToken:     "oauthtoken",
+		V3Endpoint:      server.URL + "/",
+		V4Endpoint:      server.URL + "/graphql",
+		V3AcceptHeaders: []string{"application/vnd.github.shadow-cat-preview+json"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, github.RerequestCheckSuites("commit1"))
+	_, err = github.ListPullRequests([]githubv4.PullRequestState{githubv4.PullRequestStateOpen}, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/vnd.github.antiope-preview+json, application/vnd.github.shadow-cat-preview+json", accept["/repos/itsdalmo/test-repository/commits/commit1/check-suites"])
+	assert.NotContains(t, accept["/graphql"], "shadow-cat-preview")
+}
+
+func TestQueryCacheTTL(t *testing.T) {
+	tests := []struct {
+		description string
+		ttl         time.Duration
+		listed      string
+		commit      string
+		expected    int
+	}{
+		{
+			description: "get reuses the pull request listed by check",
+			ttl:         time.Minute,
+			listed:      "oid1",
+			commit:      "oid1",
+			expected:    1,
+		},
+		{
+			description: "get queries the pull request for another commit",
+			ttl:         time.Minute,
+			listed:      "oid2",
+			commit:      "oid1",
+			expected:    2,
+		},
+		{
+			description: "get queries the pull request when the cache expired",
+			ttl:         time.Nanosecond,
+			listed:      "oid1",
+			commit:      "oid1",
+			expected:    2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var queries int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				queries++
+				var body struct {
+					Variables map[string]interface{} `json:"variables"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				if _, ok := body.Variables["commitOID"]; ok {
+					w.Write([]byte(`{"data": {"repository": {"pullRequest": {"number": 1, "title": "queried", "state": "OPEN", "headRefOid": "oid1"}, "object": {"oid": "oid1", "associatedPullRequests": {"nodes": []}}}}}`))
+					return
+				}
+				w.Write([]byte(`{"data": {"repository": {"pullRequests": {"edges": [
+					{"node": {"number": 1, "title": "listed", "state": "OPEN", "labels": {"edges": [{"node": {"name": "bug"}}]}, "commits": {"edges": [{"node": {"commit": {"oid": "` + tc.listed + `"}}}]}}}
+				], "pageInfo": {"hasNextPage": false}}}}}`))
+			}))
+			defer server.Close()
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			// Check and get are separate invocations of the resource, with a client each.
+			source := &resource.Source{
+				Repository:    "itsdalmo/test-repository",
+				AccessToken:   "oauthtoken",
+				V3Endpoint:    server.URL + "/",
+				V4Endpoint:    server.URL + "/graphql",
+				QueryCacheTTL: resource.Duration(tc.ttl),
+				QueryCacheDir: dir,
+			}
+			check, err := resource.NewGithubClient(source)
+			require.NoError(t, err)
+			_, err = check.ListPullRequests([]githubv4.PullRequestState{githubv4.PullRequestStateOpen}, false)
+			require.NoError(t, err)
+
+			get, err := resource.NewGithubClient(source)
+			require.NoError(t, err)
+			pull, err := get.GetPullRequest("1", tc.commit)
+			require.NoError(t, err)
+			assert.Equal(t, tc.commit, pull.Tip.OID)
+			if tc.expected == 1 {
+				assert.Equal(t, "listed", pull.Title)
+				assert.Equal(t, []resource.LabelObject{{Name: "bug"}}, pull.Labels)
+			} else {
+				assert.Equal(t, "queried", pull.Title)
+			}
+			assert.Equal(t, tc.expected, queries)
+		})
+	}
+}
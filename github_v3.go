@@ -0,0 +1,335 @@
+package resource
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// v4Unavailable returns true if a GraphQL query failed because the endpoint does
+// not exist, or because the schema does not support the query (e.g. older versions
+// of Github Enterprise), in which case the V3 API can be used instead. Other errors
+// (e.g. server errors or secondary rate limits) are returned as is, since falling
+// back would turn a single failed query into a request per pull request.
+func v4Unavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	for _, s := range []string{
+		"non-200 OK status code: 404",
+		"doesn't exist on type",
+		"doesn't accept argument",
+		"isn't a defined input type",
+		"Unknown directive",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// listPullRequestsV3 is the V3 equivalent of ListPullRequests. Open pull requests are
+// all listed, and closed ones only as far back as the state lookback (if any), most
+// recently updated first and at most max_prs of them.
+func (m *GithubClient) listPullRequestsV3(prStates []githubv4.PullRequestState) ([]*PullRequest, error) {
+	var open, closed bool
+	for _, s := range prStates {
+		if s == githubv4.PullRequestStateOpen {
+			open = true
+		} else {
+			closed = true
+		}
+	}
+
+	var listed []*github.PullRequest
+	if open {
+		pulls, err := m.listPullRequestsByStateV3("open", prStates, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		listed = append(listed, pulls...)
+	}
+	if closed {
+		var since time.Time
+		if m.StateLookback > 0 {
+			since = time.Now().Add(-m.StateLookback)
+		}
+		pulls, err := m.listPullRequestsByStateV3("closed", prStates, since)
+		if err != nil {
+			return nil, err
+		}
+		listed = append(listed, pulls...)
+	}
+
+	if m.MaxPRs > 0 && len(listed) > m.MaxPRs {
+		sort.SliceStable(listed, func(i, j int) bool {
+			return listed[i].GetUpdatedAt().After(listed[j].GetUpdatedAt())
+		})
+		logger.Warn("pull requests truncated by max_prs", "max_prs", m.MaxPRs)
+		listed = listed[:m.MaxPRs]
+	}
+	return m.pullRequestsV3(listed)
+}
+
+// listPullRequestsByStateV3 lists the pull requests in the V3 state ("open" or "closed")
+// which have one of the given states, most recently updated first, until a pull request
+// was updated before since (if set) or max_prs pull requests have been listed.
+func (m *GithubClient) listPullRequestsByStateV3(state string, prStates []githubv4.PullRequestState, since time.Time) ([]*github.PullRequest, error) {
+	opt := &github.PullRequestListOptions{
+		State:     state,
+		Sort:      "updated",
+		Direction: "desc",
+		ListOptions: github.ListOptions{
+			PerPage: m.pageSizeV3(),
+		},
+	}
+
+	var response []*github.PullRequest
+	for {
+		ctx, cancel := m.context()
+		result, resp, err := m.V3.PullRequests.List(ctx, m.Owner, m.Repository, opt)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range result {
+			if !since.IsZero() && p.GetUpdatedAt().Before(since) {
+				return response, nil
+			}
+			if !containsState(prStates, pullRequestFromV3(p).State) {
+				continue
+			}
+			response = append(response, p)
+			if m.MaxPRs > 0 && len(response) >= m.MaxPRs {
+				return response, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return response, nil
+}
+
+// searchPullRequestsV3 is the V3 equivalent of searchPullRequests, for the given search
+// qualifiers. The results are sorted by the API instead of a sort qualifier. Searching
+// requires an additional request per pull request, so it is only used for search_query_extra.
+func (m *GithubClient) searchPullRequestsV3(prStates []githubv4.PullRequestState, qualifiers []string) ([]*PullRequest, error) {
+	var q []string
+	for _, s := range qualifiers {
+		if !strings.HasPrefix(s, "sort:") {
+			q = append(q, s)
+		}
+	}
+	opt := &github.SearchOptions{
+		Sort:  "updated",
+		Order: "desc",
+		ListOptions: github.ListOptions{
+			PerPage: m.pageSizeV3(),
+		},
+	}
+
+	var listed []*github.PullRequest
+search:
+	for {
+		ctx, cancel := m.context()
+		result, resp, err := m.V3.Search.Issues(ctx, strings.Join(q, " "), opt)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range result.Issues {
+			// Search results are issues, which lack the details of the pull request.
+			ctx, cancel := m.context()
+			p, _, err := m.V3.PullRequests.Get(ctx, m.Owner, m.Repository, issue.GetNumber())
+			cancel()
+			if err != nil {
+				return nil, err
+			}
+			if !containsState(prStates, pullRequestFromV3(p).State) {
+				continue
+			}
+			listed = append(listed, p)
+			if m.MaxPRs > 0 && len(listed) >= m.MaxPRs {
+				break search
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return m.pullRequestsV3(listed)
+}
+
+// pullRequestsV3 converts listed pull requests, which requires additional requests for the
+// tip and approved reviews of each pull request, and for the number of changed lines if
+// they are filtered on (since they are not part of listed pull requests).
+func (m *GithubClient) pullRequestsV3(listed []*github.PullRequest) ([]*PullRequest, error) {
+	var response []*PullRequest
+	for _, p := range listed {
+		if m.IncludeChangedLines && p.Additions == nil {
+			ctx, cancel := m.context()
+			full, _, err := m.V3.PullRequests.Get(ctx, m.Owner, m.Repository, p.GetNumber())
+			cancel()
+			if err != nil {
+				return nil, err
+			}
+			p = full
+		}
+		pr := pullRequestFromV3(p)
+		var err error
+		if pr.Tip, err = m.getCommitV3(p.GetHead().GetSHA()); err != nil {
+			return nil, err
+		}
+		if pr.ApprovedBy, err = m.listApprovedReviewsV3(pr.Number); err != nil {
+			return nil, err
+		}
+		pr.ApprovedReviewCount = len(pr.ApprovedBy)
+		response = append(response, pr)
+	}
+	return response, nil
+}
+
+// pageSizeV3 is the number of pull requests listed per page of the V3 API.
+func (m *GithubClient) pageSizeV3() int {
+	if m.PageSize > 0 {
+		return m.PageSize
+	}
+	return 100
+}
+
+// getPullRequestV3 is the V3 equivalent of GetPullRequest.
+func (m *GithubClient) getPullRequestV3(prNumber int, commitRef string) (*PullRequest, error) {
+	ctx, cancel := m.context()
+	p, _, err := m.V3.PullRequests.Get(ctx, m.Owner, m.Repository, prNumber)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	pr := pullRequestFromV3(p)
+	if pr.Tip, err = m.getCommitV3(commitRef); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// getChangedFilesV3 is the V3 equivalent of GetChangedFiles.
+func (m *GithubClient) getChangedFilesV3(prNumber int) ([]ChangedFileObject, error) {
+	return m.ListModifiedFiles(prNumber, nil)
+}
+
+// v3ChangeTypes maps the status of a file in the V3 API to its V4 change type.
+var v3ChangeTypes = map[string]string{
+	"added":     "ADDED",
+	"removed":   "DELETED",
+	"modified":  "MODIFIED",
+	"renamed":   "RENAMED",
+	"copied":    "COPIED",
+	"changed":   "CHANGED",
+	"unchanged": "MODIFIED",
+}
+
+func (m *GithubClient) getCommitV3(sha string) (CommitObject, error) {
+	ctx, cancel := m.context()
+	defer cancel()
+
+	c, _, err := m.V3.Repositories.GetCommit(ctx, m.Owner, m.Repository, sha)
+	if err != nil {
+		return CommitObject{}, err
+	}
+
+	var commit CommitObject
+	commit.ID = c.GetNodeID()
+	commit.OID = c.GetSHA()
+	commit.CommittedDate = githubv4.DateTime{Time: c.GetCommit().GetCommitter().GetDate()}
+	commit.Message = c.GetCommit().GetMessage()
+	commit.Author.User.Login = c.GetAuthor().GetLogin()
+	commit.Author.Email = c.GetCommit().GetAuthor().GetEmail()
+	return commit, nil
+}
+
+func (m *GithubClient) listApprovedReviewsV3(prNumber int) ([]string, error) {
+	var approvedBy []string
+
+	opt := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		ctx, cancel := m.context()
+		result, resp, err := m.V3.PullRequests.ListReviews(ctx, m.Owner, m.Repository, prNumber, opt)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range result {
+			if r.GetState() == "APPROVED" {
+				approvedBy = append(approvedBy, r.GetUser().GetLogin())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return approvedBy, nil
+}
+
+// pullRequestFromV3 converts a V3 pull request to its V4 representation (without a tip).
+func pullRequestFromV3(p *github.PullRequest) *PullRequest {
+	pr := &PullRequest{}
+	pr.ID = p.GetNodeID()
+	pr.Number = p.GetNumber()
+	pr.Title = p.GetTitle()
+	pr.URL = p.GetHTMLURL()
+	pr.BaseRefName = p.GetBase().GetRef()
+	pr.BaseRefOID = p.GetBase().GetSHA()
+	pr.HeadRefName = p.GetHead().GetRef()
+	pr.Repository.URL = p.GetBase().GetRepo().GetHTMLURL()
+	pr.Author.Login = p.GetUser().GetLogin()
+	pr.IsCrossRepository = p.GetHead().GetRepo().GetFullName() != p.GetBase().GetRepo().GetFullName()
+	if repo := p.GetHead().GetRepo(); repo != nil {
+		pr.HeadRepository = &HeadRepositoryObject{URL: repo.GetHTMLURL(), IsArchived: repo.GetArchived(), IsPrivate: repo.GetPrivate()}
+		pr.HeadRepository.Owner.Login = repo.GetOwner().GetLogin()
+	}
+	pr.MaintainerCanModify = p.GetMaintainerCanModify()
+	pr.IsDraft = p.GetDraft()
+	pr.ClosedAt = githubv4.DateTime{Time: p.GetClosedAt()}
+	pr.MergedAt = githubv4.DateTime{Time: p.GetMergedAt()}
+	pr.UpdatedAt = githubv4.DateTime{Time: p.GetUpdatedAt()}
+	pr.Additions = p.GetAdditions()
+	pr.Deletions = p.GetDeletions()
+
+	// The review decision is not available in the V3 API.
+	pr.MergeStateStatus = strings.ToUpper(p.GetMergeableState())
+
+	switch {
+	case p.MergedAt != nil:
+		pr.State = githubv4.PullRequestStateMerged
+	case p.GetState() == "closed":
+		pr.State = githubv4.PullRequestStateClosed
+	default:
+		pr.State = githubv4.PullRequestStateOpen
+	}
+
+	for _, l := range p.Labels {
+		pr.Labels = append(pr.Labels, LabelObject{Name: l.GetName()})
+	}
+	return pr
+}
+
+func containsState(states []githubv4.PullRequestState, state githubv4.PullRequestState) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
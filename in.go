@@ -6,7 +6,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Get (business logic)
@@ -15,28 +18,72 @@ func Get(request GetRequest, github Github, git Git, outputDir string) (*GetResp
 		return &GetResponse{Version: request.Version}, nil
 	}
 
-	pull, err := github.GetPullRequest(request.Version.PR, request.Version.Commit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve pull request: %s", err)
+	// The merged tree is the result of the integration, which must therefore be a merge.
+	if request.Params.CheckoutBoth && request.Params.IntegrationTool != "" && request.Params.IntegrationTool != "merge" {
+		return nil, fmt.Errorf("checkout_both requires the merge integration tool, got: %s", request.Params.IntegrationTool)
 	}
 
-	// Initialize and pull the base for the PR
-	if err := git.Init(pull.BaseRefName); err != nil {
-		return nil, err
+	if request.Params.ListMatchedPaths && len(request.Source.Paths) == 0 && request.Source.TriggerConfig == "" {
+		return nil, fmt.Errorf("list_matched_paths requires paths (or a trigger_config)")
 	}
-	if err := git.Pull(pull.Repository.URL, pull.BaseRefName, request.Params.GitDepth, request.Params.Submodules, request.Params.FetchTags); err != nil {
-		return nil, err
+
+	switch request.Params.VerifySignatures {
+	case "", "github":
+	case "gpg_keys":
+		if len(request.Params.GPGKeys) == 0 {
+			return nil, fmt.Errorf("verify_signatures with gpg_keys requires gpg_keys")
+		}
+		if request.Params.Archive {
+			return nil, fmt.Errorf("verify_signatures with gpg_keys cannot be combined with archive")
+		}
+	default:
+		return nil, fmt.Errorf("invalid verify_signatures specified: %s", request.Params.VerifySignatures)
 	}
 
-	// Get the last commit SHA in base for the metadata
-	baseSHA, err := git.RevParse(pull.BaseRefName)
+	// Archives are not Git repositories, and contain the pull request as is.
+	if request.Params.Archive {
+		for _, p := range []struct {
+			name string
+			set  bool
+		}{
+			{"integration_tool", request.Params.IntegrationTool != ""},
+			{"submodules", request.Params.Submodules},
+			{"reference_repo", request.Params.ReferenceRepo != ""},
+			{"export_bundle", request.Params.ExportBundle},
+			{"checkout_base", request.Params.CheckoutBase},
+			{"checkout_both", request.Params.CheckoutBoth},
+			{"git_crypt_key", request.Source.GitCryptKey != ""},
+		} {
+			if p.set {
+				return nil, fmt.Errorf("archive cannot be combined with %s", p.name)
+			}
+		}
+	}
+
+	start := time.Now()
+
+	// The commit of a merge group is not part of the pull request, but the version holds
+	// the head commit of the pull request that it was created for.
+	commit := request.Version.Commit
+	if request.Version.MergeGroup != "" {
+		if request.Version.HeadSHA == "" {
+			return nil, fmt.Errorf("version of merge group %s is missing the head commit of the pull request", request.Version.MergeGroup)
+		}
+		commit = request.Version.HeadSHA
+	}
+	pull, err := github.GetPullRequest(request.Version.PR, commit)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to retrieve pull request: %w", err)
 	}
+	logger.Info("fetching pull request", "pr", pull.Number, "commit", pull.Tip.OID, "base", pull.BaseRefName)
 
-	// Fetch the PR and merge the specified commit into the base
-	if err := git.Fetch(pull.Repository.URL, pull.Number, request.Params.GitDepth, request.Params.Submodules); err != nil {
-		return nil, err
+	var baseSHA, mergeBaseSHA string
+	if request.Params.Archive {
+		baseSHA = request.Version.BaseSHA
+	} else {
+		if baseSHA, mergeBaseSHA, err = clone(request, pull, git); err != nil {
+			return nil, err
+		}
 	}
 
 	// Create the metadata
@@ -47,95 +94,359 @@ func Get(request GetRequest, github Github, git Git, outputDir string) (*GetResp
 	metadata.Add("head_name", pull.HeadRefName)
 	metadata.Add("head_sha", pull.Tip.OID)
 	metadata.Add("base_name", pull.BaseRefName)
-	metadata.Add("base_sha", baseSHA)
+	if baseSHA != "" {
+		metadata.Add("base_sha", baseSHA)
+	}
+	if mergeBaseSHA != "" {
+		metadata.Add("merge_base_sha", mergeBaseSHA)
+	}
 	metadata.Add("message", pull.Tip.Message)
 	metadata.Add("author", pull.Tip.Author.User.Login)
 	metadata.Add("author_email", pull.Tip.Author.Email)
 	metadata.Add("state", string(pull.State))
+	if !pull.UpdatedAt.IsZero() {
+		metadata.Add("last_activity", pull.UpdatedAt.UTC().Format(time.RFC3339))
+	}
+	if request.Version.ForcePushed != "" {
+		metadata.Add("force_pushed", request.Version.ForcePushed)
+	}
+	if pull.MergeStateStatus != "" {
+		metadata.Add("merge_state_status", pull.MergeStateStatus)
+	}
+	if pull.ReviewDecision != "" {
+		metadata.Add("review_decision", pull.ReviewDecision)
+	}
+	if pull.IsCrossRepository && pull.HeadRepository != nil {
+		// Tasks which push commits back to a fork need to know where (and whether they can).
+		visibility := "public"
+		if pull.HeadRepository.IsPrivate {
+			visibility = "private"
+		}
+		metadata.Add("head_repository_clone_url", pull.HeadRepository.URL+".git")
+		metadata.Add("head_repository_owner", pull.HeadRepository.Owner.Login)
+		metadata.Add("head_repository_visibility", visibility)
+		metadata.Add("maintainer_can_modify", strconv.FormatBool(pull.MaintainerCanModify))
+	}
+	if request.Version.MergeGroup != "" {
+		metadata.Add("merge_group", request.Version.MergeGroup)
+		metadata.Add("merge_group_sha", request.Version.Commit)
+	}
+	if request.Version.Component != "" {
+		metadata.Add("component", request.Version.Component)
+	}
+
+	// The paths of the trigger configuration on the base branch take precedence, like in check.
+	source := request.Source
+	if request.Params.ListMatchedPaths {
+		triggers := &triggerSources{manager: github, source: request.Source, sources: make(map[string]Source)}
+		if source, err = triggers.get(pull.BaseRefName); err != nil {
+			return nil, err
+		}
+	}
+
+	// The changed files are fetched once for both list_changed_files and list_matched_paths.
+	var changed []ChangedFileObject
+	if request.Params.ListChangedFiles || request.Params.ListMatchedPaths {
+		changed, err = github.GetChangedFiles(request.Version.PR, request.Version.Commit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch list of changed files: %w", err)
+		}
+	}
+
+	// List the patterns in paths which matched the changed files (one per line), so that
+	// tasks can decide what to build in a monorepo. Like all metadata, they are also
+	// written to a file of the same name.
+	if request.Params.ListMatchedPaths {
+		matched, err := MatchedPaths(source, changed)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Add("matched_paths", strings.Join(matched, "\n"))
+	}
+
+	// List the issue keys referenced by the pull request (one per line), so that tasks
+	// can e.g. transition the issues without parsing the pull request themselves.
+	if request.Source.IssueKeyRegex != "" {
+		commits, err := github.ListPullRequestCommits(request.Version.PR)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list commits: %w", err)
+		}
+		texts := []string{pull.Title, pull.HeadRefName}
+		for _, c := range commits {
+			texts = append(texts, c.Message)
+		}
+		keys := IssueKeys(regexp.MustCompile(request.Source.IssueKeyRegex), texts...)
+		metadata.Add("issue_keys", strings.Join(keys, "\n"))
+	}
+
+	// The rate limit helps operators to tune check_every (see check --suggest-interval).
+	if rl := reportRateLimit(github); rl != nil {
+		metadata.Add("rate_limit_remaining", strconv.Itoa(rl.Remaining))
+		metadata.Add("rate_limit_reset_at", rl.ResetAt.Time.Format(time.RFC3339))
+	}
 
 	// Write version and metadata for reuse in PUT
 	path := filepath.Join(outputDir, ".git", "resource")
 	if err := os.MkdirAll(path, os.ModePerm); err != nil {
-		return nil, fmt.Errorf("failed to create output directory: %s", err)
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 	b, err := json.Marshal(request.Version)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal version: %s", err)
+		return nil, fmt.Errorf("failed to marshal version: %w", err)
 	}
 	if err := ioutil.WriteFile(filepath.Join(path, "version.json"), b, 0644); err != nil {
-		return nil, fmt.Errorf("failed to write version: %s", err)
+		return nil, fmt.Errorf("failed to write version: %w", err)
 	}
 	b, err = json.Marshal(metadata)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal metadata: %s", err)
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 	if err := ioutil.WriteFile(filepath.Join(path, "metadata.json"), b, 0644); err != nil {
-		return nil, fmt.Errorf("failed to write metadata: %s", err)
+		return nil, fmt.Errorf("failed to write metadata: %w", err)
+	}
+	b, err = json.Marshal(NewPipelineVars(pull))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pipeline vars: %w", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(path, "vars.json"), b, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write pipeline vars: %w", err)
 	}
 
 	for _, d := range metadata {
 		filename := d.Name
 		content := []byte(d.Value)
 		if err := ioutil.WriteFile(filepath.Join(path, filename), content, 0644); err != nil {
-			return nil, fmt.Errorf("failed to write metadata file %s: %s", filename, err)
+			return nil, fmt.Errorf("failed to write metadata file %s: %w", filename, err)
 		}
 	}
 
-	switch tool := request.Params.IntegrationTool; tool {
-	case "rebase":
-		if err := git.Rebase(pull.BaseRefName, pull.Tip.OID, request.Params.Submodules); err != nil {
-			return nil, err
-		}
-	case "merge", "":
-		if err := git.Merge(pull.Tip.OID, request.Params.Submodules); err != nil {
-			return nil, err
-		}
-	case "checkout":
-		if err := git.Checkout(pull.HeadRefName, pull.Tip.OID, request.Params.Submodules); err != nil {
+	// Archives are downloaded as is, and everything else is integrated with Git.
+	if request.Params.Archive {
+		if err := downloadArchive(github, request.Version.Commit, outputDir); err != nil {
 			return nil, err
 		}
-	default:
-		return nil, fmt.Errorf("invalid integration tool specified: %s", tool)
+	} else if err := integrate(request, pull, git, mergeBaseSHA, path); err != nil {
+		return nil, err
 	}
 
-	if request.Source.GitCryptKey != "" {
-		if err := git.GitCryptUnlock(request.Source.GitCryptKey); err != nil {
+	// The archive is of the commit in the version, while the checkout is the result of the integration.
+	checkoutSHA := request.Version.Commit
+	if !request.Params.Archive {
+		if checkoutSHA, err = git.RevParse("HEAD"); err != nil {
 			return nil, err
 		}
 	}
 
-	if request.Params.ListChangedFiles {
-		cfol, err := github.GetChangedFiles(request.Version.PR, request.Version.Commit)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch list of changed files: %s", err)
-		}
+	// Fail the build if the signature of the head of the pull request cannot be verified.
+	if err := verifySignature(request.Params, pull.Tip.OID, github, git); err != nil {
+		return nil, err
+	}
 
+	if request.Params.ListChangedFiles {
 		var fl []byte
 
-		for _, v := range cfol {
+		for _, v := range changed {
 			fl = append(fl, []byte(v.Path+"\n")...)
 		}
 
 		// Create List with changed files
 		if err := ioutil.WriteFile(filepath.Join(path, "changed_files"), fl, 0644); err != nil {
-			return nil, fmt.Errorf("failed to write file list: %s", err)
+			return nil, fmt.Errorf("failed to write file list: %w", err)
 		}
 	}
 
+	// Record the provenance of the checkout for supply chain attestations.
+	b, err = json.Marshal(NewProvenance(request.Version, request.Params, pull, baseSHA, checkoutSHA, start, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal provenance: %w", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(path, "provenance.json"), b, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write provenance: %w", err)
+	}
+
+	logger.Info("get finished", "pr", pull.Number, "duration", time.Since(start))
 	return &GetResponse{
 		Version:  request.Version,
 		Metadata: metadata,
 	}, nil
 }
 
+// verifySignature of a commit with Github or the configured keys, if enabled.
+func verifySignature(params GetParameters, commit string, github Github, git Git) error {
+	switch params.VerifySignatures {
+	case "github":
+		verification, err := github.GetSignatureVerification(commit)
+		if err != nil {
+			return fmt.Errorf("failed to get signature verification: %w", err)
+		}
+		if !verification.Verified {
+			return fmt.Errorf("signature of commit '%s' is not verified by github: %s", commit, verification.Reason)
+		}
+	case "gpg_keys":
+		if err := git.VerifyCommit(commit, params.GPGKeys); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	logger.Info("verified commit signature", "commit", commit, "with", params.VerifySignatures)
+	return nil
+}
+
+// clone the base of the pull request and fetch the pull request, returning the commit of
+// the base and the merge base of the pull request (if it could be found).
+func clone(request GetRequest, pull *PullRequest, git Git) (baseSHA, mergeBaseSHA string, err error) {
+	// Initialize and pull the base for the PR
+	if err := git.Init(pull.BaseRefName); err != nil {
+		return "", "", err
+	}
+	if request.Params.ReferenceRepo != "" {
+		if err := git.AddReference(request.Params.ReferenceRepo); err != nil {
+			logger.Warn("cloning without reference repository", "error", err)
+		}
+	}
+
+	// Fetch the PR while pulling the base, unless both would update the shallow
+	// history or the submodules of the repository at the same time. Merge groups
+	// already contain the merge of the PR into the base.
+	fetch := func() error {
+		if request.Version.MergeGroup != "" {
+			return nil
+		}
+		// Private forks may not be readable with the access token, in which case the
+		// head is fetched from the fork itself with the fork access token.
+		if request.Source.ForkAccessToken != "" && pull.IsCrossRepository && pull.HeadRepository != nil {
+			return git.FetchFork(pull.HeadRepository.URL, pull.HeadRefName, request.Params.GitDepth, request.Params.Submodules)
+		}
+		return git.Fetch(pull.Repository.URL, pull.Number, request.Params.GitDepth, request.Params.Submodules)
+	}
+	fetched := make(chan error, 1)
+	if request.Params.GitDepth == 0 && !request.Params.Submodules {
+		go func() { fetched <- fetch() }()
+	}
+	if err := git.Pull(pull.Repository.URL, pull.BaseRefName, request.Params.GitDepth, request.Params.Submodules, request.Params.FetchTags); err != nil {
+		return "", "", err
+	}
+
+	// Use the base commit that was evaluated by check if the base is pinned (or of the
+	// merge group), so that the build is reproducible when the base branch has moved since.
+	if request.Version.BaseSHA != "" && (inVersionKey(request.Source, "base_sha") || request.Version.MergeGroup != "") {
+		if err := git.Reset(request.Version.BaseSHA, request.Params.GitDepth); err != nil {
+			return "", "", err
+		}
+	}
+
+	// Get the last commit SHA in base for the metadata
+	baseSHA, err = git.RevParse(pull.BaseRefName)
+	if err != nil {
+		return "", "", err
+	}
+
+	if request.Params.GitDepth != 0 || request.Params.Submodules {
+		fetched <- fetch()
+	}
+	if err := <-fetched; err != nil {
+		return "", "", err
+	}
+
+	// The merge base is what the pull request is compared against, which differs from the
+	// base when the base branch has moved since the pull request branched off. It cannot be
+	// found when the history is too shallow (or unrelated), in which case it is left out of
+	// the metadata and not checked out.
+	if request.Version.MergeGroup == "" {
+		mergeBaseSHA, err = git.MergeBase(baseSHA, pull.Tip.OID)
+		if err != nil {
+			logger.Warn("failed to find the merge base, the history may be too shallow", "git_depth", request.Params.GitDepth, "error", err)
+			mergeBaseSHA = ""
+		}
+	}
+	return baseSHA, mergeBaseSHA, nil
+}
+
+// integrate the pull request with the base using the integration tool, and prepare the
+// rest of the checkout.
+func integrate(request GetRequest, pull *PullRequest, git Git, mergeBaseSHA, path string) error {
+	// Merge groups are checked out as is.
+	if request.Version.MergeGroup != "" {
+		if err := git.Reset(request.Version.Commit, request.Params.GitDepth); err != nil {
+			return err
+		}
+	} else {
+		switch tool := request.Params.IntegrationTool; tool {
+		case "rebase":
+			if err := git.Rebase(pull.BaseRefName, pull.Tip.OID, request.Params.Submodules); err != nil {
+				return err
+			}
+		case "merge", "":
+			if err := git.Merge(pull.Tip.OID, request.Params.Submodules); err != nil {
+				return err
+			}
+		case "checkout":
+			if err := git.Checkout(pull.HeadRefName, pull.Tip.OID, request.Params.Submodules); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid integration tool specified: %s", tool)
+		}
+	}
+
+	if request.Params.ReferenceRepo != "" {
+		if err := git.Dissociate(); err != nil {
+			return err
+		}
+	}
+
+	if request.Params.ExportBundle {
+		if err := git.Bundle(filepath.Join(path, "repo.bundle")); err != nil {
+			return err
+		}
+	}
+
+	if request.Source.GitCryptKey != "" {
+		if err := git.GitCryptUnlock(request.Source.GitCryptKey); err != nil {
+			return err
+		}
+	}
+
+	// Check out the merge base next to the pull request, for tasks which compare the two.
+	// The trees are written outside of the working tree (where they could collide with the
+	// files of the repository), once it has been unlocked so that they are decrypted too.
+	trees := filepath.Join(path, "trees")
+	if request.Params.CheckoutBase && mergeBaseSHA != "" {
+		if err := git.CheckoutTree(mergeBaseSHA, filepath.Join(trees, "base")); err != nil {
+			return err
+		}
+	}
+
+	// Check out the head of the pull request as is and merged with the base, for tasks
+	// which compare the behavior before and after the merge.
+	if request.Params.CheckoutBoth {
+		if err := git.CheckoutTree(pull.Tip.OID, filepath.Join(trees, "head")); err != nil {
+			return err
+		}
+		if err := git.CheckoutTree("HEAD", filepath.Join(trees, "merged")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetParameters ...
 type GetParameters struct {
-	SkipDownload     bool   `json:"skip_download"`
-	IntegrationTool  string `json:"integration_tool"`
-	GitDepth         int    `json:"git_depth"`
-	Submodules       bool   `json:"submodules"`
-	ListChangedFiles bool   `json:"list_changed_files"`
-	FetchTags        bool   `json:"fetch_tags"`
+	SkipDownload     bool     `json:"skip_download"`
+	IntegrationTool  string   `json:"integration_tool"`
+	GitDepth         int      `json:"git_depth"`
+	Submodules       bool     `json:"submodules"`
+	ListChangedFiles bool     `json:"list_changed_files"`
+	ListMatchedPaths bool     `json:"list_matched_paths"`
+	FetchTags        bool     `json:"fetch_tags"`
+	ReferenceRepo    string   `json:"reference_repo"`
+	ExportBundle     bool     `json:"export_bundle"`
+	CheckoutBase     bool     `json:"checkout_base"`
+	CheckoutBoth     bool     `json:"checkout_both"`
+	Archive          bool     `json:"archive"`
+	VerifySignatures string   `json:"verify_signatures"`
+	GPGKeys          []string `json:"gpg_keys"`
 }
 
 // GetRequest ...
@@ -1,11 +1,17 @@
 package resource_test
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -44,7 +50,48 @@ func TestGet(t *testing.T) {
 			parameters:     resource.GetParameters{},
 			pullRequest:    createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
 			versionString:  `{"pr":"pr1","commit":"commit1","committed":"0001-01-01T00:00:00Z","approved_review_count":"0","state":"OPEN"}`,
-			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"}]`,
+			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"},{"name":"last_activity","value":"2020-05-01T12:00:00Z"}]`,
+		},
+		{
+			description: "get resets the base to the commit evaluated by check",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+				PinBaseSHA:  true,
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+				State:         githubv4.PullRequestStateOpen,
+				BaseSHA:       "basesha1",
+			},
+			parameters:     resource.GetParameters{GitDepth: 2},
+			pullRequest:    createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+			versionString:  `{"pr":"pr1","commit":"commit1","committed":"0001-01-01T00:00:00Z","state":"OPEN","base_sha":"basesha1"}`,
+			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"},{"name":"last_activity","value":"2020-05-01T12:00:00Z"}]`,
+		},
+		{
+			description: "get includes the merge state and review decision",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+				State:         githubv4.PullRequestStateOpen,
+			},
+			parameters: resource.GetParameters{},
+			pullRequest: func() *resource.PullRequest {
+				p := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+				p.MergeStateStatus = "BLOCKED"
+				p.ReviewDecision = "REVIEW_REQUIRED"
+				return p
+			}(),
+			versionString:  `{"pr":"pr1","commit":"commit1","committed":"0001-01-01T00:00:00Z","state":"OPEN"}`,
+			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"},{"name":"last_activity","value":"2020-05-01T12:00:00Z"},{"name":"merge_state_status","value":"BLOCKED"},{"name":"review_decision","value":"REVIEW_REQUIRED"}]`,
 		},
 		{
 			description: "get supports unlocking with git crypt",
@@ -63,7 +110,7 @@ func TestGet(t *testing.T) {
 			parameters:     resource.GetParameters{},
 			pullRequest:    createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
 			versionString:  `{"pr":"pr1","commit":"commit1","committed":"0001-01-01T00:00:00Z","approved_review_count":"0","state":"OPEN"}`,
-			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"}]`,
+			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"},{"name":"last_activity","value":"2020-05-01T12:00:00Z"}]`,
 		},
 		{
 			description: "get supports rebasing",
@@ -83,7 +130,7 @@ func TestGet(t *testing.T) {
 			},
 			pullRequest:    createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
 			versionString:  `{"pr":"pr1","commit":"commit1","committed":"0001-01-01T00:00:00Z","approved_review_count":"0","state":"OPEN"}`,
-			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"}]`,
+			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"},{"name":"last_activity","value":"2020-05-01T12:00:00Z"}]`,
 		},
 		{
 			description: "get supports checkout",
@@ -103,7 +150,7 @@ func TestGet(t *testing.T) {
 			},
 			pullRequest:    createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
 			versionString:  `{"pr":"pr1","commit":"commit1","committed":"0001-01-01T00:00:00Z","approved_review_count":"0","state":"OPEN"}`,
-			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"}]`,
+			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"},{"name":"last_activity","value":"2020-05-01T12:00:00Z"}]`,
 		},
 		{
 			description: "get supports git_depth",
@@ -123,7 +170,7 @@ func TestGet(t *testing.T) {
 			},
 			pullRequest:    createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
 			versionString:  `{"pr":"pr1","commit":"commit1","committed":"0001-01-01T00:00:00Z","approved_review_count":"0","state":"OPEN"}`,
-			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"}]`,
+			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"},{"name":"last_activity","value":"2020-05-01T12:00:00Z"}]`,
 		},
 		{
 			description: "get supports list_changed_files",
@@ -151,14 +198,50 @@ func TestGet(t *testing.T) {
 				},
 			},
 			versionString:  `{"pr":"pr1","commit":"commit1","committed":"0001-01-01T00:00:00Z","approved_review_count":"0","state":"OPEN"}`,
-			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"}]`,
+			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"},{"name":"last_activity","value":"2020-05-01T12:00:00Z"}]`,
 			filesString:    "README.md\nOther.md\n",
 		},
+		{
+			description: "get lists the matched paths",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+				Paths:       []string{"src/*", "docs", "README.md"},
+				IgnorePaths: []string{"src/*.png"},
+			},
+			version: resource.Version{
+				PR:                  "pr1",
+				Commit:              "commit1",
+				CommittedDate:       time.Time{},
+				ApprovedReviewCount: "0",
+				State:               githubv4.PullRequestStateOpen,
+			},
+			parameters: resource.GetParameters{
+				ListChangedFiles: true,
+				ListMatchedPaths: true,
+			},
+			pullRequest: createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+			files: []resource.ChangedFileObject{
+				{
+					Path: "README.md",
+				},
+				{
+					Path: "docs/index.md",
+				},
+				{
+					Path: "src/logo.png",
+				},
+			},
+			versionString:  `{"pr":"pr1","commit":"commit1","committed":"0001-01-01T00:00:00Z","approved_review_count":"0","state":"OPEN"}`,
+			metadataString: `[{"name":"pr","value":"1"},{"name":"title","value":"pr1 title"},{"name":"url","value":"pr1 url"},{"name":"head_name","value":"pr1"},{"name":"head_sha","value":"oid1"},{"name":"base_name","value":"master"},{"name":"base_sha","value":"sha"},{"name":"message","value":"commit message1"},{"name":"author","value":"login1"},{"name":"author_email","value":"user@example.com"},{"name":"state","value":"OPEN"},{"name":"last_activity","value":"2020-05-01T12:00:00Z"},{"name":"matched_paths","value":"docs\nREADME.md"}]`,
+			filesString:    "README.md\ndocs/index.md\nsrc/logo.png\n",
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.description, func(t *testing.T) {
 			github := new(fakes.FakeGithub)
+			tc.pullRequest.UpdatedAt = githubv4.DateTime{Time: time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC)}
 			github.GetPullRequestReturns(tc.pullRequest, nil)
 
 			if tc.files != nil {
@@ -232,9 +315,20 @@ func TestGet(t *testing.T) {
 				assert.Equal(t, tc.parameters.FetchTags, fetchTags)
 			}
 
-			if assert.Equal(t, 1, git.RevParseCallCount()) {
-				base := git.RevParseArgsForCall(0)
-				assert.Equal(t, tc.pullRequest.BaseRefName, base)
+			if tc.version.BaseSHA != "" && tc.source.PinBaseSHA {
+				if assert.Equal(t, 1, git.ResetCallCount()) {
+					sha, depth := git.ResetArgsForCall(0)
+					assert.Equal(t, tc.version.BaseSHA, sha)
+					assert.Equal(t, tc.parameters.GitDepth, depth)
+				}
+			} else {
+				assert.Equal(t, 0, git.ResetCallCount())
+			}
+
+			// The base is resolved before, and the checkout after the integration.
+			if assert.Equal(t, 2, git.RevParseCallCount()) {
+				assert.Equal(t, tc.pullRequest.BaseRefName, git.RevParseArgsForCall(0))
+				assert.Equal(t, "HEAD", git.RevParseArgsForCall(1))
 			}
 
 			if assert.Equal(t, 1, git.FetchCallCount()) {
@@ -277,6 +371,690 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestGetMergeGroup(t *testing.T) {
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	version := resource.Version{
+		PR:         "1",
+		Commit:     "mergegroup1",
+		State:      githubv4.PullRequestStateOpen,
+		BaseSHA:    "basesha1",
+		MergeGroup: "gh-readonly-queue/master/pr-1-oid1",
+		HeadSHA:    "oid1",
+	}
+	input := resource.GetRequest{
+		Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken", MergeQueue: true, BaseBranch: "master"},
+		Version: version,
+		Params:  resource.GetParameters{GitDepth: 1},
+	}
+	_, err := resource.Get(input, github, git, dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The pull request is looked up by its head commit.
+	if assert.Equal(t, 1, github.GetPullRequestCallCount()) {
+		pr, commit := github.GetPullRequestArgsForCall(0)
+		assert.Equal(t, "1", pr)
+		assert.Equal(t, "oid1", commit)
+	}
+
+	// The merge group is checked out instead of merging the pull request.
+	assert.Equal(t, 0, git.FetchCallCount())
+	assert.Equal(t, 0, git.MergeCallCount())
+	if assert.Equal(t, 2, git.ResetCallCount()) {
+		sha, depth := git.ResetArgsForCall(1)
+		assert.Equal(t, "mergegroup1", sha)
+		assert.Equal(t, 1, depth)
+	}
+
+	assert.Equal(t, "gh-readonly-queue/master/pr-1-oid1", readTestFile(t, filepath.Join(dir, ".git", "resource", "merge_group")))
+	assert.Equal(t, "mergegroup1", readTestFile(t, filepath.Join(dir, ".git", "resource", "merge_group_sha")))
+}
+
+func TestGetFetchFails(t *testing.T) {
+	for _, depth := range []int{0, 1} {
+		t.Run(fmt.Sprintf("git_depth %d", depth), func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+			git.FetchReturns(errors.New("fetch failed"))
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			input := resource.GetRequest{
+				Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+				Version: resource.Version{PR: "1", Commit: "oid1"},
+				Params:  resource.GetParameters{GitDepth: depth},
+			}
+			_, err := resource.Get(input, github, git, dir)
+			assert.EqualError(t, err, "fetch failed")
+			assert.Equal(t, 1, git.PullCallCount())
+			assert.Equal(t, 1, git.FetchCallCount())
+			assert.Equal(t, 0, git.MergeCallCount())
+		})
+	}
+}
+
+func TestGetReferenceRepo(t *testing.T) {
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+	git.AddReferenceReturns(errors.New("reference repository '/mirror' does not exist"))
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	input := resource.GetRequest{
+		Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+		Version: resource.Version{PR: "1", Commit: "oid1"},
+		Params:  resource.GetParameters{ReferenceRepo: "/mirror"},
+	}
+	_, err := resource.Get(input, github, git, dir)
+	assert.NoError(t, err)
+
+	// A missing reference repository is not an error, and the objects borrowed
+	// from it are copied once the pull request has been merged.
+	if assert.Equal(t, 1, git.AddReferenceCallCount()) {
+		assert.Equal(t, "/mirror", git.AddReferenceArgsForCall(0))
+	}
+	assert.Equal(t, 1, git.MergeCallCount())
+	assert.Equal(t, 1, git.DissociateCallCount())
+}
+
+func TestGetMergeBase(t *testing.T) {
+	tests := []struct {
+		description  string
+		parameters   resource.GetParameters
+		mergeBaseErr error
+		expectedErr  string
+		mergeBase    string
+	}{
+		{
+			description: "merge base is added to metadata and checked out",
+			parameters:  resource.GetParameters{CheckoutBase: true},
+			mergeBase:   "mergebase",
+		},
+		{
+			description:  "merge base is skipped when the history is too shallow",
+			parameters:   resource.GetParameters{GitDepth: 1},
+			mergeBaseErr: errors.New("merge-base failed"),
+		},
+		{
+			description:  "merge base is not checked out when it cannot be found",
+			parameters:   resource.GetParameters{CheckoutBase: true},
+			mergeBaseErr: errors.New("merge-base failed"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+			git.MergeBaseReturns(tc.mergeBase, tc.mergeBaseErr)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			input := resource.GetRequest{
+				Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+				Version: resource.Version{PR: "1", Commit: "oid1"},
+				Params:  tc.parameters,
+			}
+			output, err := resource.Get(input, github, git, dir)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if assert.Equal(t, 1, git.MergeBaseCallCount()) {
+				base, head := git.MergeBaseArgsForCall(0)
+				assert.Equal(t, "sha", base)
+				assert.Equal(t, "oid1", head)
+			}
+			if tc.mergeBase == "" {
+				assert.Equal(t, 0, git.CheckoutTreeCallCount())
+				for _, m := range output.Metadata {
+					assert.NotEqual(t, "merge_base_sha", m.Name)
+				}
+				return
+			}
+			assert.Equal(t, tc.mergeBase, readTestFile(t, filepath.Join(dir, ".git", "resource", "merge_base_sha")))
+			if assert.Equal(t, 1, git.CheckoutTreeCallCount()) {
+				sha, path := git.CheckoutTreeArgsForCall(0)
+				assert.Equal(t, tc.mergeBase, sha)
+				assert.Equal(t, filepath.Join(dir, ".git", "resource", "trees", "base"), path)
+			}
+		})
+	}
+}
+
+func TestGetCheckoutBoth(t *testing.T) {
+	tests := []struct {
+		description string
+		parameters  resource.GetParameters
+		expectedErr string
+	}{
+		{
+			description: "head and merged trees are checked out",
+			parameters:  resource.GetParameters{CheckoutBoth: true},
+		},
+		{
+			description: "checkout_both requires a merge",
+			parameters:  resource.GetParameters{CheckoutBoth: true, IntegrationTool: "rebase"},
+			expectedErr: "checkout_both requires the merge integration tool, got: rebase",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			input := resource.GetRequest{
+				Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+				Version: resource.Version{PR: "1", Commit: "oid1"},
+				Params:  tc.parameters,
+			}
+			_, err := resource.Get(input, github, git, dir)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				assert.Equal(t, 0, git.InitCallCount())
+				return
+			}
+			assert.NoError(t, err)
+
+			// The trees are checked out after the merge.
+			assert.Equal(t, 1, git.MergeCallCount())
+			if assert.Equal(t, 2, git.CheckoutTreeCallCount()) {
+				sha, path := git.CheckoutTreeArgsForCall(0)
+				assert.Equal(t, "oid1", sha)
+				assert.Equal(t, filepath.Join(dir, ".git", "resource", "trees", "head"), path)
+				sha, path = git.CheckoutTreeArgsForCall(1)
+				assert.Equal(t, "HEAD", sha)
+				assert.Equal(t, filepath.Join(dir, ".git", "resource", "trees", "merged"), path)
+			}
+		})
+	}
+}
+
+func TestGetArchive(t *testing.T) {
+	tests := []struct {
+		description string
+		parameters  resource.GetParameters
+		files       map[string]string
+		expectedErr string
+	}{
+		{
+			description: "archive is extracted without the top-level directory",
+			parameters:  resource.GetParameters{Archive: true},
+			files: map[string]string{
+				"repo-oid1/README.md":   "readme",
+				"repo-oid1/src/main.go": "package main",
+			},
+		},
+		{
+			description: "archive cannot be merged",
+			parameters:  resource.GetParameters{Archive: true, IntegrationTool: "merge"},
+			expectedErr: "archive cannot be combined with integration_tool",
+		},
+		{
+			description: "archive paths must be inside the output directory",
+			parameters:  resource.GetParameters{Archive: true},
+			files:       map[string]string{"repo-oid1/../../escape": "escape"},
+			expectedErr: "failed to extract archive: invalid path in archive: repo-oid1/../../escape",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+			github.DownloadArchiveStub = func(commit string, w io.Writer) error {
+				gz := gzip.NewWriter(w)
+				archive := tar.NewWriter(gz)
+				for name, content := range tc.files {
+					archive.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+					archive.Write([]byte(content))
+				}
+				archive.Close()
+				return gz.Close()
+			}
+			git := new(fakes.FakeGit)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			input := resource.GetRequest{
+				Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+				Version: resource.Version{PR: "1", Commit: "oid1", BaseSHA: "base1"},
+				Params:  tc.parameters,
+			}
+			_, err := resource.Get(input, github, git, dir)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			if assert.Equal(t, 1, github.DownloadArchiveCallCount()) {
+				commit, _ := github.DownloadArchiveArgsForCall(0)
+				assert.Equal(t, "oid1", commit)
+			}
+			assert.Equal(t, "readme", readTestFile(t, filepath.Join(dir, "README.md")))
+			assert.Equal(t, "package main", readTestFile(t, filepath.Join(dir, "src", "main.go")))
+			assert.Equal(t, "base1", readTestFile(t, filepath.Join(dir, ".git", "resource", "base_sha")))
+
+			// Git is not used at all.
+			assert.Equal(t, 0, git.InitCallCount())
+			assert.Equal(t, 0, git.MergeCallCount())
+		})
+	}
+}
+
+func TestGetVerifySignatures(t *testing.T) {
+	tests := []struct {
+		description  string
+		parameters   resource.GetParameters
+		verification *resource.SignatureVerification
+		verifyErr    error
+		expectedErr  string
+	}{
+		{
+			description:  "signature verified by github",
+			parameters:   resource.GetParameters{VerifySignatures: "github"},
+			verification: &resource.SignatureVerification{Verified: true, Reason: "valid"},
+		},
+		{
+			description:  "unsigned commit fails with github",
+			parameters:   resource.GetParameters{VerifySignatures: "github"},
+			verification: &resource.SignatureVerification{Verified: false, Reason: "unsigned"},
+			expectedErr:  "signature of commit 'oid1' is not verified by github: unsigned",
+		},
+		{
+			description: "signature verified with gpg keys",
+			parameters:  resource.GetParameters{VerifySignatures: "gpg_keys", GPGKeys: []string{"key"}},
+		},
+		{
+			description: "invalid signature fails with gpg keys",
+			parameters:  resource.GetParameters{VerifySignatures: "gpg_keys", GPGKeys: []string{"key"}},
+			verifyErr:   errors.New("signature of commit 'oid1' is not valid"),
+			expectedErr: "signature of commit 'oid1' is not valid",
+		},
+		{
+			description: "gpg keys are required",
+			parameters:  resource.GetParameters{VerifySignatures: "gpg_keys"},
+			expectedErr: "verify_signatures with gpg_keys requires gpg_keys",
+		},
+		{
+			description: "invalid verification",
+			parameters:  resource.GetParameters{VerifySignatures: "ssh"},
+			expectedErr: "invalid verify_signatures specified: ssh",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+			github.GetSignatureVerificationReturns(tc.verification, nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+			git.VerifyCommitReturns(tc.verifyErr)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			input := resource.GetRequest{
+				Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+				Version: resource.Version{PR: "1", Commit: "oid1"},
+				Params:  tc.parameters,
+			}
+			_, err := resource.Get(input, github, git, dir)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			switch tc.parameters.VerifySignatures {
+			case "github":
+				if assert.Equal(t, 1, github.GetSignatureVerificationCallCount()) {
+					assert.Equal(t, "oid1", github.GetSignatureVerificationArgsForCall(0))
+				}
+			case "gpg_keys":
+				if tc.parameters.GPGKeys != nil && assert.Equal(t, 1, git.VerifyCommitCallCount()) {
+					sha, keys := git.VerifyCommitArgsForCall(0)
+					assert.Equal(t, "oid1", sha)
+					assert.Equal(t, tc.parameters.GPGKeys, keys)
+				}
+			}
+		})
+	}
+}
+
+func TestGetProvenance(t *testing.T) {
+	pull := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	pull.Author.Login = "contributor"
+
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(pull, nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseStub = func(ref string) (string, error) {
+		if ref == "HEAD" {
+			return "merged", nil
+		}
+		return "sha", nil
+	}
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	input := resource.GetRequest{
+		Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+		Version: resource.Version{PR: "1", Commit: "oid1"},
+		Params:  resource.GetParameters{GitDepth: 1},
+	}
+	_, err := resource.Get(input, github, git, dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var provenance resource.Provenance
+	b, err := ioutil.ReadFile(filepath.Join(dir, ".git", "resource", "provenance.json"))
+	if !assert.NoError(t, err) || !assert.NoError(t, json.Unmarshal(b, &provenance)) {
+		return
+	}
+	assert.Equal(t, "https://in-toto.io/Statement/v0.1", provenance.Type)
+	assert.Equal(t, "https://slsa.dev/provenance/v0.2", provenance.PredicateType)
+	// The subject is the merge of the pull request, which is what was checked out.
+	assert.Equal(t, []resource.ProvenanceArtifact{{Name: "pr1 url", Digest: map[string]string{"sha1": "merged"}}}, provenance.Subject)
+	assert.Equal(t, []resource.ProvenanceArtifact{
+		{URI: "git+repo1 url@refs/pull/1/head", Digest: map[string]string{"sha1": "oid1"}},
+		{URI: "git+repo1 url@refs/heads/master", Digest: map[string]string{"sha1": "sha"}},
+	}, provenance.Predicate.Materials)
+	assert.Equal(t, 1, provenance.Predicate.Invocation.Parameters.GitDepth)
+	assert.Equal(t, "contributor", provenance.Predicate.Invocation.Environment["author"])
+	assert.Equal(t, "sha", provenance.Predicate.Invocation.Environment["base_sha"])
+	assert.False(t, provenance.Predicate.Metadata.BuildStartedOn.IsZero())
+}
+
+func TestGetPipelineVars(t *testing.T) {
+	pull := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	pull.Author.Login = "contributor"
+
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(pull, nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	input := resource.GetRequest{
+		Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+		Version: resource.Version{PR: "1", Commit: "oid1"},
+	}
+	_, err := resource.Get(input, github, git, dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, ".git", "resource", "vars.json"))
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"number": 1, "branch": "pr1", "base_branch": "master", "author": "contributor"}`, string(b))
+	}
+}
+
+func TestGetHeadRepository(t *testing.T) {
+	tests := []struct {
+		description string
+		crossRepo   bool
+		expected    map[string]string
+	}{
+		{
+			description: "forks include the head repository",
+			crossRepo:   true,
+			expected: map[string]string{
+				"head_repository_clone_url":  "https://github.com/contributor/test-repository.git",
+				"head_repository_owner":      "contributor",
+				"head_repository_visibility": "private",
+				"maintainer_can_modify":      "true",
+			},
+		},
+		{
+			description: "pull requests from the repository itself do not",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			pull := createTestPR(1, "master", false, tc.crossRepo, 0, nil, false, githubv4.PullRequestStateOpen)
+			pull.HeadRepository.URL = "https://github.com/contributor/test-repository"
+			pull.HeadRepository.Owner.Login = "contributor"
+			pull.HeadRepository.IsPrivate = true
+			pull.MaintainerCanModify = true
+
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(pull, nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			input := resource.GetRequest{
+				Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+				Version: resource.Version{PR: "1", Commit: "oid1"},
+			}
+			output, err := resource.Get(input, github, git, dir)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			metadata := make(map[string]string)
+			for _, m := range output.Metadata {
+				if strings.HasPrefix(m.Name, "head_repository_") || m.Name == "maintainer_can_modify" {
+					metadata[m.Name] = m.Value
+				}
+			}
+			if tc.expected == nil {
+				assert.Empty(t, metadata)
+			} else {
+				assert.Equal(t, tc.expected, metadata)
+			}
+		})
+	}
+}
+
+func TestGetIssueKeys(t *testing.T) {
+	tests := []struct {
+		description string
+		regex       string
+		expected    string
+	}{
+		{
+			description: "issue keys are extracted from the title, branch and commits",
+			regex:       `[A-Z][A-Z0-9]+-[0-9]+`,
+			expected:    "ABC-1\nABC-2\nDEF-3",
+		},
+		{
+			description: "the first capture group is the issue key",
+			regex:       `#([0-9]+)`,
+			expected:    "42",
+		},
+		{
+			description: "no issue keys",
+			regex:       `XYZ-[0-9]+`,
+			expected:    "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			pull := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+			pull.Title = "ABC-1: Fix the thing"
+			pull.HeadRefName = "feature/ABC-2-fix"
+
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(pull, nil)
+			github.ListPullRequestCommitsReturns([]resource.PullRequestCommit{
+				{Message: "Fix ABC-1"},
+				{Message: "Refactor for DEF-3, see #42"},
+			}, nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			input := resource.GetRequest{
+				Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken", IssueKeyRegex: tc.regex},
+				Version: resource.Version{PR: "1", Commit: "oid1"},
+			}
+			output, err := resource.Get(input, github, git, dir)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, "1", github.ListPullRequestCommitsArgsForCall(0))
+			assert.Equal(t, tc.expected, readTestFile(t, filepath.Join(dir, ".git", "resource", "issue_keys")))
+			assert.Contains(t, output.Metadata, &resource.MetadataField{Name: "issue_keys", Value: tc.expected})
+		})
+	}
+}
+
+func TestGetTriggerConfig(t *testing.T) {
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+	github.GetFileContentsReturns([]byte("paths:\n  - src/*\n"), nil)
+	github.GetChangedFilesReturns(changedFiles("src/main.go", "docs/index.md"), nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	input := resource.GetRequest{
+		Source: resource.Source{
+			Repository:    "itsdalmo/test-repository",
+			AccessToken:   "oauthtoken",
+			Paths:         []string{"docs/*"},
+			TriggerConfig: ".concourse/trigger.yml",
+		},
+		Version: resource.Version{PR: "1", Commit: "oid1"},
+		Params:  resource.GetParameters{ListMatchedPaths: true},
+	}
+	output, err := resource.Get(input, github, git, dir)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// The paths of the trigger configuration on the base branch replace those of the source.
+	path, ref := github.GetFileContentsArgsForCall(0)
+	assert.Equal(t, ".concourse/trigger.yml", path)
+	assert.Equal(t, "master", ref)
+	assert.Contains(t, output.Metadata, &resource.MetadataField{Name: "matched_paths", Value: "src/*"})
+	assert.Equal(t, "src/*", readTestFile(t, filepath.Join(dir, ".git", "resource", "matched_paths")))
+
+	// Without list_matched_paths, neither the configuration nor the changed files are fetched.
+	github = new(fakes.FakeGithub)
+	github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+	input.Params = resource.GetParameters{}
+	dir = createTestDirectory(t)
+	defer os.RemoveAll(dir)
+	_, err = resource.Get(input, github, git, dir)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, github.GetFileContentsCallCount())
+		assert.Equal(t, 0, github.GetChangedFilesCallCount())
+	}
+}
+
+func TestGetForkAccessToken(t *testing.T) {
+	pull := createTestPR(1, "master", false, true, 0, nil, false, githubv4.PullRequestStateOpen)
+	pull.HeadRepository.URL = "https://github.com/contributor/test-repository"
+
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(pull, nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	input := resource.GetRequest{
+		Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken", ForkAccessToken: "forktoken"},
+		Version: resource.Version{PR: "1", Commit: "oid1"},
+		Params:  resource.GetParameters{GitDepth: 2},
+	}
+	_, err := resource.Get(input, github, git, dir)
+	assert.NoError(t, err)
+
+	// The head is fetched from the fork instead of the pull request ref of the base repository.
+	assert.Equal(t, 0, git.FetchCallCount())
+	if assert.Equal(t, 1, git.FetchForkCallCount()) {
+		url, branch, depth, submodules := git.FetchForkArgsForCall(0)
+		assert.Equal(t, "https://github.com/contributor/test-repository", url)
+		assert.Equal(t, pull.HeadRefName, branch)
+		assert.Equal(t, 2, depth)
+		assert.False(t, submodules)
+	}
+	assert.Equal(t, 1, git.MergeCallCount())
+}
+
+func TestGetExportBundle(t *testing.T) {
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	input := resource.GetRequest{
+		Source:  resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"},
+		Version: resource.Version{PR: "1", Commit: "oid1"},
+		Params:  resource.GetParameters{ExportBundle: true},
+	}
+	_, err := resource.Get(input, github, git, dir)
+	assert.NoError(t, err)
+
+	if assert.Equal(t, 1, git.BundleCallCount()) {
+		assert.Equal(t, filepath.Join(dir, ".git", "resource", "repo.bundle"), git.BundleArgsForCall(0))
+	}
+}
+
 func TestGetSkipDownload(t *testing.T) {
 
 	tests := []struct {
@@ -356,11 +1134,13 @@ func createTestPR(
 			Repository: struct{ URL string }{
 				URL: fmt.Sprintf("repo%s url", n),
 			},
+			HeadRepository:    &resource.HeadRepositoryObject{},
 			IsCrossRepository: isCrossRepo,
 			IsDraft:           isDraft,
 			State:             state,
 			ClosedAt:          githubv4.DateTime{Time: time.Now()},
 			MergedAt:          githubv4.DateTime{Time: time.Now()},
+			UpdatedAt:         githubv4.DateTime{Time: d},
 		},
 		Tip: resource.CommitObject{
 			ID:            fmt.Sprintf("commit%s", n),
@@ -0,0 +1,104 @@
+package resource
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// IntervalSuggestion is a recommended check_every interval for a repository, together
+// with the figures it is based on.
+type IntervalSuggestion struct {
+	PullRequests       int        `json:"pull_requests"`
+	LastActivity       *time.Time `json:"last_activity,omitempty"`
+	CostPerCheck       int        `json:"cost_per_check"`
+	RateLimit          int        `json:"rate_limit"`
+	RateLimitRemaining int        `json:"rate_limit_remaining"`
+	RateLimitResetAt   time.Time  `json:"rate_limit_reset_at"`
+	Interval           string     `json:"interval"`
+}
+
+// checkRateLimitShare is the share of the hourly rate limit that checks are suggested to
+// use, leaving the rest for get, put and anything else using the access token.
+const checkRateLimitShare = 0.2
+
+// activityIntervals suggest shorter intervals for repositories with recent activity, as
+// new commits are likely to follow. The last interval applies to inactive repositories.
+var activityIntervals = []struct {
+	since    time.Duration
+	interval time.Duration
+}{
+	{time.Hour, time.Minute},
+	{24 * time.Hour, 5 * time.Minute},
+	{7 * 24 * time.Hour, 15 * time.Minute},
+	{0, time.Hour},
+}
+
+// lastActivity returns the most recent update of the pull requests, if any.
+func lastActivity(source Source, pulls []*PullRequest) time.Time {
+	var last time.Time
+	for _, p := range pulls {
+		if d := updatedDate(source, p).Time; d.After(last) {
+			last = d
+		}
+	}
+	return last
+}
+
+// SuggestInterval suggests a check_every interval based on the activity of the pull
+// requests matching the source, and the share of the rate limit that checks would use.
+func SuggestInterval(request CheckRequest, manager Github, now time.Time) (*IntervalSuggestion, error) {
+	states := []githubv4.PullRequestState{githubv4.PullRequestStateOpen}
+	if len(request.Source.States) > 0 {
+		states = request.Source.States
+	}
+	pulls, err := manager.ListPullRequests(states, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last commits: %w", err)
+	}
+	rl, err := manager.GetRateLimit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate limit: %w", err)
+	}
+
+	s := &IntervalSuggestion{
+		PullRequests:       len(pulls),
+		RateLimit:          rl.Limit,
+		RateLimitRemaining: rl.Remaining,
+		RateLimitResetAt:   rl.ResetAt.Time,
+	}
+	if last := lastActivity(request.Source, pulls); !last.IsZero() {
+		s.LastActivity = &last
+	}
+
+	// A check costs a request per page of pull requests, and one per pull request when
+	// the modified files are listed separately (in the worst case).
+	pageSize := request.Source.PageSize
+	if pageSize == 0 {
+		pageSize = 100
+	}
+	s.CostPerCheck = (len(pulls) + pageSize - 1) / pageSize
+	if s.CostPerCheck == 0 {
+		s.CostPerCheck = 1
+	}
+	if len(request.Source.Paths) > 0 || len(request.Source.IgnorePaths) > 0 || len(request.Source.RequirePaths) > 0 || len(request.Source.Components) > 0 || request.Source.TriggerConfig != "" {
+		s.CostPerCheck += len(pulls)
+	}
+
+	var interval time.Duration
+	for _, a := range activityIntervals {
+		interval = a.interval
+		if s.LastActivity != nil && now.Sub(*s.LastActivity) < a.since {
+			break
+		}
+	}
+	if rl.Limit > 0 {
+		checksPerHour := float64(rl.Limit) * checkRateLimitShare / float64(s.CostPerCheck)
+		if min := time.Duration(float64(time.Hour) / checksPerHour).Round(time.Second); min > interval {
+			interval = min
+		}
+	}
+	s.Interval = interval.String()
+	return s, nil
+}
@@ -0,0 +1,74 @@
+package resource_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resource "github.com/telia-oss/github-pr-resource"
+	"github.com/telia-oss/github-pr-resource/fakes"
+)
+
+func TestSuggestInterval(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	pulls := func(count int, updated time.Time) []*resource.PullRequest {
+		var pulls []*resource.PullRequest
+		for i := 1; i <= count; i++ {
+			p := createTestPR(i, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+			p.Tip.CommittedDate = githubv4.DateTime{Time: updated}
+			pulls = append(pulls, p)
+		}
+		return pulls
+	}
+
+	tests := []struct {
+		description string
+		source      resource.Source
+		pulls       []*resource.PullRequest
+		cost        int
+		expected    string
+	}{
+		{
+			description: "recent activity suggests a short interval",
+			pulls:       pulls(10, now.Add(-10*time.Minute)),
+			cost:        1,
+			expected:    "1m0s",
+		},
+		{
+			description: "older activity suggests a longer interval",
+			pulls:       pulls(10, now.Add(-48*time.Hour)),
+			cost:        1,
+			expected:    "15m0s",
+		},
+		{
+			description: "no pull requests suggest an hourly interval",
+			cost:        1,
+			expected:    "1h0m0s",
+		},
+		{
+			description: "interval is limited by the rate limit",
+			source:      resource.Source{Paths: []string{"src/*"}},
+			pulls:       pulls(300, now.Add(-10*time.Minute)),
+			cost:        303,
+			expected:    "18m11s",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.ListPullRequestsReturns(tc.pulls, nil)
+			github.GetRateLimitReturns(&resource.RateLimit{Limit: 5000, Remaining: 4000, ResetAt: githubv4.DateTime{Time: now.Add(time.Hour)}}, nil)
+
+			suggestion, err := resource.SuggestInterval(resource.CheckRequest{Source: tc.source}, github, now)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, suggestion.Interval)
+			assert.Equal(t, tc.cost, suggestion.CostPerCheck)
+			assert.Equal(t, len(tc.pulls), suggestion.PullRequests)
+			assert.Equal(t, 4000, suggestion.RateLimitRemaining)
+			assert.Equal(t, now.Add(time.Hour), suggestion.RateLimitResetAt)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package resource
+
+import "regexp"
+
+// IssueKeys returns the unique matches of the regular expression in the texts (e.g. the
+// title, branch and commit messages of a pull request), in the order they appear. If the
+// expression has a capture group, the first group is the issue key.
+func IssueKeys(re *regexp.Regexp, texts ...string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		for _, match := range re.FindAllStringSubmatch(text, -1) {
+			key := match[0]
+			if len(match) > 1 {
+				key = match[1]
+			}
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,177 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel for the Logger.
+type LogLevel int
+
+// Supported log levels.
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// ParseLogLevel returns the LogLevel with the given name.
+func ParseLogLevel(s string) (LogLevel, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level: %s", s)
+}
+
+// Logger writes leveled and structured log lines in either text or JSON format.
+type Logger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	level  LogLevel
+	json   bool
+	secret []string
+}
+
+// NewLogger ...
+func NewLogger(out io.Writer, level LogLevel, format string) *Logger {
+	return &Logger{
+		out:   out,
+		level: level,
+		json:  format == "json",
+	}
+}
+
+// NewLoggerFromSource creates a Logger writing to stderr as configured in the source.
+func NewLoggerFromSource(s *Source) *Logger {
+	level, _ := ParseLogLevel(s.LogLevel)
+	if s.Debug {
+		level = LevelDebug
+	}
+	l := NewLogger(os.Stderr, level, s.LogFormat)
+	l.Redact(s.AccessToken)
+	l.Redact(s.ForkAccessToken)
+	return l
+}
+
+var logger = NewLogger(os.Stderr, LevelInfo, "text")
+
+// SetLogger sets the logger used by the resource.
+func SetLogger(l *Logger) {
+	logger = l
+}
+
+// Redact a secret from all subsequent log lines.
+func (l *Logger) Redact(secret string) {
+	if secret == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.secret = append(l.secret, secret)
+}
+
+// Enabled returns true if messages at the given level are logged.
+func (l *Logger) Enabled(level LogLevel) bool {
+	return level >= l.level
+}
+
+// Debug logs a message with optional key/value pairs.
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.log(LevelDebug, msg, keyvals...)
+}
+
+// Info logs a message with optional key/value pairs.
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.log(LevelInfo, msg, keyvals...)
+}
+
+// Warn logs a message with optional key/value pairs.
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.log(LevelWarn, msg, keyvals...)
+}
+
+// Error logs a message with optional key/value pairs.
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.log(LevelError, msg, keyvals...)
+}
+
+func (l *Logger) log(level LogLevel, msg string, keyvals ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	if len(keyvals)%2 != 0 {
+		keyvals = append(keyvals, "")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	var line string
+	if l.json {
+		fields := map[string]interface{}{
+			"time":  now,
+			"level": level.String(),
+			"msg":   msg,
+		}
+		for i := 0; i < len(keyvals); i += 2 {
+			v := keyvals[i+1]
+			switch t := v.(type) {
+			case error:
+				v = t.Error()
+			case time.Duration:
+				v = t.String()
+			}
+			fields[fmt.Sprint(keyvals[i])] = v
+		}
+		b, err := json.Marshal(fields)
+		if err != nil {
+			return
+		}
+		line = string(b)
+	} else {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s %-5s %s", now, level, msg)
+		for i := 0; i < len(keyvals); i += 2 {
+			v := fmt.Sprint(keyvals[i+1])
+			if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+				v = strconv.Quote(v)
+			}
+			fmt.Fprintf(&b, " %v=%s", keyvals[i], v)
+		}
+		line = b.String()
+	}
+
+	for _, s := range l.secret {
+		line = strings.Replace(line, s, "[redacted]", -1)
+	}
+	fmt.Fprintln(l.out, line)
+}
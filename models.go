@@ -1,9 +1,17 @@
 package resource
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/shurcooL/githubv4"
@@ -11,29 +19,123 @@ import (
 
 // Source represents the configuration for the resource.
 type Source struct {
-	Repository              string                      `json:"repository"`
-	AccessToken             string                      `json:"access_token"`
-	V3Endpoint              string                      `json:"v3_endpoint"`
-	V4Endpoint              string                      `json:"v4_endpoint"`
-	Paths                   []string                    `json:"paths"`
-	IgnorePaths             []string                    `json:"ignore_paths"`
-	DisableCISkip           bool                        `json:"disable_ci_skip"`
-	DisableGitLFS           bool                        `json:"disable_git_lfs"`
-	SkipSSLVerification     bool                        `json:"skip_ssl_verification"`
-	DisableForks            bool                        `json:"disable_forks"`
-	IgnoreDrafts            bool                        `json:"ignore_drafts"`
-	GitCryptKey             string                      `json:"git_crypt_key"`
-	BaseBranch              string                      `json:"base_branch"`
-	RequiredReviewApprovals int                         `json:"required_review_approvals"`
-	Labels                  []string                    `json:"labels"`
-	States                  []githubv4.PullRequestState `json:"states"`
+	Repository                 string                      `json:"repository"`
+	Number                     int                         `json:"number"`
+	AccessToken                string                      `json:"access_token"`
+	AccessTokenFile            string                      `json:"access_token_file"`
+	AccessTokenCmd             string                      `json:"access_token_cmd"`
+	AccessTokens               []string                    `json:"access_tokens"`
+	ForkAccessToken            string                      `json:"fork_access_token"`
+	UseEnvToken                bool                        `json:"use_env_token"`
+	V3Endpoint                 string                      `json:"v3_endpoint"`
+	V4Endpoint                 string                      `json:"v4_endpoint"`
+	V3AcceptHeaders            []string                    `json:"v3_accept_headers"`
+	Paths                      []string                    `json:"paths"`
+	IgnorePaths                []string                    `json:"ignore_paths"`
+	RequirePaths               []string                    `json:"require_paths"`
+	Components                 map[string][]string         `json:"components"`
+	PathsChangeType            []string                    `json:"paths_changetype"`
+	TriggerConfig              string                      `json:"trigger_config"`
+	DisableCISkip              bool                        `json:"disable_ci_skip"`
+	DisableGitLFS              bool                        `json:"disable_git_lfs"`
+	SkipSSLVerification        bool                        `json:"skip_ssl_verification"`
+	DisableForks               bool                        `json:"disable_forks"`
+	ForkAuthorsAllow           []string                    `json:"fork_authors_allow"`
+	ForkAuthorsDeny            []string                    `json:"fork_authors_deny"`
+	SkipUnavailableForks       bool                        `json:"skip_unavailable_forks"`
+	IgnoreDrafts               bool                        `json:"ignore_drafts"`
+	OnlyDrafts                 bool                        `json:"only_drafts"`
+	TriggerOnReady             bool                        `json:"trigger_on_ready"`
+	TriggerPhrase              string                      `json:"trigger_phrase"`
+	TriggerPhraseUsers         []string                    `json:"trigger_phrase_users"`
+	GitCryptKey                string                      `json:"git_crypt_key"`
+	BaseBranch                 string                      `json:"base_branch"`
+	IgnoreBaseBranches         []string                    `json:"ignore_base_branches"`
+	ContextNamespace           string                      `json:"context_namespace"`
+	MergeQueue                 bool                        `json:"merge_queue"`
+	RequiredReviewApprovals    ReviewApprovals             `json:"required_review_approvals"`
+	ExcludeAuthorTeamApprovals bool                        `json:"exclude_author_team_approvals"`
+	MinChangedLines            int                         `json:"min_changed_lines"`
+	MaxChangedLines            int                         `json:"max_changed_lines"`
+	TrackReviewApprovals       bool                        `json:"track_review_approvals"`
+	PinBaseSHA                 bool                        `json:"pin_base_sha"`
+	DetectForcePushes          bool                        `json:"detect_force_pushes"`
+	VersionKey                 []string                    `json:"version_key"`
+	LatestPerPR                *bool                       `json:"latest_per_pr"`
+	AllNewVersions             bool                        `json:"all_new_versions"`
+	IssueKeyRegex              string                      `json:"issue_key_regex"`
+	Labels                     []string                    `json:"labels"`
+	States                     []githubv4.PullRequestState `json:"states"`
+	StateLookback              Duration                    `json:"state_lookback"`
+	InitialLookback            Duration                    `json:"initial_lookback"`
+	SkipBackfill               bool                        `json:"skip_backfill"`
+	SearchQueryExtra           string                      `json:"search_query_extra"`
+	PageSize                   int                         `json:"page_size"`
+	MaxPRs                     int                         `json:"max_prs"`
+	PagesPerCheck              int                         `json:"pages_per_check"`
+	APIBudgetPerCheck          int                         `json:"api_budget_per_check"`
+	LogLevel                   string                      `json:"log_level"`
+	LogFormat                  string                      `json:"log_format"`
+	Debug                      bool                        `json:"debug"`
+	APITimeout                 Duration                    `json:"api_timeout"`
+	GitTimeout                 Duration                    `json:"git_timeout"`
+	GitTrace                   bool                        `json:"git_trace"`
+	GitArgs                    []string                    `json:"git_args"`
+	CacheDir                   string                      `json:"cache_dir"`
+	QueryCacheTTL              Duration                    `json:"query_cache_ttl"`
+	QueryCacheDir              string                      `json:"query_cache_dir"`
+	Concurrency                int                         `json:"concurrency"`
+	OTLPEndpoint               string                      `json:"otlp_endpoint"`
+	OTLPHeaders                map[string]string           `json:"otlp_headers"`
+	Lenient                    bool                        `json:"lenient"`
+	VerifyPermissions          bool                        `json:"verify_permissions"`
+}
+
+// DecodeRequest decodes a check, get or put request. Unknown fields (e.g. typos
+// in the configuration) are an error unless lenient is set in the source.
+func DecodeRequest(r io.Reader, request interface{}) error {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var lenient struct {
+		Source struct {
+			Lenient bool `json:"lenient"`
+		} `json:"source"`
+	}
+	// Errors are reported when decoding the request itself.
+	json.Unmarshal(content, &lenient)
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if !lenient.Source.Lenient {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder.Decode(request)
 }
 
 // Validate the source configuration.
 func (s *Source) Validate() error {
-	if s.AccessToken == "" {
+	var tokens int
+	for _, t := range []string{s.AccessToken, s.AccessTokenFile, s.AccessTokenCmd} {
+		if t != "" {
+			tokens++
+		}
+	}
+	if s.UseEnvToken {
+		tokens++
+	}
+	if tokens == 0 {
 		return errors.New("access_token must be set")
 	}
+	if tokens > 1 {
+		return errors.New("only one of access_token, access_token_file, access_token_cmd and use_env_token can be set")
+	}
+	for _, t := range s.AccessTokens {
+		if t == "" {
+			return errors.New("access_tokens cannot contain empty tokens")
+		}
+	}
 	if s.Repository == "" {
 		return errors.New("repository must be set")
 	}
@@ -43,6 +145,109 @@ func (s *Source) Validate() error {
 	if s.V4Endpoint != "" && s.V3Endpoint == "" {
 		return errors.New("v3_endpoint must be set together with v4_endpoint")
 	}
+	for _, t := range s.PathsChangeType {
+		switch t {
+		case "ADDED", "DELETED", "MODIFIED", "RENAMED", "COPIED", "CHANGED":
+		default:
+			return fmt.Errorf("paths_changetype value \"%s\" must be one of: ADDED, DELETED, MODIFIED, RENAMED, COPIED, CHANGED", t)
+		}
+	}
+	if s.IgnoreDrafts && s.OnlyDrafts {
+		return errors.New("only one of ignore_drafts and only_drafts can be set")
+	}
+	if s.PageSize < 0 || s.PageSize > 100 {
+		return errors.New("page_size must be between 1 and 100")
+	}
+	if s.MaxPRs < 0 {
+		return errors.New("max_prs cannot be negative")
+	}
+	if s.Number < 0 {
+		return errors.New("number cannot be negative")
+	}
+	if s.Number > 0 && (s.PagesPerCheck > 0 || s.SearchQueryExtra != "" || s.StateLookback > 0) {
+		return errors.New("number cannot be combined with pages_per_check, search_query_extra or state_lookback")
+	}
+	if s.PagesPerCheck < 0 {
+		return errors.New("pages_per_check cannot be negative")
+	}
+	if s.PagesPerCheck > 0 {
+		if s.CacheDir == "" {
+			return errors.New("cache_dir must be set together with pages_per_check")
+		}
+		if s.MaxPRs > 0 || s.SearchQueryExtra != "" || s.StateLookback > 0 {
+			return errors.New("pages_per_check cannot be combined with max_prs, search_query_extra or state_lookback")
+		}
+	}
+	if s.APIBudgetPerCheck < 0 {
+		return errors.New("api_budget_per_check cannot be negative")
+	}
+	if s.APIBudgetPerCheck > 0 && s.CacheDir == "" {
+		return errors.New("cache_dir must be set together with api_budget_per_check")
+	}
+	if s.MinChangedLines < 0 || s.MaxChangedLines < 0 {
+		return errors.New("min_changed_lines and max_changed_lines cannot be negative")
+	}
+	if s.MaxChangedLines > 0 && s.MinChangedLines > s.MaxChangedLines {
+		return errors.New("min_changed_lines cannot be greater than max_changed_lines")
+	}
+	if s.RequiredReviewApprovals.Count < 0 {
+		return errors.New("required_review_approvals cannot be negative")
+	}
+	for team, count := range s.RequiredReviewApprovals.Teams {
+		if count < 0 {
+			return fmt.Errorf("required_review_approvals for team %s cannot be negative", team)
+		}
+	}
+	for _, k := range s.VersionKey {
+		switch k {
+		case "commit", "approvals", "labels", "base_sha":
+		default:
+			return fmt.Errorf("version_key value \"%s\" must be one of: commit, approvals, labels, base_sha", k)
+		}
+	}
+	if s.TrackReviewApprovals && len(s.VersionKey) > 0 && !containsString(s.VersionKey, "approvals") {
+		return errors.New("track_review_approvals conflicts with a version_key without approvals")
+	}
+	if s.PinBaseSHA && len(s.VersionKey) > 0 && !containsString(s.VersionKey, "base_sha") {
+		return errors.New("pin_base_sha conflicts with a version_key without base_sha")
+	}
+	if len(s.TriggerPhraseUsers) > 0 && s.TriggerPhrase == "" {
+		return errors.New("trigger_phrase must be set together with trigger_phrase_users")
+	}
+	for _, pattern := range s.IgnoreBaseBranches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("ignore_base_branches pattern '%s' is invalid: %s", pattern, err)
+		}
+	}
+	if err := validateComponents(s.Components); err != nil {
+		return err
+	}
+	if _, err := regexp.Compile(s.IssueKeyRegex); err != nil {
+		return fmt.Errorf("issue_key_regex is invalid: %s", err)
+	}
+	if s.InitialLookback < 0 {
+		return errors.New("initial_lookback cannot be negative")
+	}
+	if s.SkipBackfill && s.InitialLookback == 0 {
+		return errors.New("skip_backfill requires initial_lookback")
+	}
+	if s.AllNewVersions && s.LatestPerPR != nil && *s.LatestPerPR {
+		return errors.New("latest_per_pr and all_new_versions cannot both be set")
+	}
+	if s.MergeQueue && s.BaseBranch == "" {
+		return errors.New("base_branch must be set together with merge_queue")
+	}
+	if s.Concurrency < 0 {
+		return errors.New("concurrency cannot be negative")
+	}
+	if _, err := ParseLogLevel(s.LogLevel); err != nil {
+		return err
+	}
+	switch s.LogFormat {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("log_format value \"%s\" must be one of: text, json", s.LogFormat)
+	}
 	for _, state := range s.States {
 		switch state {
 		case githubv4.PullRequestStateOpen:
@@ -55,6 +260,92 @@ func (s *Source) Validate() error {
 	return nil
 }
 
+// ReviewApprovals is the number of approved reviews required for a pull request, which
+// is configured either as a number, or as the number of approvals required from the
+// members of each team, e.g. {"platform-team": 1, "security": 1}.
+type ReviewApprovals struct {
+	Count int
+	Teams map[string]int
+}
+
+// UnmarshalJSON parses a number or a map of team to number.
+func (r *ReviewApprovals) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &r.Count); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(b, &r.Teams); err != nil {
+		return errors.New("required_review_approvals must be a number, or a map of team to number")
+	}
+	return nil
+}
+
+// MarshalJSON formats the approvals in the same way as they are configured.
+func (r ReviewApprovals) MarshalJSON() ([]byte, error) {
+	if len(r.Teams) > 0 {
+		return json.Marshal(r.Teams)
+	}
+	return json.Marshal(r.Count)
+}
+
+// Duration is a time.Duration which is configured as a string, e.g. "30s" or "5m".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %s", err)
+	}
+	if s == "" {
+		*d = 0
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if v < 0 {
+		return fmt.Errorf("duration cannot be negative: %s", s)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON formats the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// ResolveAccessToken reads the access token from access_token_file, the output of
+// access_token_cmd, or the GITHUB_TOKEN environment variable (use_env_token).
+func (s *Source) ResolveAccessToken() error {
+	switch {
+	case s.AccessTokenFile != "":
+		content, err := ioutil.ReadFile(s.AccessTokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read access token file: %s", err)
+		}
+		s.AccessToken = strings.TrimSpace(string(content))
+	case s.AccessTokenCmd != "":
+		var stderr bytes.Buffer
+		cmd := shellCommand(s.AccessTokenCmd)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("access token command failed: %s: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		s.AccessToken = strings.TrimSpace(string(out))
+	case s.UseEnvToken:
+		s.AccessToken = os.Getenv("GITHUB_TOKEN")
+	default:
+		return nil
+	}
+	if s.AccessToken == "" {
+		return errors.New("resolved access token is empty")
+	}
+	return nil
+}
+
 // Metadata output from get/put steps.
 type Metadata []*MetadataField
 
@@ -63,38 +354,123 @@ func (m *Metadata) Add(name, value string) {
 	*m = append(*m, &MetadataField{Name: name, Value: value})
 }
 
+// Set the value of a MetadataField, adding it to the Metadata if it does not exist.
+func (m *Metadata) Set(name, value string) {
+	for _, f := range *m {
+		if f.Name == name {
+			f.Value = value
+			return
+		}
+	}
+	m.Add(name, value)
+}
+
 // MetadataField ...
 type MetadataField struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
 }
 
+// PipelineVars identify a pull request in vars.json, so that a pipeline can be instantiated
+// for each pull request by set_pipeline, e.g. with the variables as instance_vars. They do
+// not change when commits are pushed, which would instantiate another pipeline.
+type PipelineVars struct {
+	Number     int    `json:"number"`
+	Branch     string `json:"branch"`
+	BaseBranch string `json:"base_branch"`
+	Author     string `json:"author"`
+}
+
+// NewPipelineVars constructs the pipeline variables of a pull request.
+func NewPipelineVars(p *PullRequest) PipelineVars {
+	return PipelineVars{
+		Number:     p.Number,
+		Branch:     p.HeadRefName,
+		BaseBranch: p.BaseRefName,
+		Author:     p.Author.Login,
+	}
+}
+
 // Version communicated with Concourse.
 type Version struct {
 	PR                  string                    `json:"pr"`
 	Commit              string                    `json:"commit"`
 	CommittedDate       time.Time                 `json:"committed,omitempty"`
-	ApprovedReviewCount string                    `json:"approved_review_count"`
+	ApprovedReviewCount string                    `json:"approved_review_count,omitempty"`
 	State               githubv4.PullRequestState `json:"state"`
+	ForcePushed         string                    `json:"force_pushed,omitempty"`
+	BaseSHA             string                    `json:"base_sha,omitempty"`
+	MergeGroup          string                    `json:"merge_group,omitempty"`
+	HeadSHA             string                    `json:"head_sha,omitempty"`
+	Comment             string                    `json:"comment,omitempty"`
+	Labels              string                    `json:"labels,omitempty"`
+	Component           string                    `json:"component,omitempty"`
 }
 
 // NewVersion constructs a new Version.
 func NewVersion(p *PullRequest) Version {
 	return Version{
-		PR:                  strconv.Itoa(p.Number),
-		Commit:              p.Tip.OID,
-		CommittedDate:       p.UpdatedDate().Time,
-		ApprovedReviewCount: strconv.Itoa(p.ApprovedReviewCount),
-		State:               p.State,
+		PR:            strconv.Itoa(p.Number),
+		Commit:        p.Tip.OID,
+		CommittedDate: p.UpdatedDate().Time,
+		State:         p.State,
+	}
+}
+
+// Matches returns true if the version is the same as the previous version, where
+// fields which are not set in the previous version (e.g. because it was stored by an
+// older release of the resource) match any value.
+func (v Version) Matches(previous Version) bool {
+	if v.PR != previous.PR || v.Commit != previous.Commit {
+		return false
+	}
+	if previous.State != "" && v.State != previous.State {
+		return false
+	}
+	if !previous.CommittedDate.IsZero() && !v.CommittedDate.Equal(previous.CommittedDate) {
+		return false
 	}
+	optional := [][2]string{
+		{v.ApprovedReviewCount, previous.ApprovedReviewCount},
+		{v.ForcePushed, previous.ForcePushed},
+		{v.BaseSHA, previous.BaseSHA},
+		{v.MergeGroup, previous.MergeGroup},
+		{v.HeadSHA, previous.HeadSHA},
+		{v.Comment, previous.Comment},
+		{v.Labels, previous.Labels},
+		{v.Component, previous.Component},
+	}
+	for _, f := range optional {
+		if f[1] != "" && f[0] != f[1] {
+			return false
+		}
+	}
+	return true
 }
 
 // PullRequest represents a pull request and includes the tip (commit).
+// Files holds the modified files if they were listed together with the pull
+// request, and FilesComplete is set if there were no more files to list.
+// ForcePushed is set if the tip was introduced by a force-push, and ReopenedAt
+// and ReadyAt are when the pull request was last reopened or marked as ready, and
+// LabeledAt and ApprovedAt when its labels and approvals last changed (if listed).
+// MergeStateStatus and ReviewDecision are only set when getting a single pull request.
 type PullRequest struct {
 	PullRequestObject
 	Tip                 CommitObject
 	ApprovedReviewCount int
 	Labels              []LabelObject
+	Files               []ChangedFileObject
+	FilesComplete       bool
+	ForcePushed         bool
+	ReopenedAt          githubv4.DateTime
+	ReadyAt             githubv4.DateTime
+	LabeledAt           githubv4.DateTime
+	ApprovedAt          githubv4.DateTime
+	ApprovedBy          []string
+	Comments            []CommentObject
+	MergeStateStatus    string
+	ReviewDecision      string
 }
 
 // PullRequestObject represents the GraphQL commit node.
@@ -105,22 +481,35 @@ type PullRequestObject struct {
 	Title       string
 	URL         string
 	BaseRefName string
+	BaseRefOID  string `graphql:"baseRefOid"`
 	HeadRefName string
 	Repository  struct {
 		URL string
 	}
-	IsCrossRepository bool
-	IsDraft           bool
-	State             githubv4.PullRequestState
-	ClosedAt          githubv4.DateTime
-	MergedAt          githubv4.DateTime
+	Author struct {
+		Login string
+	}
+	HeadRepository      *HeadRepositoryObject
+	IsCrossRepository   bool
+	MaintainerCanModify bool
+	IsDraft             bool
+	State               githubv4.PullRequestState
+	ClosedAt            githubv4.DateTime
+	MergedAt            githubv4.DateTime
+	UpdatedAt           githubv4.DateTime
+	Additions           int
+	Deletions           int
 }
 
 // UpdatedDate returns the last time a PR was updated, either by commit
-// or being closed/merged.
+// or being closed/merged/reopened.
 func (p *PullRequest) UpdatedDate() githubv4.DateTime {
 	date := p.Tip.CommittedDate
 	switch p.State {
+	case githubv4.PullRequestStateOpen:
+		if p.ReopenedAt.After(date.Time) {
+			date = p.ReopenedAt
+		}
 	case githubv4.PullRequestStateClosed:
 		date = p.ClosedAt
 	case githubv4.PullRequestStateMerged:
@@ -129,6 +518,17 @@ func (p *PullRequest) UpdatedDate() githubv4.DateTime {
 	return date
 }
 
+// HeadRepositoryObject represents the repository of the head of a pull request,
+// which is null if the repository (i.e. the fork) has been deleted.
+type HeadRepositoryObject struct {
+	URL        string
+	IsArchived bool
+	IsPrivate  bool
+	Owner      struct {
+		Login string
+	}
+}
+
 // CommitObject represents the GraphQL commit node.
 // https://developer.github.com/v4/object/commit/
 type CommitObject struct {
@@ -144,10 +544,61 @@ type CommitObject struct {
 	}
 }
 
+// MergeQueueEntry represents the GraphQL MergeQueueEntry node, where the head commit
+// is the commit of the merge group which the pull request is tested in.
+// https://docs.github.com/en/graphql/reference/objects#mergequeueentry
+type MergeQueueEntry struct {
+	PullRequest struct {
+		Number     int
+		HeadRefOID string `graphql:"headRefOid"`
+	}
+	HeadCommit CommitObject
+	BaseCommit struct {
+		OID string
+	}
+}
+
+// Ref of the merge group, which Github pushes to a temporary branch.
+func (e *MergeQueueEntry) Ref(baseBranch string) string {
+	return fmt.Sprintf("gh-readonly-queue/%s/pr-%d-%s", baseBranch, e.PullRequest.Number, e.PullRequest.HeadRefOID)
+}
+
 // ChangedFileObject represents the GraphQL FilesChanged node.
 // https://developer.github.com/v4/object/pullrequestchangedfile/
 type ChangedFileObject struct {
-	Path string
+	Path       string `json:"path"`
+	ChangeType string `json:"change_type,omitempty"`
+	Additions  int    `json:"additions,omitempty"`
+	Deletions  int    `json:"deletions,omitempty"`
+}
+
+// PullRequestCommit is a commit of a pull request, with the author recorded in the commit.
+type PullRequestCommit struct {
+	OID           string
+	Message       string
+	AuthorName    string
+	AuthorEmail   string
+	Parents       int
+	CommittedDate time.Time
+}
+
+// SignatureVerification is the result of the verification of a commit signature by Github,
+// where the reason is e.g. "unsigned" or "bad_email" if it is not verified.
+type SignatureVerification struct {
+	Verified bool
+	Reason   string
+}
+
+// CommentObject represents the GraphQL issue comment node.
+// https://developer.github.com/v4/object/issuecomment/
+type CommentObject struct {
+	ID         string
+	DatabaseID int64 `graphql:"databaseId"`
+	Body       string
+	CreatedAt  githubv4.DateTime
+	Author     struct {
+		Login string
+	}
 }
 
 // LabelObject represents the GraphQL label node.
@@ -155,3 +606,12 @@ type ChangedFileObject struct {
 type LabelObject struct {
 	Name string
 }
+
+// RateLimit represents the GraphQL rateLimit node.
+// https://developer.github.com/v4/object/ratelimit/
+type RateLimit struct {
+	Cost      int
+	Limit     int
+	Remaining int
+	ResetAt   githubv4.DateTime
+}
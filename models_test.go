@@ -0,0 +1,209 @@
+package resource_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resource "github.com/telia-oss/github-pr-resource"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	tests := []struct {
+		description string
+		request     string
+		expected    resource.CheckRequest
+		wantErr     string
+	}{
+		{
+			description: "decodes a valid request",
+			request:     `{"source": {"repository": "itsdalmo/test-repository", "paths": ["terraform/*"]}}`,
+			expected: resource.CheckRequest{
+				Source: resource.Source{Repository: "itsdalmo/test-repository", Paths: []string{"terraform/*"}},
+			},
+		},
+		{
+			description: "fails on unknown fields",
+			request:     `{"source": {"repository": "itsdalmo/test-repository", "ignore_pathes": ["*.md"]}}`,
+			wantErr:     `json: unknown field "ignore_pathes"`,
+		},
+		{
+			description: "ignores unknown fields when lenient",
+			request:     `{"source": {"repository": "itsdalmo/test-repository", "ignore_pathes": ["*.md"], "lenient": true}}`,
+			expected: resource.CheckRequest{
+				Source: resource.Source{Repository: "itsdalmo/test-repository", Lenient: true},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var request resource.CheckRequest
+			err := resource.DecodeRequest(strings.NewReader(tc.request), &request)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, request)
+			}
+		})
+	}
+}
+
+func TestResolveAccessToken(t *testing.T) {
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	os.Setenv("GITHUB_TOKEN", "envtoken")
+	defer os.Unsetenv("GITHUB_TOKEN")
+
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("filetoken\n"), 0600))
+
+	tests := []struct {
+		description string
+		source      resource.Source
+		expected    string
+		wantErr     string
+	}{
+		{
+			description: "keeps the access token",
+			source:      resource.Source{AccessToken: "oauthtoken"},
+			expected:    "oauthtoken",
+		},
+		{
+			description: "reads the access token from a file",
+			source:      resource.Source{AccessTokenFile: tokenFile},
+			expected:    "filetoken",
+		},
+		{
+			description: "reads the access token from a command",
+			source:      resource.Source{AccessTokenCmd: "echo cmdtoken"},
+			expected:    "cmdtoken",
+		},
+		{
+			description: "reads the access token from the environment",
+			source:      resource.Source{UseEnvToken: true},
+			expected:    "envtoken",
+		},
+		{
+			description: "fails if the command fails",
+			source:      resource.Source{AccessTokenCmd: "echo denied >&2; exit 1"},
+			wantErr:     "access token command failed: exit status 1: denied",
+		},
+		{
+			description: "fails if the resolved token is empty",
+			source:      resource.Source{AccessTokenCmd: "true"},
+			wantErr:     "resolved access token is empty",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			err := tc.source.ResolveAccessToken()
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, tc.source.AccessToken)
+			}
+		})
+	}
+}
+
+func TestReviewApprovals(t *testing.T) {
+	tests := []struct {
+		description string
+		config      string
+		expected    resource.ReviewApprovals
+		wantErr     bool
+	}{
+		{
+			description: "a number of approvals",
+			config:      `2`,
+			expected:    resource.ReviewApprovals{Count: 2},
+		},
+		{
+			description: "a number of approvals per team",
+			config:      `{"platform-team": 1, "security": 2}`,
+			expected:    resource.ReviewApprovals{Teams: map[string]int{"platform-team": 1, "security": 2}},
+		},
+		{
+			description: "fails on other values",
+			config:      `"two"`,
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			var source resource.Source
+			err := json.Unmarshal([]byte(`{"required_review_approvals": `+tc.config+`}`), &source)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, source.RequiredReviewApprovals)
+
+			b, err := json.Marshal(source.RequiredReviewApprovals)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.config, string(b))
+		})
+	}
+}
+
+func TestVersionMatches(t *testing.T) {
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	version := resource.Version{
+		PR:            "1",
+		Commit:        "oid1",
+		CommittedDate: date,
+		State:         githubv4.PullRequestStateOpen,
+		BaseSHA:       "basesha",
+	}
+
+	tests := []struct {
+		description string
+		previous    resource.Version
+		expected    bool
+	}{
+		{
+			description: "matches the same version",
+			previous:    version,
+			expected:    true,
+		},
+		{
+			description: "missing fields match any value",
+			previous:    resource.Version{PR: "1", Commit: "oid1"},
+			expected:    true,
+		},
+		{
+			description: "does not match another commit",
+			previous:    resource.Version{PR: "1", Commit: "oid2"},
+		},
+		{
+			description: "does not match another date",
+			previous:    resource.Version{PR: "1", Commit: "oid1", CommittedDate: date.Add(time.Hour)},
+		},
+		{
+			description: "does not match another base commit",
+			previous:    resource.Version{PR: "1", Commit: "oid1", BaseSHA: "other"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, version.Matches(tc.previous))
+		})
+	}
+}
@@ -1,90 +1,384 @@
 package resource
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Put (business logic)
 func Put(request PutRequest, manager Github, inputDir string) (*PutResponse, error) {
 	if err := request.Params.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid parameters: %s", err)
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if request.Params.DryRun {
+		manager = &dryRunGithub{Github: manager}
+	}
+	if strings.ToLower(request.Params.Status) == "from_outcome" {
+		request.Params.Status = statusForOutcome(request.Params.Outcome, request.Params.AbortState)
 	}
 	path := filepath.Join(inputDir, request.Params.Path, ".git", "resource")
+	if p := request.Params; p.AuditLog {
+		file := filepath.Join(inputDir, p.Path, ".audit.jsonl")
+		if p.AuditLogFile != "" {
+			file = filepath.Join(inputDir, p.AuditLogFile)
+		}
+		out, err := openAuditLog(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+		defer out.Close()
+		manager = &auditGithub{Github: manager, out: out, dryRun: p.DryRun}
+	}
+
+	// Sweep the stale pull requests instead of updating the pull request of the version.
+	if request.Params.SweepStale != nil {
+		return sweepStale(request, manager, path, time.Now())
+	}
 
 	// Version available after a GET step.
 	var version Version
 	content, err := ioutil.ReadFile(filepath.Join(path, "version.json"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read version from path: %s", err)
+		return nil, fmt.Errorf("failed to read version from path: %w", err)
 	}
 	if err := json.Unmarshal(content, &version); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal version from file: %s", err)
+		return nil, fmt.Errorf("failed to unmarshal version from file: %w", err)
 	}
 
 	// Metadata available after a GET step.
 	var metadata Metadata
 	content, err = ioutil.ReadFile(filepath.Join(path, "metadata.json"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read metadata from path: %s", err)
+		return nil, fmt.Errorf("failed to read metadata from path: %w", err)
 	}
 	if err := json.Unmarshal(content, &metadata); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal metadata from file: %s", err)
+		return nil, fmt.Errorf("failed to unmarshal metadata from file: %w", err)
+	}
+
+	// Variables for templated parameters. The version is written by the GET
+	// step, so its modification time approximates when the build started.
+	data := NewTemplateData(version)
+	if info, err := os.Stat(filepath.Join(path, "version.json")); err == nil {
+		data.Duration = time.Since(info.ModTime()).Round(time.Second).String()
 	}
 
 	// Set status if specified
 	if p := request.Params; p.Status != "" {
+		data.Status = strings.ToLower(p.Status)
 		description := p.Description
 
 		// Set description from a file
 		if p.DescriptionFile != "" {
 			content, err := ioutil.ReadFile(filepath.Join(inputDir, p.DescriptionFile))
 			if err != nil {
-				return nil, fmt.Errorf("failed to read description file: %s", err)
+				return nil, fmt.Errorf("failed to read description file: %w", err)
 			}
 			description = string(content)
 		}
+		if description == "" {
+			description = outcomeDescriptions[p.Outcome]
+		}
+
+		description, err = renderStatus(p, "description", description, data)
+		if err != nil {
+			return nil, err
+		}
 
-		if err := manager.UpdateCommitStatus(version.Commit, p.BaseContext, safeExpandEnv(p.Context), p.Status, safeExpandEnv(p.TargetURL), description); err != nil {
-			return nil, fmt.Errorf("failed to set status: %s", err)
+		targetURL := p.TargetURL
+
+		// Set target URL from a file
+		if p.TargetURLFile != "" {
+			content, err := ioutil.ReadFile(filepath.Join(inputDir, p.TargetURLFile))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read target url file: %w", err)
+			}
+			targetURL = strings.TrimSpace(string(content))
+		}
+
+		targetURL, err = renderStatus(p, "target_url", targetURL, data)
+		if err != nil {
+			return nil, err
+		}
+
+		contexts := p.Contexts
+		if len(contexts) == 0 {
+			contexts = []string{p.Context}
+		}
+
+		baseContext, err := statusBaseContext(request.Source, p, data)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range contexts {
+			c, err := renderStatus(p, "context", c, data)
+			if err != nil {
+				return nil, err
+			}
+			logger.Info("setting status", "commit", version.Commit, "context", c, "status", p.Status)
+			if err := manager.UpdateCommitStatus(version.Commit, baseContext, safeExpandEnv(c), p.Status, safeExpandEnv(targetURL), description); err != nil {
+				return nil, fmt.Errorf("failed to set status for context '%s': %w", c, err)
+			}
 		}
 	}
 
 	// Delete previous comments if specified
-	if request.Params.DeletePreviousComments {
-		err = manager.DeletePreviousComments(version.PR)
+	if p := request.Params; p.DeletePreviousComments || p.DeleteCommentsOlderThan > 0 {
+		var before time.Time
+		if p.DeleteCommentsOlderThan > 0 {
+			before = time.Now().Add(-time.Duration(p.DeleteCommentsOlderThan))
+		}
+		err = manager.DeletePreviousComments(version.PR, commentMarker(request.Source.ContextNamespace, p.Context), before)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete previous comments: %w", err)
+		}
+	}
+
+	// Minimize previous comments if specified
+	if p := request.Params; p.MinimizePreviousComments != "" {
+		err = manager.MinimizePreviousComments(version.PR, commentMarker(request.Source.ContextNamespace, p.Context), p.MinimizePreviousComments)
 		if err != nil {
-			return nil, fmt.Errorf("failed to delete previous comments: %s", err)
+			return nil, fmt.Errorf("failed to minimize previous comments: %w", err)
 		}
 	}
 
+	// Comments are only posted for the outcomes listed in comment_on (if any).
+	postComments := len(request.Params.CommentOn) == 0 || containsString(request.Params.CommentOn, request.Params.Outcome)
+
 	// Set comment if specified
-	if p := request.Params; p.Comment != "" {
-		err = manager.PostComment(version.PR, safeExpandEnv(p.Comment))
+	if p := request.Params; p.Comment != "" && postComments {
+		comment := p.Comment
+		if p.CommentTemplate {
+			comment, err = RenderComment("comment", comment, data, inputDir)
+			if err != nil {
+				return nil, err
+			}
+		}
+		logger.Info("posting comment", "pr", version.PR)
+		err = postComment(manager, request.Source, p, version, safeExpandEnv(comment))
 		if err != nil {
-			return nil, fmt.Errorf("failed to post comment: %s", err)
+			return nil, fmt.Errorf("failed to post comment: %w", err)
 		}
 	}
 
-	// Set comment from a file
-	if p := request.Params; p.CommentFile != "" {
-		content, err := ioutil.ReadFile(filepath.Join(inputDir, p.CommentFile))
+	// Set comment from a file, which can be selected by the outcome of the build
+	if p := request.Params; postComments && (p.CommentFile != "" || p.CommentFiles[p.Outcome] != "") {
+		commentFile := p.CommentFile
+		if f, ok := p.CommentFiles[p.Outcome]; ok {
+			commentFile = f
+		}
+		content, err := ioutil.ReadFile(filepath.Join(inputDir, commentFile))
 		if err != nil {
-			return nil, fmt.Errorf("failed to read comment file: %s", err)
+			return nil, fmt.Errorf("failed to read comment file: %w", err)
 		}
 		comment := string(content)
+		if p.CommentTemplate {
+			comment, err = RenderComment("comment_file", comment, data, inputDir)
+			if err != nil {
+				return nil, err
+			}
+		}
 		if comment != "" {
-			err = manager.PostComment(version.PR, safeExpandEnv(comment))
+			logger.Info("posting comment", "pr", version.PR, "file", commentFile)
+			err = postComment(manager, request.Source, p, version, safeExpandEnv(comment))
+			if err != nil {
+				return nil, fmt.Errorf("failed to post comment: %w", err)
+			}
+		}
+	}
+
+	// Upload files as a gist and link to it in a comment if specified
+	if p := request.Params; len(p.GistFiles) > 0 && postComments {
+		files := make(map[string]string)
+		for _, name := range p.GistFiles {
+			content, err := ioutil.ReadFile(filepath.Join(inputDir, name))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read gist file: %w", err)
+			}
+			if len(content) == 0 {
+				continue
+			}
+			base := filepath.Base(name)
+			if _, ok := files[base]; ok {
+				return nil, fmt.Errorf("gist files cannot have the same name: %s", base)
+			}
+			files[base] = string(content)
+		}
+		if len(files) > 0 {
+			description, err := RenderTemplate("gist_description", "Build {{.PipelineName}}/{{.JobName}} #{{.BuildName}} for pull request #{{.PR}} at commit {{.Commit}}", data)
+			if err != nil {
+				return nil, err
+			}
+			logger.Info("creating gist", "pr", version.PR, "files", len(files))
+			data.GistURL, err = manager.CreateGist(description, files)
 			if err != nil {
-				return nil, fmt.Errorf("failed to post comment: %s", err)
+				return nil, fmt.Errorf("failed to create gist: %w", err)
 			}
+			comment := p.GistComment
+			if comment == "" {
+				comment = "Output of build [{{.PipelineName}}/{{.JobName}} #{{.BuildName}}]({{.BuildURL}}) for commit {{.Commit}}: {{.GistURL}}"
+			}
+			comment, err = RenderTemplate("gist_comment", comment, data)
+			if err != nil {
+				return nil, err
+			}
+			if err := postComment(manager, request.Source, p, version, comment); err != nil {
+				return nil, fmt.Errorf("failed to post comment: %w", err)
+			}
+		}
+	}
+
+	// Re-run the check suites of the commit if specified
+	if request.Params.RerequestChecks {
+		if err := manager.RerequestCheckSuites(version.Commit); err != nil {
+			return nil, fmt.Errorf("failed to re-request check suites: %w", err)
+		}
+	}
+
+	// Dismiss reviews made on earlier commits if specified
+	if p := request.Params; p.DismissReviews {
+		message := p.DismissMessage
+		if message == "" {
+			message = "Dismissed by Concourse CI since new commits were pushed after the review."
+		}
+		if err := manager.DismissStaleReviews(version.PR, version.Commit, safeExpandEnv(message)); err != nil {
+			return nil, fmt.Errorf("failed to dismiss reviews: %w", err)
+		}
+	}
+
+	// Open an issue for the failed build if specified
+	if p := request.Params; p.OnFailureIssue {
+		switch strings.ToLower(p.Status) {
+		case "failure", "error":
+			title := p.IssueTitle
+			if title == "" {
+				title = "Concourse CI build failed for pull request #{{.PR}}"
+			}
+			title, err := RenderTemplate("issue_title", title, data)
+			if err != nil {
+				return nil, err
+			}
+			body, err := RenderTemplate("issue_body", failureIssueBody, data)
+			if err != nil {
+				return nil, err
+			}
+			if err := manager.CreateOrUpdateIssue(title, body, p.IssueLabels); err != nil {
+				return nil, fmt.Errorf("failed to open issue: %w", err)
+			}
+		}
+	}
+
+	// Lock or unlock the conversation if specified
+	if p := request.Params; p.Lock != nil {
+		if err := manager.SetLocked(version.PR, *p.Lock, p.LockReason); err != nil {
+			return nil, fmt.Errorf("failed to set lock: %w", err)
+		}
+	}
+
+	// Add a reaction if specified
+	if p := request.Params; p.Reaction != "" {
+		if err := manager.AddReaction(version.PR, p.ReactionCommentID, p.Reaction); err != nil {
+			return nil, fmt.Errorf("failed to add reaction: %w", err)
+		}
+	}
+
+	// Label the pull request by the paths it changes if specified
+	if p := request.Params; len(p.LabelByPaths) > 0 {
+		labels, err := labelsByPaths(manager, version, p.LabelByPaths)
+		if err != nil {
+			return nil, err
+		}
+		if len(labels) > 0 {
+			logger.Info("adding labels", "pr", version.PR, "labels", labels)
+			if err := manager.AddLabels(version.PR, labels); err != nil {
+				return nil, fmt.Errorf("failed to add labels: %w", err)
+			}
+		}
+	}
+
+	// Set the DCO status if specified
+	if p := request.Params; p.DCO {
+		status, description, err := checkDCO(manager, version.PR)
+		if err != nil {
+			return nil, err
+		}
+		baseContext, err := statusBaseContext(request.Source, p, data)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("setting status", "commit", version.Commit, "context", dcoContext, "status", status)
+		if err := manager.UpdateCommitStatus(version.Commit, baseContext, dcoContext, status, "", description); err != nil {
+			return nil, fmt.Errorf("failed to set status for context '%s': %w", dcoContext, err)
+		}
+	}
+
+	// Validate the title of the pull request if specified
+	if p := request.Params; p.ValidateTitle != nil {
+		pull, err := manager.GetPullRequest(version.PR, version.Commit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve pull request: %w", err)
+		}
+		status, description := "success", "Title is valid"
+		reason := p.ValidateTitle.ValidateTitle(pull.Title)
+		if reason != "" {
+			status, description = "failure", "Title is invalid"
+		}
+		baseContext, err := statusBaseContext(request.Source, p, data)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("setting status", "commit", version.Commit, "context", titleContext, "status", status)
+		if err := manager.UpdateCommitStatus(version.Commit, baseContext, titleContext, status, "", description); err != nil {
+			return nil, fmt.Errorf("failed to set status for context '%s': %w", titleContext, err)
+		}
+		if reason != "" {
+			comment := p.ValidateTitle.Comment
+			if comment == "" {
+				comment = "The title of this pull request is invalid. {{.Reason}}."
+			}
+			comment, err := RenderTemplate("validate_title.comment", comment, struct {
+				TemplateData
+				Title  string
+				Reason string
+			}{data, pull.Title, reason})
+			if err != nil {
+				return nil, err
+			}
+			// The comment has a marker of its own, and is updated in place by later puts.
+			tp := PutParameters{Context: titleCommentContext, UpdateComment: true}
+			logger.Info("posting comment", "pr", version.PR)
+			if err := postComment(manager, request.Source, tp, version, comment); err != nil {
+				return nil, fmt.Errorf("failed to post comment: %w", err)
+			}
+		} else if err := resolveTitleComment(manager, request.Source, version); err != nil {
+			return nil, err
+		}
+	}
+
+	// Label the pull request by its size if specified
+	if p := request.Params; p.SizeLabels {
+		if err := setSizeLabel(manager, version, p.SizeThresholds); err != nil {
+			return nil, err
 		}
 	}
 
+	// The rate limit in the metadata of the get is outdated by now.
+	if rl := reportRateLimit(manager); rl != nil {
+		metadata.Set("rate_limit_remaining", strconv.Itoa(rl.Remaining))
+		metadata.Set("rate_limit_reset_at", rl.ResetAt.Time.Format(time.RFC3339))
+	}
+
 	return &PutResponse{
 		Version:  version,
 		Metadata: metadata,
@@ -105,20 +399,136 @@ type PutResponse struct {
 
 // PutParameters for the resource.
 type PutParameters struct {
-	Path                   string `json:"path"`
-	BaseContext            string `json:"base_context"`
-	Context                string `json:"context"`
-	TargetURL              string `json:"target_url"`
-	DescriptionFile        string `json:"description_file"`
-	Description            string `json:"description"`
-	Status                 string `json:"status"`
-	CommentFile            string `json:"comment_file"`
-	Comment                string `json:"comment"`
-	DeletePreviousComments bool   `json:"delete_previous_comments"`
+	Path                     string                   `json:"path"`
+	BaseContext              string                   `json:"base_context"`
+	Context                  string                   `json:"context"`
+	Contexts                 []string                 `json:"contexts"`
+	TargetURL                string                   `json:"target_url"`
+	TargetURLFile            string                   `json:"target_url_file"`
+	DescriptionFile          string                   `json:"description_file"`
+	Description              string                   `json:"description"`
+	Status                   string                   `json:"status"`
+	CommentFile              string                   `json:"comment_file"`
+	CommentFiles             map[string]string        `json:"comment_files"`
+	CommentOn                []string                 `json:"comment_on"`
+	Outcome                  string                   `json:"outcome"`
+	Comment                  string                   `json:"comment"`
+	DeletePreviousComments   bool                     `json:"delete_previous_comments"`
+	Reaction                 string                   `json:"reaction"`
+	ReactionCommentID        string                   `json:"reaction_comment_id"`
+	DismissReviews           bool                     `json:"dismiss_reviews"`
+	DismissMessage           string                   `json:"dismiss_message"`
+	OnFailureIssue           bool                     `json:"on_failure_issue"`
+	IssueTitle               string                   `json:"issue_title"`
+	IssueLabels              []string                 `json:"issue_labels"`
+	Lock                     *bool                    `json:"lock"`
+	LockReason               string                   `json:"lock_reason"`
+	DryRun                   bool                     `json:"dry_run"`
+	AuditLog                 bool                     `json:"audit_log"`
+	AuditLogFile             string                   `json:"audit_log_file"`
+	CommentTemplate          bool                     `json:"comment_template"`
+	StatusTemplate           bool                     `json:"status_template"`
+	AbortState               string                   `json:"abort_state"`
+	RerequestChecks          bool                     `json:"rerequest_checks"`
+	GistFiles                []string                 `json:"gist_files"`
+	GistComment              string                   `json:"gist_comment"`
+	MaxCommentsPerPR         int                      `json:"max_comments_per_pr"`
+	CommentInterval          Duration                 `json:"comment_interval"`
+	UpdateComment            bool                     `json:"update_comment"`
+	Idempotent               bool                     `json:"idempotent"`
+	DeleteCommentsOlderThan  Duration                 `json:"delete_comments_older_than"`
+	MinimizePreviousComments string                   `json:"minimize_previous_comments"`
+	SweepStale               *SweepStaleParameters    `json:"sweep_stale"`
+	LabelByPaths             map[string]string        `json:"label_by_paths"`
+	SizeLabels               bool                     `json:"size_labels"`
+	SizeThresholds           []int                    `json:"size_thresholds"`
+	DCO                      bool                     `json:"dco"`
+	ValidateTitle            *ValidateTitleParameters `json:"validate_title"`
 }
 
 // Validate the put parameters.
 func (p *PutParameters) Validate() error {
+	if p.Context != "" && len(p.Contexts) > 0 {
+		return errors.New("context and contexts cannot be set at the same time")
+	}
+	if p.TargetURL != "" && p.TargetURLFile != "" {
+		return errors.New("target_url and target_url_file cannot be set at the same time")
+	}
+	if p.MaxCommentsPerPR < 0 {
+		return errors.New("max_comments_per_pr cannot be negative")
+	}
+	if p.ReactionCommentID != "" && p.Reaction == "" {
+		return errors.New("reaction must be set together with reaction_comment_id")
+	}
+	if p.Reaction != "" {
+		var allowedReaction bool
+		for _, a := range []string{"+1", "-1", "laugh", "confused", "heart", "hooray", "rocket", "eyes"} {
+			if p.Reaction == a {
+				allowedReaction = true
+			}
+		}
+		if !allowedReaction {
+			return fmt.Errorf("unknown reaction: %s", p.Reaction)
+		}
+	}
+	if p.Outcome != "" {
+		switch p.Outcome {
+		case "success", "failure", "error", "abort":
+		default:
+			return fmt.Errorf("unknown outcome: %s", p.Outcome)
+		}
+	} else if len(p.CommentOn) > 0 || len(p.CommentFiles) > 0 {
+		return errors.New("outcome must be set when using comment_on or comment_files")
+	}
+	switch strings.ToLower(p.MinimizePreviousComments) {
+	case "":
+	case "outdated", "resolved":
+		if p.DeletePreviousComments || p.DeleteCommentsOlderThan > 0 {
+			return errors.New("minimize_previous_comments cannot be combined with deleting previous comments")
+		}
+	default:
+		return fmt.Errorf("minimize_previous_comments must be one of: outdated, resolved")
+	}
+	for pattern := range p.LabelByPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid label_by_paths pattern '%s': %w", pattern, err)
+		}
+	}
+	if len(p.SizeThresholds) > 0 {
+		if len(p.SizeThresholds) != len(sizeLabels)-1 {
+			return fmt.Errorf("size_thresholds must have %d thresholds", len(sizeLabels)-1)
+		}
+		for i := 1; i < len(p.SizeThresholds); i++ {
+			if p.SizeThresholds[i] <= p.SizeThresholds[i-1] {
+				return errors.New("size_thresholds must be increasing")
+			}
+		}
+	}
+	if p.ValidateTitle != nil {
+		if err := p.ValidateTitle.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.SweepStale != nil {
+		if err := p.SweepStale.Validate(); err != nil {
+			return err
+		}
+	}
+	switch strings.ToLower(p.AbortState) {
+	case "", "error", "failure":
+	default:
+		return fmt.Errorf("abort_state must be one of: error, failure")
+	}
+	if p.LockReason != "" {
+		if p.Lock == nil || !*p.Lock {
+			return errors.New("lock_reason can only be set together with lock: true")
+		}
+		switch p.LockReason {
+		case "off-topic", "too heated", "resolved", "spam":
+		default:
+			return fmt.Errorf("unknown lock reason: %s", p.LockReason)
+		}
+	}
 	if p.Status == "" {
 		return nil
 	}
@@ -126,7 +536,7 @@ func (p *PutParameters) Validate() error {
 	var allowedStatus bool
 
 	status := strings.ToLower(p.Status)
-	allowed := []string{"success", "pending", "failure", "error"}
+	allowed := []string{"success", "pending", "failure", "error", "from_outcome"}
 
 	for _, a := range allowed {
 		if status == a {
@@ -141,6 +551,260 @@ func (p *PutParameters) Validate() error {
 	return nil
 }
 
+// renderStatus renders a parameter of the status as a template if status_template is set, so
+// that text containing a literal {{ is left alone otherwise.
+func renderStatus(p PutParameters, name, text string, data TemplateData) (string, error) {
+	if !p.StatusTemplate {
+		return text, nil
+	}
+	return RenderTemplate(name, text, data)
+}
+
+// statusBaseContext renders the base context of statuses, prefixed by the context namespace.
+func statusBaseContext(source Source, p PutParameters, data TemplateData) (string, error) {
+	baseContext, err := renderStatus(p, "base_context", p.BaseContext, data)
+	if err != nil {
+		return "", err
+	}
+	if ns := source.ContextNamespace; ns != "" {
+		if baseContext == "" {
+			baseContext = DefaultBaseContext
+		}
+		baseContext = ns + "/" + baseContext
+	}
+	return baseContext, nil
+}
+
+// labelsByPaths returns the (sorted) labels of the patterns which match the files changed
+// by the pull request.
+func labelsByPaths(manager Github, version Version, patterns map[string]string) ([]string, error) {
+	changed, err := manager.GetChangedFiles(version.PR, version.Commit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch list of changed files: %w", err)
+	}
+	var files []string
+	for _, f := range changed {
+		files = append(files, f.Path)
+	}
+
+	var labels []string
+	for pattern, label := range patterns {
+		matched, err := FilterPath(files, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("path match failed: %w", err)
+		}
+		if len(matched) > 0 && !containsString(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
+
+// sizeLabels from the smallest to the largest pull requests.
+var sizeLabels = []string{"size/XS", "size/S", "size/M", "size/L", "size/XL", "size/XXL"}
+
+// defaultSizeThresholds are the number of changed lines from which pull requests have the
+// next size label.
+var defaultSizeThresholds = []int{10, 30, 100, 500, 1000}
+
+// SizeLabel returns the size label for a number of changed lines.
+func SizeLabel(changed int, thresholds []int) string {
+	if len(thresholds) == 0 {
+		thresholds = defaultSizeThresholds
+	}
+	for i, t := range thresholds {
+		if changed < t {
+			return sizeLabels[i]
+		}
+	}
+	return sizeLabels[len(sizeLabels)-1]
+}
+
+// setSizeLabel labels the pull request by its size (additions and deletions), replacing
+// the size label it had before (if any).
+func setSizeLabel(manager Github, version Version, thresholds []int) error {
+	pull, err := manager.GetPullRequest(version.PR, version.Commit)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve pull request: %w", err)
+	}
+	label := SizeLabel(pull.Additions+pull.Deletions, thresholds)
+
+	var labelled bool
+	for _, l := range pull.Labels {
+		switch {
+		case l.Name == label:
+			labelled = true
+		case containsString(sizeLabels, l.Name):
+			if err := manager.RemoveLabel(version.PR, l.Name); err != nil {
+				return fmt.Errorf("failed to remove label: %w", err)
+			}
+		}
+	}
+	if labelled {
+		return nil
+	}
+	logger.Info("adding size label", "pr", version.PR, "label", label, "changed_lines", pull.Additions+pull.Deletions)
+	if err := manager.AddLabels(version.PR, []string{label}); err != nil {
+		return fmt.Errorf("failed to add label: %w", err)
+	}
+	return nil
+}
+
+// postComment on the pull request, unless the resource has already made max_comments_per_pr
+// comments on it, or made the same comment within the comment_interval. This protects pull
+// requests from being flooded by a misconfigured pipeline. Comments which are too long for
+// Github are split into a series of comments. With update_comment, the last comment of the
+// job is updated instead, unless the comment has to be split. With idempotent, the comments
+// are marked with a key, and comments which were already posted (e.g. by an earlier attempt
+// of the same step) are skipped.
+func postComment(manager Github, source Source, p PutParameters, version Version, comment string) error {
+	pr := version.PR
+	marker := commentMarker(source.ContextNamespace, p.Context)
+	suffix := len(marker) + 2
+	if p.Idempotent {
+		suffix += len(idempotencyKey("", "", 0)) + 1
+	}
+	parts := SplitComment(comment, MaxCommentLength-suffix)
+	keys := make([]string, len(parts))
+	for i := range parts {
+		parts[i] += "\n\n" + marker
+		if p.Idempotent {
+			keys[i] = idempotencyKey(version.Commit, comment, i)
+			parts[i] += "\n" + keys[i]
+		}
+	}
+	posted := make([]bool, len(parts))
+	if p.MaxCommentsPerPR > 0 || p.CommentInterval > 0 || p.UpdateComment || p.Idempotent {
+		comments, err := manager.ListOwnComments(pr)
+		if err != nil {
+			return fmt.Errorf("failed to list previous comments: %w", err)
+		}
+		if p.Idempotent {
+			remaining := len(parts)
+			for i, key := range keys {
+				for _, c := range comments {
+					if strings.Contains(c.Body, key) {
+						posted[i] = true
+						remaining--
+						break
+					}
+				}
+			}
+			if remaining == 0 {
+				logger.Info("skipping comment", "pr", pr, "reason", "comment was already posted")
+				return nil
+			}
+		}
+		if p.UpdateComment && len(parts) == 1 {
+			for i := len(comments) - 1; i >= 0; i-- {
+				if strings.Contains(comments[i].Body, marker) {
+					logger.Info("updating comment", "pr", pr, "comment_id", comments[i].DatabaseID)
+					return manager.UpdateComment(strconv.FormatInt(comments[i].DatabaseID, 10), parts[0])
+				}
+			}
+		}
+		if p.MaxCommentsPerPR > 0 {
+			// Only the comments of the job (and context) count, so that other jobs are not silenced.
+			var count int
+			for _, c := range comments {
+				if strings.Contains(c.Body, marker) {
+					count++
+				}
+			}
+			if count >= p.MaxCommentsPerPR {
+				logger.Warn("skipping comment", "pr", pr, "reason", "max_comments_per_pr reached", "comments", count)
+				return nil
+			}
+		}
+		for _, c := range comments {
+			if p.CommentInterval > 0 && c.Body == parts[0] && time.Since(c.CreatedAt.Time) < time.Duration(p.CommentInterval) {
+				logger.Warn("skipping comment", "pr", pr, "reason", "same comment posted within comment_interval", "posted_at", c.CreatedAt.Time)
+				return nil
+			}
+		}
+	}
+	if len(parts) > 1 {
+		logger.Info("splitting comment", "pr", pr, "length", len(comment), "comments", len(parts))
+	}
+	for i, part := range parts {
+		if posted[i] {
+			logger.Info("skipping part of comment", "pr", pr, "part", i+1, "reason", "already posted")
+			continue
+		}
+		if err := manager.PostComment(pr, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// idempotencyKey identifies a part of a comment for a commit in a build, so that it is only
+// posted once by the attempts of the build, while a new build of the job posts it again.
+func idempotencyKey(commit, comment string, part int) string {
+	build := os.Getenv("BUILD_ID")
+	if build == "" {
+		build = os.Getenv("BUILD_NAME")
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d\x00%s", build, commit, part, comment)))
+	return "<!-- github-pr-resource-key=" + hex.EncodeToString(sum[:8]) + " -->"
+}
+
+// DefaultBaseContext of commit statuses.
+const DefaultBaseContext = "concourse-ci"
+
+// commentMarker is an invisible marker added to comments, which identifies the job (and
+// the context namespace) that posted them, so that delete_previous_comments and
+// update_comment only touch the comments of the job.
+func commentMarker(namespace, context string) string {
+	fields := []string{"github-pr-resource"}
+	for _, f := range [][2]string{
+		{"namespace", namespace},
+		{"pipeline", os.Getenv("BUILD_PIPELINE_NAME")},
+		{"job", os.Getenv("BUILD_JOB_NAME")},
+		{"context", context},
+	} {
+		if f[1] != "" {
+			fields = append(fields, f[0]+"="+f[1])
+		}
+	}
+	return "<!-- " + strings.Join(fields, " ") + " -->"
+}
+
+// statusForOutcome returns the status for the outcome of the build, which is pending
+// if the build has not finished yet.
+func statusForOutcome(outcome, abortState string) string {
+	switch outcome {
+	case "":
+		return "pending"
+	case "abort":
+		if abortState != "" {
+			return strings.ToLower(abortState)
+		}
+		return "error"
+	default:
+		return outcome
+	}
+}
+
+// outcomeDescriptions are the default descriptions of statuses for builds which did
+// not run to completion, to tell them apart from failed builds.
+var outcomeDescriptions = map[string]string{
+	"error": "Concourse CI build errored",
+	"abort": "Concourse CI build was aborted",
+}
+
+const failureIssueBody = `Build [{{.PipelineName}}/{{.JobName}} #{{.BuildName}}]({{.BuildURL}}) failed for pull request #{{.PR}} at commit {{.Commit}}.`
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
 func safeExpandEnv(s string) string {
 	return os.Expand(s, func(v string) string {
 		switch v {
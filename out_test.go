@@ -1,8 +1,13 @@
 package resource_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -127,6 +132,24 @@ func TestPut(t *testing.T) {
 			pullRequest: createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
 		},
 
+		{
+			description: "we can set status for multiple contexts",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+			},
+			parameters: resource.PutParameters{
+				Status:   "pending",
+				Contexts: []string{"unit", "integration", "lint"},
+			},
+			pullRequest: createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		},
+
 		{
 			description: "we can comment on the pull request",
 			source: resource.Source{
@@ -145,27 +168,1113 @@ func TestPut(t *testing.T) {
 		},
 
 		{
-			description: "we can delete previous comments made on the pull request",
-			source: resource.Source{
-				Repository:  "itsdalmo/test-repository",
-				AccessToken: "oauthtoken",
-			},
-			version: resource.Version{
-				PR:            "pr1",
-				Commit:        "commit1",
-				CommittedDate: time.Time{},
-			},
-			parameters: resource.PutParameters{
-				DeletePreviousComments: true,
-			},
-			pullRequest: createTestPR(1, "master", false, false, 0, []string{}, false, githubv4.PullRequestStateOpen),
+			description: "we can delete previous comments made on the pull request",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+			},
+			parameters: resource.PutParameters{
+				DeletePreviousComments: true,
+			},
+			pullRequest: createTestPR(1, "master", false, false, 0, []string{}, false, githubv4.PullRequestStateOpen),
+		},
+
+		{
+			description: "we can add a reaction to the pull request",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+			},
+			parameters: resource.PutParameters{
+				Reaction:          "rocket",
+				ReactionCommentID: "1234",
+			},
+			pullRequest: createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		},
+
+		{
+			description: "we can dismiss stale reviews",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+			},
+			parameters: resource.PutParameters{
+				DismissReviews: true,
+				DismissMessage: "Please review again",
+			},
+			pullRequest: createTestPR(1, "master", false, false, 1, nil, false, githubv4.PullRequestStateOpen),
+		},
+
+		{
+			description: "we can open an issue when the build fails",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+			},
+			parameters: resource.PutParameters{
+				Status:         "failure",
+				OnFailureIssue: true,
+				IssueLabels:    []string{"flaky"},
+			},
+			pullRequest: createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		},
+
+		{
+			description: "we do not open an issue without a failed status",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+			},
+			parameters: resource.PutParameters{
+				Comment:        "comment",
+				OnFailureIssue: true,
+			},
+			pullRequest: createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		},
+
+		{
+			description: "we can lock the pull request conversation",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+			},
+			parameters: resource.PutParameters{
+				Lock:       boolPtr(true),
+				LockReason: "resolved",
+			},
+			pullRequest: createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		},
+
+		{
+			description: "we can unlock the pull request conversation",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+			},
+			parameters: resource.PutParameters{
+				Lock: boolPtr(false),
+			},
+			pullRequest: createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(tc.pullRequest, nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			// Run get so we have version and metadata for the put request
+			// (This is tested in in_test.go)
+			getInput := resource.GetRequest{Source: tc.source, Version: tc.version, Params: resource.GetParameters{}}
+			_, err := resource.Get(getInput, github, git, dir)
+			require.NoError(t, err)
+
+			putInput := resource.PutRequest{Source: tc.source, Params: tc.parameters}
+			output, err := resource.Put(putInput, github, dir)
+
+			// Validate output
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.version, output.Version)
+			}
+
+			// Validate method calls put on Github.
+			if tc.parameters.Status != "" {
+				contexts := tc.parameters.Contexts
+				if len(contexts) == 0 {
+					contexts = []string{tc.parameters.Context}
+				}
+				if assert.Equal(t, len(contexts), github.UpdateCommitStatusCallCount()) {
+					for i, expectedContext := range contexts {
+						commit, baseContext, context, status, targetURL, description := github.UpdateCommitStatusArgsForCall(i)
+						assert.Equal(t, tc.version.Commit, commit)
+						assert.Equal(t, tc.parameters.BaseContext, baseContext)
+						assert.Equal(t, expectedContext, context)
+						assert.Equal(t, tc.parameters.TargetURL, targetURL)
+						assert.Equal(t, tc.parameters.Description, description)
+						assert.Equal(t, tc.parameters.Status, status)
+					}
+				}
+			}
+
+			if tc.parameters.Comment != "" {
+				if assert.Equal(t, 1, github.PostCommentCallCount()) {
+					pr, comment := github.PostCommentArgsForCall(0)
+					assert.Equal(t, tc.version.PR, pr)
+					assert.Equal(t, tc.parameters.Comment, withoutMarker(comment))
+				}
+			}
+
+			if tc.parameters.DeletePreviousComments {
+				if assert.Equal(t, 1, github.DeletePreviousCommentsCallCount()) {
+					pr, marker, before := github.DeletePreviousCommentsArgsForCall(0)
+					assert.Equal(t, tc.version.PR, pr)
+					assert.Equal(t, "<!-- github-pr-resource -->", marker)
+					assert.True(t, before.IsZero())
+				}
+			}
+
+			if tc.parameters.DismissReviews {
+				if assert.Equal(t, 1, github.DismissStaleReviewsCallCount()) {
+					pr, commit, message := github.DismissStaleReviewsArgsForCall(0)
+					assert.Equal(t, tc.version.PR, pr)
+					assert.Equal(t, tc.version.Commit, commit)
+					assert.Equal(t, tc.parameters.DismissMessage, message)
+				}
+			}
+
+			if s := strings.ToLower(tc.parameters.Status); tc.parameters.OnFailureIssue && (s == "failure" || s == "error") {
+				if assert.Equal(t, 1, github.CreateOrUpdateIssueCallCount()) {
+					title, body, labels := github.CreateOrUpdateIssueArgsForCall(0)
+					assert.Equal(t, "Concourse CI build failed for pull request #"+tc.version.PR, title)
+					assert.Contains(t, body, tc.version.Commit)
+					assert.Equal(t, tc.parameters.IssueLabels, labels)
+				}
+			} else {
+				assert.Equal(t, 0, github.CreateOrUpdateIssueCallCount())
+			}
+
+			if tc.parameters.Lock != nil {
+				if assert.Equal(t, 1, github.SetLockedCallCount()) {
+					pr, locked, reason := github.SetLockedArgsForCall(0)
+					assert.Equal(t, tc.version.PR, pr)
+					assert.Equal(t, *tc.parameters.Lock, locked)
+					assert.Equal(t, tc.parameters.LockReason, reason)
+				}
+			}
+
+			if tc.parameters.Reaction != "" {
+				if assert.Equal(t, 1, github.AddReactionCallCount()) {
+					pr, commentID, reaction := github.AddReactionArgsForCall(0)
+					assert.Equal(t, tc.version.PR, pr)
+					assert.Equal(t, tc.parameters.ReactionCommentID, commentID)
+					assert.Equal(t, tc.parameters.Reaction, reaction)
+				}
+			}
+		})
+	}
+}
+
+func TestPutCommentOutcome(t *testing.T) {
+	tests := []struct {
+		description     string
+		parameters      resource.PutParameters
+		expectedComment string
+	}{
+		{
+			description: "comment is posted when the outcome is listed in comment_on",
+			parameters: resource.PutParameters{
+				Comment:   "build failed",
+				CommentOn: []string{"failure"},
+				Outcome:   "failure",
+			},
+			expectedComment: "build failed",
+		},
+		{
+			description: "comment is not posted when the outcome is not listed in comment_on",
+			parameters: resource.PutParameters{
+				Comment:   "build failed",
+				CommentOn: []string{"failure"},
+				Outcome:   "success",
+			},
+		},
+		{
+			description: "comment file is selected by the outcome",
+			parameters: resource.PutParameters{
+				CommentFile: "default.txt",
+				CommentFiles: map[string]string{
+					"success": "success.txt",
+					"failure": "failure.txt",
+				},
+				Outcome: "success",
+			},
+			expectedComment: "success.txt",
+		},
+		{
+			description: "comment file falls back to comment_file for other outcomes",
+			parameters: resource.PutParameters{
+				CommentFile: "default.txt",
+				CommentFiles: map[string]string{
+					"success": "success.txt",
+				},
+				Outcome: "error",
+			},
+			expectedComment: "default.txt",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+			version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			for _, name := range []string{"default.txt", "success.txt", "failure.txt"} {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+			}
+
+			getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+			_, err := resource.Get(getInput, github, git, dir)
+			require.NoError(t, err)
+
+			putInput := resource.PutRequest{Source: source, Params: tc.parameters}
+			_, err = resource.Put(putInput, github, dir)
+			require.NoError(t, err)
+
+			if tc.expectedComment == "" {
+				assert.Equal(t, 0, github.PostCommentCallCount())
+			} else if assert.Equal(t, 1, github.PostCommentCallCount()) {
+				_, comment := github.PostCommentArgsForCall(0)
+				assert.Equal(t, tc.expectedComment, withoutMarker(comment))
+			}
+		})
+	}
+}
+
+func TestPutDryRun(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+	_, err := resource.Get(getInput, github, git, dir)
+	require.NoError(t, err)
+
+	params := resource.PutParameters{
+		DryRun:                 true,
+		Status:                 "failure",
+		Comment:                "comment",
+		DeletePreviousComments: true,
+		Reaction:               "rocket",
+		DismissReviews:         true,
+		OnFailureIssue:         true,
+		Lock:                   boolPtr(true),
+	}
+	output, err := resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+	require.NoError(t, err)
+	assert.Equal(t, version, output.Version)
+
+	assert.Equal(t, 0, github.UpdateCommitStatusCallCount())
+	assert.Equal(t, 0, github.PostCommentCallCount())
+	assert.Equal(t, 0, github.DeletePreviousCommentsCallCount())
+	assert.Equal(t, 0, github.AddReactionCallCount())
+	assert.Equal(t, 0, github.DismissStaleReviewsCallCount())
+	assert.Equal(t, 0, github.CreateOrUpdateIssueCallCount())
+	assert.Equal(t, 0, github.SetLockedCallCount())
+}
+
+func TestPutAuditLog(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+	github.PostCommentReturns(errors.New("secondary rate limit"))
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+	_, err := resource.Get(getInput, github, git, dir)
+	require.NoError(t, err)
+
+	// The audit log is appended to by each put.
+	params := resource.PutParameters{AuditLog: true, Status: "success"}
+	_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+	require.NoError(t, err)
+	params = resource.PutParameters{AuditLog: true, Comment: "password=hunter2"}
+	_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+	require.Error(t, err)
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, ".audit.jsonl"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "hunter2")
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "set_status", entries[0]["action"])
+		assert.Equal(t, "commit1", entries[0]["commit"])
+		assert.Equal(t, "success", entries[0]["status"])
+		assert.Nil(t, entries[0]["error"])
+
+		assert.Equal(t, "post_comment", entries[1]["action"])
+		assert.Equal(t, "pr1", entries[1]["pr"])
+		assert.Equal(t, "secondary rate limit", entries[1]["error"])
+		assert.Contains(t, entries[1]["comment"], "sha256")
+	}
+
+	// The audit log can be written elsewhere.
+	params = resource.PutParameters{AuditLog: true, AuditLogFile: "audit/pull-request.jsonl", Status: "pending"}
+	_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+	require.NoError(t, err)
+	content, err = ioutil.ReadFile(filepath.Join(dir, "audit", "pull-request.jsonl"))
+	require.NoError(t, err)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &entry))
+	assert.Equal(t, "pending", entry["status"])
+}
+
+func TestPutSweepStale(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken", BaseBranch: "master"}
+
+	// Pull requests with recent activity are not stale, regardless of their commits.
+	active := createTestPR(50, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+	active.UpdatedAt = githubv4.DateTime{Time: time.Now().AddDate(0, 0, -2)}
+
+	github := new(fakes.FakeGithub)
+	github.ListPullRequestsReturns([]*resource.PullRequest{
+		createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		createTestPR(20, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		createTestPR(30, "master", false, false, 0, []string{"stale"}, false, githubv4.PullRequestStateOpen),
+		createTestPR(40, "develop", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		active,
+	}, nil)
+
+	// No get step is required to sweep the repository.
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	params := resource.PutParameters{SweepStale: &resource.SweepStaleParameters{Days: 14, Label: "stale", Comment: "#{{.PR}} has been inactive for two weeks.", Close: true}}
+	output, err := resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+	require.NoError(t, err)
+	assert.Equal(t, resource.Version{}, output.Version)
+	assert.Equal(t, resource.Metadata{{Name: "swept", Value: "20,30"}}, output.Metadata)
+
+	// Pull requests which already have the label are not labelled or commented on again.
+	if assert.Equal(t, 1, github.AddLabelsCallCount()) {
+		pr, labels := github.AddLabelsArgsForCall(0)
+		assert.Equal(t, "20", pr)
+		assert.Equal(t, []string{"stale"}, labels)
+	}
+	if assert.Equal(t, 1, github.PostCommentCallCount()) {
+		pr, comment := github.PostCommentArgsForCall(0)
+		assert.Equal(t, "20", pr)
+		assert.Equal(t, "#20 has been inactive for two weeks.", comment)
+	}
+	// Only pull requests which have been labelled for the grace period are closed.
+	if assert.Equal(t, 1, github.ClosePullRequestCallCount()) {
+		assert.Equal(t, "30", github.ClosePullRequestArgsForCall(0))
+	}
+
+	params = resource.PutParameters{SweepStale: &resource.SweepStaleParameters{Days: 14, Label: "stale", Close: true, CloseAfterDays: 31}}
+	_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, github.ClosePullRequestCallCount())
+
+	params = resource.PutParameters{SweepStale: &resource.SweepStaleParameters{Days: 14}}
+	_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+	assert.EqualError(t, err, "invalid parameters: sweep_stale requires at least one of label, comment or close")
+
+	params = resource.PutParameters{SweepStale: &resource.SweepStaleParameters{Days: 14, Close: true}}
+	_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+	assert.EqualError(t, err, "invalid parameters: sweep_stale requires a label to mark pull requests as stale before closing them")
+}
+
+func TestPutLabelByPaths(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+	tests := []struct {
+		description string
+		labels      map[string]string
+		expected    []string
+		expectedErr string
+	}{
+		{
+			description: "labels of matching paths are added once",
+			labels:      map[string]string{"docs": "area/docs", "*.md": "area/docs", "src/api/*": "area/api", "src/ui": "area/ui"},
+			expected:    []string{"area/api", "area/docs"},
+		},
+		{
+			description: "no labels are added without matching paths",
+			labels:      map[string]string{"src/ui": "area/ui"},
+		},
+		{
+			description: "patterns are validated",
+			labels:      map[string]string{"src/[": "area/src"},
+			expectedErr: "invalid parameters: invalid label_by_paths pattern 'src/[': syntax error in pattern",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+			github.GetChangedFilesReturns([]resource.ChangedFileObject{{Path: "README.md"}, {Path: "docs/index.md"}, {Path: "src/api/server.go"}}, nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+			_, err := resource.Get(getInput, github, git, dir)
+			require.NoError(t, err)
+
+			params := resource.PutParameters{LabelByPaths: tc.labels}
+			_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			if tc.expected == nil {
+				assert.Equal(t, 0, github.AddLabelsCallCount())
+				return
+			}
+			if assert.Equal(t, 1, github.AddLabelsCallCount()) {
+				pr, labels := github.AddLabelsArgsForCall(0)
+				assert.Equal(t, "pr1", pr)
+				assert.Equal(t, tc.expected, labels)
+			}
+		})
+	}
+}
+
+func TestPutSizeLabels(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+	tests := []struct {
+		description string
+		changed     int
+		labels      []string
+		thresholds  []int
+		added       string
+		removed     []string
+	}{
+		{
+			description: "size label is added",
+			changed:     5,
+			added:       "size/XS",
+		},
+		{
+			description: "previous size label is replaced",
+			changed:     250,
+			labels:      []string{"bug", "size/S"},
+			added:       "size/L",
+			removed:     []string{"size/S"},
+		},
+		{
+			description: "current size label is kept",
+			changed:     2000,
+			labels:      []string{"size/XXL"},
+		},
+		{
+			description: "thresholds can be configured",
+			changed:     250,
+			thresholds:  []int{100, 200, 300, 400, 500},
+			added:       "size/M",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			pull := createTestPR(1, "master", false, false, 0, tc.labels, false, githubv4.PullRequestStateOpen)
+			pull.Additions, pull.Deletions = tc.changed-tc.changed/2, tc.changed/2
+
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(pull, nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+			_, err := resource.Get(getInput, github, git, dir)
+			require.NoError(t, err)
+
+			params := resource.PutParameters{SizeLabels: true, SizeThresholds: tc.thresholds}
+			_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+			require.NoError(t, err)
+
+			var removed []string
+			for i := 0; i < github.RemoveLabelCallCount(); i++ {
+				_, label := github.RemoveLabelArgsForCall(i)
+				removed = append(removed, label)
+			}
+			assert.Equal(t, tc.removed, removed)
+
+			if tc.added == "" {
+				assert.Equal(t, 0, github.AddLabelsCallCount())
+			} else if assert.Equal(t, 1, github.AddLabelsCallCount()) {
+				_, labels := github.AddLabelsArgsForCall(0)
+				assert.Equal(t, []string{tc.added}, labels)
+			}
+		})
+	}
+}
+
+func TestPutDCO(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+	signed := resource.PullRequestCommit{OID: "1111111111", Message: "One\n\nSigned-off-by: Jane <jane@example.com>", AuthorEmail: "jane@example.com"}
+	unsigned := resource.PullRequestCommit{OID: "2222222222", Message: "Two", AuthorEmail: "jane@example.com"}
+	merge := resource.PullRequestCommit{OID: "3333333333", Message: "Merge branch 'master'", AuthorEmail: "jane@example.com", Parents: 2}
+
+	tests := []struct {
+		description         string
+		commits             []resource.PullRequestCommit
+		expectedStatus      string
+		expectedDescription string
+	}{
+		{
+			description:         "succeeds when all commits are signed off",
+			commits:             []resource.PullRequestCommit{signed, merge},
+			expectedStatus:      "success",
+			expectedDescription: "All commits are signed off",
+		},
+		{
+			description:         "fails when a commit is not signed off",
+			commits:             []resource.PullRequestCommit{signed, unsigned},
+			expectedStatus:      "failure",
+			expectedDescription: "Commits are not signed off by their author: 2222222",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+			github.ListPullRequestCommitsReturns(tc.commits, nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+			_, err := resource.Get(getInput, github, git, dir)
+			require.NoError(t, err)
+
+			params := resource.PutParameters{DCO: true}
+			_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+			require.NoError(t, err)
+
+			assert.Equal(t, "pr1", github.ListPullRequestCommitsArgsForCall(0))
+			if assert.Equal(t, 1, github.UpdateCommitStatusCallCount()) {
+				commit, baseContext, context, status, _, description := github.UpdateCommitStatusArgsForCall(0)
+				assert.Equal(t, "commit1", commit)
+				assert.Equal(t, "", baseContext)
+				assert.Equal(t, "dco", context)
+				assert.Equal(t, tc.expectedStatus, status)
+				assert.Equal(t, tc.expectedDescription, description)
+			}
+		})
+	}
+}
+
+func TestPutValidateTitle(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+	tests := []struct {
+		description     string
+		parameters      resource.ValidateTitleParameters
+		title           string
+		comments        []resource.CommentObject
+		expectedStatus  string
+		expectedComment string
+		expectedUpdate  bool
+	}{
+		{
+			description:    "conventional commit titles are valid",
+			parameters:     resource.ValidateTitleParameters{ConventionalCommits: true},
+			title:          "feat(api)!: add pagination",
+			expectedStatus: "success",
+		},
+		{
+			description:     "titles without a conventional commit type are invalid",
+			parameters:      resource.ValidateTitleParameters{ConventionalCommits: true, Types: []string{"feat", "fix"}},
+			title:           "chore: update dependencies",
+			expectedStatus:  "failure",
+			expectedComment: "The title of this pull request is invalid. Title must follow conventional commits, e.g. \"fix(api): handle empty responses\", with one of the types: feat, fix.",
+		},
+		{
+			description:    "titles matching the pattern are valid",
+			parameters:     resource.ValidateTitleParameters{Pattern: `^[A-Z]+-[0-9]+ `},
+			title:          "PROJ-123 Add pagination",
+			expectedStatus: "success",
+		},
+		{
+			description:     "titles not matching the pattern are invalid",
+			parameters:      resource.ValidateTitleParameters{Pattern: `^[A-Z]+-[0-9]+ `, Comment: "{{.Title}}: {{.Reason}}"},
+			title:           "Add pagination",
+			expectedStatus:  "failure",
+			expectedComment: "Add pagination: Title must match the pattern: ^[A-Z]+-[0-9]+ ",
+		},
+		{
+			description: "the comment about an invalid title is updated in place",
+			parameters:  resource.ValidateTitleParameters{Pattern: `^[A-Z]+-[0-9]+ `},
+			title:       "Add pagination",
+			comments: []resource.CommentObject{
+				{DatabaseID: 1, Body: "comment\n\n<!-- github-pr-resource -->"},
+				{DatabaseID: 2, Body: "The title of this pull request is invalid.\n\n<!-- github-pr-resource context=validate_title -->"},
+			},
+			expectedStatus:  "failure",
+			expectedComment: "The title of this pull request is invalid. Title must match the pattern: ^[A-Z]+-[0-9]+ .",
+			expectedUpdate:  true,
+		},
+		{
+			description: "the comment about an invalid title is updated once the title is valid",
+			parameters:  resource.ValidateTitleParameters{Pattern: `^[A-Z]+-[0-9]+ `},
+			title:       "PROJ-123 Add pagination",
+			comments: []resource.CommentObject{
+				{DatabaseID: 1, Body: "comment\n\n<!-- github-pr-resource -->"},
+				{DatabaseID: 2, Body: "The title of this pull request is invalid.\n\n<!-- github-pr-resource context=validate_title -->"},
+			},
+			expectedStatus:  "success",
+			expectedComment: "The title of this pull request is valid now.",
+			expectedUpdate:  true,
+		},
+		{
+			description: "the comment about a fixed title is not updated again",
+			parameters:  resource.ValidateTitleParameters{Pattern: `^[A-Z]+-[0-9]+ `},
+			title:       "PROJ-123 Add pagination",
+			comments: []resource.CommentObject{
+				{DatabaseID: 2, Body: "The title of this pull request is valid now.\n\n<!-- github-pr-resource context=validate_title -->"},
+			},
+			expectedStatus: "success",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			pull := createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen)
+			pull.Title = tc.title
+
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(pull, nil)
+			github.ListOwnCommentsReturns(tc.comments, nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+			_, err := resource.Get(getInput, github, git, dir)
+			require.NoError(t, err)
+
+			params := resource.PutParameters{ValidateTitle: &tc.parameters}
+			_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+			require.NoError(t, err)
+
+			if assert.Equal(t, 1, github.UpdateCommitStatusCallCount()) {
+				commit, _, context, status, _, _ := github.UpdateCommitStatusArgsForCall(0)
+				assert.Equal(t, "commit1", commit)
+				assert.Equal(t, "title", context)
+				assert.Equal(t, tc.expectedStatus, status)
+			}
+			switch {
+			case tc.expectedComment == "":
+				assert.Equal(t, 0, github.PostCommentCallCount())
+				assert.Equal(t, 0, github.UpdateCommentCallCount())
+			case tc.expectedUpdate:
+				assert.Equal(t, 0, github.PostCommentCallCount())
+				if assert.Equal(t, 1, github.UpdateCommentCallCount()) {
+					id, comment := github.UpdateCommentArgsForCall(0)
+					assert.Equal(t, "2", id)
+					assert.Equal(t, tc.expectedComment, withoutMarker(comment))
+				}
+			case assert.Equal(t, 1, github.PostCommentCallCount()):
+				_, comment := github.PostCommentArgsForCall(0)
+				assert.Contains(t, comment, tc.expectedComment)
+				assert.Contains(t, comment, "<!-- github-pr-resource context=validate_title -->")
+			}
+		})
+	}
+}
+
+func TestPutCommentThrottling(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+	recent := resource.CommentObject{Body: "comment\n\n<!-- github-pr-resource -->", CreatedAt: githubv4.DateTime{Time: time.Now().Add(-time.Minute)}}
+	old := resource.CommentObject{Body: "comment\n\n<!-- github-pr-resource -->", CreatedAt: githubv4.DateTime{Time: time.Now().Add(-time.Hour)}}
+
+	tests := []struct {
+		description string
+		parameters  resource.PutParameters
+		comments    []resource.CommentObject
+		expectList  bool
+		expectPost  bool
+	}{
+		{
+			description: "comments are posted without listing previous comments by default",
+			parameters:  resource.PutParameters{Comment: "comment"},
+			comments:    []resource.CommentObject{recent, old},
+			expectPost:  true,
+		},
+		{
+			description: "comments are posted until max_comments_per_pr is reached",
+			parameters:  resource.PutParameters{Comment: "comment", MaxCommentsPerPR: 3},
+			comments:    []resource.CommentObject{recent, old},
+			expectList:  true,
+			expectPost:  true,
+		},
+		{
+			description: "comments are skipped when max_comments_per_pr is reached",
+			parameters:  resource.PutParameters{Comment: "comment", MaxCommentsPerPR: 2},
+			comments:    []resource.CommentObject{recent, old},
+			expectList:  true,
+		},
+		{
+			description: "comments in other contexts do not count towards max_comments_per_pr",
+			parameters:  resource.PutParameters{Comment: "comment", Context: "lint", MaxCommentsPerPR: 2},
+			comments:    []resource.CommentObject{recent, old},
+			expectList:  true,
+			expectPost:  true,
+		},
+		{
+			description: "the same comment is skipped within the comment_interval",
+			parameters:  resource.PutParameters{Comment: "comment", CommentInterval: resource.Duration(10 * time.Minute)},
+			comments:    []resource.CommentObject{old, recent},
+			expectList:  true,
+		},
+		{
+			description: "the same comment is posted after the comment_interval",
+			parameters:  resource.PutParameters{Comment: "comment", CommentInterval: resource.Duration(10 * time.Minute)},
+			comments:    []resource.CommentObject{old},
+			expectList:  true,
+			expectPost:  true,
+		},
+		{
+			description: "other comments are posted within the comment_interval",
+			parameters:  resource.PutParameters{Comment: "other comment", CommentInterval: resource.Duration(10 * time.Minute)},
+			comments:    []resource.CommentObject{recent},
+			expectList:  true,
+			expectPost:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+			github.ListOwnCommentsReturns(tc.comments, nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+			_, err := resource.Get(getInput, github, git, dir)
+			require.NoError(t, err)
+
+			_, err = resource.Put(resource.PutRequest{Source: source, Params: tc.parameters}, github, dir)
+			require.NoError(t, err)
+
+			if tc.expectList {
+				assert.Equal(t, 1, github.ListOwnCommentsCallCount())
+			} else {
+				assert.Equal(t, 0, github.ListOwnCommentsCallCount())
+			}
+			if tc.expectPost {
+				assert.Equal(t, 1, github.PostCommentCallCount())
+			} else {
+				assert.Equal(t, 0, github.PostCommentCallCount())
+			}
+		})
+	}
+}
+
+func TestPutSplitsLongComments(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+	_, err := resource.Get(getInput, github, git, dir)
+	require.NoError(t, err)
+
+	comment := strings.Repeat(strings.Repeat("x", 99)+"\n", 1000)
+	_, err = resource.Put(resource.PutRequest{Source: source, Params: resource.PutParameters{Comment: comment}}, github, dir)
+	require.NoError(t, err)
+
+	if assert.Equal(t, 2, github.PostCommentCallCount()) {
+		for i := 0; i < 2; i++ {
+			pr, part := github.PostCommentArgsForCall(i)
+			assert.Equal(t, "pr1", pr)
+			assert.True(t, len(part) <= resource.MaxCommentLength)
+			assert.True(t, strings.HasPrefix(part, fmt.Sprintf("(%d/2)", i+1)))
+		}
+	}
+}
+
+func TestPutGistFiles(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+	tests := []struct {
+		description     string
+		parameters      resource.PutParameters
+		files           map[string]string
+		expectedFiles   map[string]string
+		expectedComment string
+		wantErr         string
+	}{
+		{
+			description:     "uploads files and links to the gist",
+			parameters:      resource.PutParameters{GistFiles: []string{"plan/plan.txt", "logs/build.log"}},
+			files:           map[string]string{"plan/plan.txt": "plan", "logs/build.log": "log"},
+			expectedFiles:   map[string]string{"plan.txt": "plan", "build.log": "log"},
+			expectedComment: "Output of build [/ #](/builds/) for commit commit1: https://gist.github.com/1",
+		},
+		{
+			description:     "uses the gist_comment template",
+			parameters:      resource.PutParameters{GistFiles: []string{"plan/plan.txt"}, GistComment: "Plan for {{.Commit}}: {{.GistURL}}"},
+			files:           map[string]string{"plan/plan.txt": "plan"},
+			expectedFiles:   map[string]string{"plan.txt": "plan"},
+			expectedComment: "Plan for commit1: https://gist.github.com/1",
+		},
+		{
+			description: "skips empty files",
+			parameters:  resource.PutParameters{GistFiles: []string{"plan/plan.txt"}},
+			files:       map[string]string{"plan/plan.txt": ""},
+		},
+		{
+			description: "fails on files with the same name",
+			parameters:  resource.PutParameters{GistFiles: []string{"a/plan.txt", "b/plan.txt"}},
+			files:       map[string]string{"a/plan.txt": "a", "b/plan.txt": "b"},
+			wantErr:     "gist files cannot have the same name: plan.txt",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+			github.CreateGistReturns("https://gist.github.com/1", nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+			_, err := resource.Get(getInput, github, git, dir)
+			require.NoError(t, err)
+
+			for name, content := range tc.files {
+				require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+			}
+
+			_, err = resource.Put(resource.PutRequest{Source: source, Params: tc.parameters}, github, dir)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			if tc.expectedFiles == nil {
+				assert.Equal(t, 0, github.CreateGistCallCount())
+				assert.Equal(t, 0, github.PostCommentCallCount())
+				return
+			}
+			if assert.Equal(t, 1, github.CreateGistCallCount()) {
+				_, files := github.CreateGistArgsForCall(0)
+				assert.Equal(t, tc.expectedFiles, files)
+			}
+			if assert.Equal(t, 1, github.PostCommentCallCount()) {
+				_, comment := github.PostCommentArgsForCall(0)
+				assert.Equal(t, tc.expectedComment, withoutMarker(comment))
+			}
+		})
+	}
+}
+
+func TestPutStatusFromOutcome(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+	tests := []struct {
+		outcome             string
+		abortState          string
+		expected            string
+		expectedDescription string
+	}{
+		{outcome: "", expected: "pending"},
+		{outcome: "success", expected: "success"},
+		{outcome: "failure", expected: "failure"},
+		{outcome: "error", expected: "error", expectedDescription: "Concourse CI build errored"},
+		{outcome: "abort", expected: "error", expectedDescription: "Concourse CI build was aborted"},
+		{outcome: "abort", abortState: "failure", expected: "failure", expectedDescription: "Concourse CI build was aborted"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expected+" for outcome "+tc.outcome, func(t *testing.T) {
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+			_, err := resource.Get(getInput, github, git, dir)
+			require.NoError(t, err)
+
+			params := resource.PutParameters{Status: "FROM_OUTCOME", Outcome: tc.outcome, AbortState: tc.abortState}
+			_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+			require.NoError(t, err)
+
+			if assert.Equal(t, 1, github.UpdateCommitStatusCallCount()) {
+				_, _, _, status, _, description := github.UpdateCommitStatusArgsForCall(0)
+				assert.Equal(t, tc.expected, status)
+				assert.Equal(t, tc.expectedDescription, description)
+			}
+		})
+	}
+}
+
+func TestPutTemplatedContext(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+	for name, value := range map[string]string{"BUILD_PIPELINE_NAME": "pipeline", "BUILD_JOB_NAME": "job"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Setenv(name, value)
+	}
+
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+	_, err := resource.Get(getInput, github, git, dir)
+	require.NoError(t, err)
+
+	params := resource.PutParameters{
+		Status:         "success",
+		BaseContext:    "ci/{{.PipelineName}}",
+		Contexts:       []string{"{{.JobName}}", "{{.JobName}}-lint"},
+		StatusTemplate: true,
+	}
+	_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+	require.NoError(t, err)
+
+	if assert.Equal(t, 2, github.UpdateCommitStatusCallCount()) {
+		for i, expected := range []string{"job", "job-lint"} {
+			_, baseContext, context, _, _, _ := github.UpdateCommitStatusArgsForCall(i)
+			assert.Equal(t, "ci/pipeline", baseContext)
+			assert.Equal(t, expected, context)
+		}
+	}
+}
+
+func TestPutContextNamespace(t *testing.T) {
+	tests := []struct {
+		description string
+		baseContext string
+		expected    string
+	}{
+		{
+			description: "prefixes the default base context",
+			expected:    "team-a/concourse-ci",
+		},
+		{
+			description: "prefixes the base context",
+			baseContext: "ci",
+			expected:    "team-a/ci",
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.description, func(t *testing.T) {
+			source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken", ContextNamespace: "team-a"}
+			version := resource.Version{PR: "pr1", Commit: "commit1"}
+
 			github := new(fakes.FakeGithub)
-			github.GetPullRequestReturns(tc.pullRequest, nil)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
 
 			git := new(fakes.FakeGit)
 			git.RevParseReturns("sha", nil)
@@ -173,51 +1282,220 @@ func TestPut(t *testing.T) {
 			dir := createTestDirectory(t)
 			defer os.RemoveAll(dir)
 
-			// Run get so we have version and metadata for the put request
-			// (This is tested in in_test.go)
-			getInput := resource.GetRequest{Source: tc.source, Version: tc.version, Params: resource.GetParameters{}}
+			getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
 			_, err := resource.Get(getInput, github, git, dir)
 			require.NoError(t, err)
 
-			putInput := resource.PutRequest{Source: tc.source, Params: tc.parameters}
-			output, err := resource.Put(putInput, github, dir)
+			params := resource.PutParameters{
+				Status:                 "success",
+				BaseContext:            tc.baseContext,
+				Comment:                "build succeeded",
+				DeletePreviousComments: true,
+			}
+			_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+			require.NoError(t, err)
 
-			// Validate output
-			if assert.NoError(t, err) {
-				assert.Equal(t, tc.version, output.Version)
+			if assert.Equal(t, 1, github.UpdateCommitStatusCallCount()) {
+				_, baseContext, _, _, _, _ := github.UpdateCommitStatusArgsForCall(0)
+				assert.Equal(t, tc.expected, baseContext)
 			}
 
-			// Validate method calls put on Github.
-			if tc.parameters.Status != "" {
-				if assert.Equal(t, 1, github.UpdateCommitStatusCallCount()) {
-					commit, baseContext, context, status, targetURL, description := github.UpdateCommitStatusArgsForCall(0)
-					assert.Equal(t, tc.version.Commit, commit)
-					assert.Equal(t, tc.parameters.BaseContext, baseContext)
-					assert.Equal(t, tc.parameters.Context, context)
-					assert.Equal(t, tc.parameters.TargetURL, targetURL)
-					assert.Equal(t, tc.parameters.Description, description)
-					assert.Equal(t, tc.parameters.Status, status)
-				}
+			// Only the comments of the namespace are deleted.
+			marker := "<!-- github-pr-resource namespace=team-a -->"
+			if assert.Equal(t, 1, github.DeletePreviousCommentsCallCount()) {
+				_, m, _ := github.DeletePreviousCommentsArgsForCall(0)
+				assert.Equal(t, marker, m)
+			}
+			if assert.Equal(t, 1, github.PostCommentCallCount()) {
+				_, comment := github.PostCommentArgsForCall(0)
+				assert.Equal(t, "build succeeded\n\n"+marker, comment)
 			}
+		})
+	}
+}
 
-			if tc.parameters.Comment != "" {
-				if assert.Equal(t, 1, github.PostCommentCallCount()) {
-					pr, comment := github.PostCommentArgsForCall(0)
-					assert.Equal(t, tc.version.PR, pr)
-					assert.Equal(t, tc.parameters.Comment, comment)
+func TestPutUpdateComment(t *testing.T) {
+	for name, value := range map[string]string{"BUILD_PIPELINE_NAME": "pipeline", "BUILD_JOB_NAME": "job"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Setenv(name, value)
+	}
+	marker := "<!-- github-pr-resource pipeline=pipeline job=job context=unit -->"
+
+	comment := func(id int64, body string) resource.CommentObject {
+		return resource.CommentObject{DatabaseID: id, Body: body, CreatedAt: githubv4.DateTime{Time: time.Now()}}
+	}
+
+	tests := []struct {
+		description   string
+		comments      []resource.CommentObject
+		expectUpdate  string
+		expectPosting bool
+	}{
+		{
+			description: "updates the last comment of the job",
+			comments: []resource.CommentObject{
+				comment(1, "tests failed\n\n"+marker),
+				comment(2, "tests failed\n\n"+marker),
+				comment(3, "lint failed\n\n<!-- github-pr-resource pipeline=pipeline job=job context=lint -->"),
+			},
+			expectUpdate: "2",
+		},
+		{
+			description: "posts a comment if the job has not commented yet",
+			comments: []resource.CommentObject{
+				comment(1, "tests failed"),
+				comment(2, "tests failed\n\n<!-- github-pr-resource pipeline=pipeline job=other context=unit -->"),
+			},
+			expectPosting: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+			version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+			github := new(fakes.FakeGithub)
+			github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+			github.ListOwnCommentsReturns(tc.comments, nil)
+
+			git := new(fakes.FakeGit)
+			git.RevParseReturns("sha", nil)
+
+			dir := createTestDirectory(t)
+			defer os.RemoveAll(dir)
+
+			getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+			_, err := resource.Get(getInput, github, git, dir)
+			require.NoError(t, err)
+
+			params := resource.PutParameters{Context: "unit", Comment: "tests passed", UpdateComment: true}
+			_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+			require.NoError(t, err)
+
+			if tc.expectUpdate != "" {
+				if assert.Equal(t, 1, github.UpdateCommentCallCount()) {
+					id, body := github.UpdateCommentArgsForCall(0)
+					assert.Equal(t, tc.expectUpdate, id)
+					assert.Equal(t, "tests passed\n\n"+marker, body)
 				}
+			} else {
+				assert.Equal(t, 0, github.UpdateCommentCallCount())
 			}
-
-			if tc.parameters.DeletePreviousComments {
-				if assert.Equal(t, 1, github.DeletePreviousCommentsCallCount()) {
-					pr := github.DeletePreviousCommentsArgsForCall(0)
-					assert.Equal(t, tc.version.PR, pr)
+			if tc.expectPosting {
+				if assert.Equal(t, 1, github.PostCommentCallCount()) {
+					_, body := github.PostCommentArgsForCall(0)
+					assert.Equal(t, "tests passed\n\n"+marker, body)
 				}
+			} else {
+				assert.Equal(t, 0, github.PostCommentCallCount())
 			}
 		})
 	}
 }
 
+func TestPutDeleteCommentsOlderThan(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+	_, err := resource.Get(getInput, github, git, dir)
+	require.NoError(t, err)
+
+	params := resource.PutParameters{DeleteCommentsOlderThan: resource.Duration(24 * time.Hour)}
+	_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+	require.NoError(t, err)
+
+	if assert.Equal(t, 1, github.DeletePreviousCommentsCallCount()) {
+		pr, _, before := github.DeletePreviousCommentsArgsForCall(0)
+		assert.Equal(t, "pr1", pr)
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), before, time.Minute)
+	}
+}
+
+func TestPutIdempotent(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	defer os.Setenv("BUILD_ID", os.Getenv("BUILD_ID"))
+	os.Setenv("BUILD_ID", "1")
+
+	put := func(commit string, comments []resource.CommentObject) *fakes.FakeGithub {
+		github := new(fakes.FakeGithub)
+		github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+		github.ListOwnCommentsReturns(comments, nil)
+
+		git := new(fakes.FakeGit)
+		git.RevParseReturns("sha", nil)
+
+		dir := createTestDirectory(t)
+		defer os.RemoveAll(dir)
+
+		getInput := resource.GetRequest{Source: source, Version: resource.Version{PR: "pr1", Commit: commit}, Params: resource.GetParameters{}}
+		_, err := resource.Get(getInput, github, git, dir)
+		require.NoError(t, err)
+
+		params := resource.PutParameters{Comment: "tests failed", Idempotent: true}
+		_, err = resource.Put(resource.PutRequest{Source: source, Params: params}, github, dir)
+		require.NoError(t, err)
+		return github
+	}
+
+	first := put("commit1", nil)
+	require.Equal(t, 1, first.PostCommentCallCount())
+	_, body := first.PostCommentArgsForCall(0)
+	assert.Equal(t, "tests failed", withoutMarker(body))
+	assert.Contains(t, body, "<!-- github-pr-resource-key=")
+
+	// A retry does not post the comment again.
+	posted := []resource.CommentObject{{DatabaseID: 1, Body: body}}
+	assert.Equal(t, 0, put("commit1", posted).PostCommentCallCount())
+
+	// The same comment is posted for another commit.
+	assert.Equal(t, 1, put("commit2", posted).PostCommentCallCount())
+
+	// And by another build of the job.
+	os.Setenv("BUILD_ID", "2")
+	assert.Equal(t, 1, put("commit1", posted).PostCommentCallCount())
+}
+
+func TestPutRateLimitMetadata(t *testing.T) {
+	source := resource.Source{Repository: "itsdalmo/test-repository", AccessToken: "oauthtoken"}
+	version := resource.Version{PR: "pr1", Commit: "commit1"}
+	resetAt := time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	github := new(fakes.FakeGithub)
+	github.GetPullRequestReturns(createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen), nil)
+	github.GetRateLimitReturnsOnCall(0, &resource.RateLimit{Limit: 5000, Remaining: 4400, ResetAt: githubv4.DateTime{Time: resetAt}}, nil)
+	github.GetRateLimitReturnsOnCall(1, &resource.RateLimit{Limit: 5000, Remaining: 4321, ResetAt: githubv4.DateTime{Time: resetAt}}, nil)
+
+	git := new(fakes.FakeGit)
+	git.RevParseReturns("sha", nil)
+
+	dir := createTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	getInput := resource.GetRequest{Source: source, Version: version, Params: resource.GetParameters{}}
+	getOutput, err := resource.Get(getInput, github, git, dir)
+	require.NoError(t, err)
+	assert.Contains(t, getOutput.Metadata, &resource.MetadataField{Name: "rate_limit_remaining", Value: "4400"})
+	assert.Contains(t, getOutput.Metadata, &resource.MetadataField{Name: "rate_limit_reset_at", Value: "2020-05-01T12:00:00Z"})
+
+	// The rate limit replaces the one in the metadata of the get.
+	output, err := resource.Put(resource.PutRequest{Source: source}, github, dir)
+	require.NoError(t, err)
+	assert.Contains(t, output.Metadata, &resource.MetadataField{Name: "rate_limit_remaining", Value: "4321"})
+	assert.NotContains(t, output.Metadata, &resource.MetadataField{Name: "rate_limit_remaining", Value: "4400"})
+	assert.Contains(t, output.Metadata, &resource.MetadataField{Name: "rate_limit_reset_at", Value: "2020-05-01T12:00:00Z"})
+}
+
 func TestVariableSubstitution(t *testing.T) {
 
 	var (
@@ -227,13 +1505,14 @@ func TestVariableSubstitution(t *testing.T) {
 	)
 
 	tests := []struct {
-		description       string
-		source            resource.Source
-		version           resource.Version
-		parameters        resource.PutParameters
-		expectedComment   string
-		expectedTargetURL string
-		pullRequest       *resource.PullRequest
+		description         string
+		source              resource.Source
+		version             resource.Version
+		parameters          resource.PutParameters
+		expectedComment     string
+		expectedTargetURL   string
+		expectedDescription string
+		pullRequest         *resource.PullRequest
 	}{
 
 		{
@@ -273,6 +1552,65 @@ func TestVariableSubstitution(t *testing.T) {
 			pullRequest:       createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
 		},
 
+		{
+			description: "we can use template variables in TargetURL",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+			},
+			parameters: resource.PutParameters{
+				Status:         "failure",
+				TargetURL:      variableURL + "{{.JobName}}/{{.PR}}/{{.Commit}}",
+				StatusTemplate: true,
+			},
+			expectedTargetURL: fmt.Sprintf("%s%s/pr1/commit1", variableURL, variableValue),
+			pullRequest:       createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		},
+
+		{
+			description: "we can use template variables in Description",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+			},
+			parameters: resource.PutParameters{
+				Status:         "SUCCESS",
+				Description:    "{{.JobName}} {{.Status}} for #{{.PR}}",
+				StatusTemplate: true,
+			},
+			expectedDescription: fmt.Sprintf("%s success for #pr1", variableValue),
+			pullRequest:         createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		},
+
+		{
+			description: "we do not render descriptions as templates unless enabled",
+			source: resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+			},
+			version: resource.Version{
+				PR:            "pr1",
+				Commit:        "commit1",
+				CommittedDate: time.Time{},
+			},
+			parameters: resource.PutParameters{
+				Status:      "SUCCESS",
+				Description: "lint: unexpected {{ in template.go",
+			},
+			expectedDescription: "lint: unexpected {{ in template.go",
+			pullRequest:         createTestPR(1, "master", false, false, 0, nil, false, githubv4.PullRequestStateOpen),
+		},
+
 		{
 			description: "we do not substitute variables other then concourse build metadata",
 			source: resource.Source{
@@ -323,13 +1661,32 @@ func TestVariableSubstitution(t *testing.T) {
 				}
 			}
 
+			if tc.parameters.Description != "" {
+				if assert.Equal(t, 1, github.UpdateCommitStatusCallCount()) {
+					_, _, _, _, _, description := github.UpdateCommitStatusArgsForCall(0)
+					assert.Equal(t, tc.expectedDescription, description)
+				}
+			}
+
 			if tc.parameters.Comment != "" {
 				if assert.Equal(t, 1, github.PostCommentCallCount()) {
 					_, comment := github.PostCommentArgsForCall(0)
-					assert.Equal(t, tc.expectedComment, comment)
+					assert.Equal(t, tc.expectedComment, withoutMarker(comment))
 				}
 			}
 
 		})
 	}
 }
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// withoutMarker removes the marker which put adds to comments.
+func withoutMarker(comment string) string {
+	if i := strings.LastIndex(comment, "\n\n<!-- github-pr-resource"); i >= 0 {
+		return comment[:i]
+	}
+	return comment
+}
@@ -0,0 +1,83 @@
+package resource
+
+import (
+	"strconv"
+	"time"
+)
+
+// Provenance of the source fetched by get, as an in-toto statement with a SLSA provenance
+// (v0.2) predicate, so that supply chain attestation tooling can consume it as is.
+// https://slsa.dev/provenance/v0.2
+type Provenance struct {
+	Type          string               `json:"_type"`
+	Subject       []ProvenanceArtifact `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     ProvenancePredicate  `json:"predicate"`
+}
+
+// ProvenanceArtifact is a subject or material of the provenance.
+type ProvenanceArtifact struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+// ProvenancePredicate describes how the source was fetched.
+type ProvenancePredicate struct {
+	Builder struct {
+		ID string `json:"id"`
+	} `json:"builder"`
+	BuildType  string `json:"buildType"`
+	Invocation struct {
+		Parameters  GetParameters          `json:"parameters"`
+		Environment map[string]interface{} `json:"environment"`
+	} `json:"invocation"`
+	Metadata struct {
+		BuildStartedOn  time.Time `json:"buildStartedOn"`
+		BuildFinishedOn time.Time `json:"buildFinishedOn"`
+	} `json:"metadata"`
+	Materials []ProvenanceArtifact `json:"materials"`
+}
+
+// provenanceBuilder identifies the resource as the builder of the provenance.
+const provenanceBuilder = "https://github.com/telia-oss/github-pr-resource"
+
+// NewProvenance of a pull request fetched at the given version and base. The subject is
+// the commit that was checked out, which is the result of the integration (e.g. merging
+// the pull request into the base) rather than the head of the pull request.
+func NewProvenance(version Version, params GetParameters, pull *PullRequest, baseSHA, checkoutSHA string, started, finished time.Time) Provenance {
+	head := ProvenanceArtifact{
+		URI:    "git+" + pull.Repository.URL + "@refs/pull/" + strconv.Itoa(pull.Number) + "/head",
+		Digest: map[string]string{"sha1": pull.Tip.OID},
+	}
+
+	var p Provenance
+	p.Type = "https://in-toto.io/Statement/v0.1"
+	p.Subject = []ProvenanceArtifact{{Name: pull.URL, Digest: map[string]string{"sha1": checkoutSHA}}}
+	p.PredicateType = "https://slsa.dev/provenance/v0.2"
+	p.Predicate.Builder.ID = provenanceBuilder
+	p.Predicate.BuildType = provenanceBuilder + "/get@v1"
+	p.Predicate.Invocation.Parameters = params
+	p.Predicate.Invocation.Environment = map[string]interface{}{
+		"repository":     pull.Repository.URL,
+		"pr":             pull.Number,
+		"head_name":      pull.HeadRefName,
+		"head_sha":       pull.Tip.OID,
+		"base_name":      pull.BaseRefName,
+		"base_sha":       baseSHA,
+		"author":         pull.Author.Login,
+		"commit_author":  pull.Tip.Author.User.Login,
+		"committed_date": pull.Tip.CommittedDate.Time,
+		"version":        version,
+	}
+	p.Predicate.Metadata.BuildStartedOn = started.UTC()
+	p.Predicate.Metadata.BuildFinishedOn = finished.UTC()
+	p.Predicate.Materials = []ProvenanceArtifact{head}
+	if baseSHA != "" {
+		p.Predicate.Materials = append(p.Predicate.Materials, ProvenanceArtifact{
+			URI:    "git+" + pull.Repository.URL + "@refs/heads/" + pull.BaseRefName,
+			Digest: map[string]string{"sha1": baseSHA},
+		})
+	}
+	return p
+}
@@ -0,0 +1,12 @@
+// +build !windows
+
+package resource
+
+import (
+	"os/exec"
+)
+
+// shellCommand runs a command line with sh.
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,10 @@
+package resource
+
+import (
+	"os/exec"
+)
+
+// shellCommand runs a command line with cmd, since Windows workers do not have sh.
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("cmd", "/C", command)
+}
@@ -0,0 +1,123 @@
+package resource
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// CheckState is kept in the cache directory between check invocations, so that
+// check can skip fetching data for pull requests which have not moved. With
+// pages_per_check, it also holds the cursor to resume listing pull requests from,
+// and when the current and previous cycles through all pages were started. With
+// api_budget_per_check, it holds the pull requests (and the date since which merge
+// groups are listed) which were skipped when the budget was exhausted.
+type CheckState struct {
+	PullRequests         map[int]CachedPullRequest `json:"pull_requests"`
+	Cursor               string                    `json:"cursor,omitempty"`
+	CycleStarted         time.Time                 `json:"cycle_started,omitempty"`
+	PreviousCycleStarted time.Time                 `json:"previous_cycle_started,omitempty"`
+	Seen                 []int                     `json:"seen,omitempty"`
+	Pending              []int                     `json:"pending,omitempty"`
+	MergeQueueSince      *time.Time                `json:"merge_queue_since,omitempty"`
+
+	path string
+	seen map[int]bool
+}
+
+// CachedPullRequest holds the data fetched for a pull request at a given tip.
+type CachedPullRequest struct {
+	Commit string              `json:"commit"`
+	Files  []ChangedFileObject `json:"files"`
+}
+
+// LoadCheckState for the repository from the cache directory. A missing or
+// corrupt state file results in an empty state.
+func LoadCheckState(dir, repository string) *CheckState {
+	h := sha256.Sum256([]byte(repository))
+	s := &CheckState{
+		PullRequests: make(map[int]CachedPullRequest),
+		path:         filepath.Join(dir, "check-"+hex.EncodeToString(h[:8])+".json"),
+		seen:         make(map[int]bool),
+	}
+	content, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return s
+	}
+	if err := json.Unmarshal(content, s); err != nil {
+		logger.Warn("ignoring corrupt check state", "path", s.path, "error", err)
+		*s = CheckState{PullRequests: make(map[int]CachedPullRequest), path: s.path, seen: s.seen}
+	}
+	if s.PullRequests == nil {
+		s.PullRequests = make(map[int]CachedPullRequest)
+	}
+	for _, n := range s.Seen {
+		s.seen[n] = true
+	}
+	return s
+}
+
+// NextPages starts a new cycle through the pages of pull requests if the previous cycle
+// has been completed, and returns the cursor to resume listing pull requests from.
+func (s *CheckState) NextPages(now time.Time) string {
+	if s.Cursor == "" {
+		s.PreviousCycleStarted = s.CycleStarted
+		s.CycleStarted = now
+		s.seen = make(map[int]bool)
+	}
+	return s.Cursor
+}
+
+// IsPending returns true if the pull request was skipped by the previous check because
+// the API budget was exhausted.
+func (s *CheckState) IsPending(number int) bool {
+	for _, n := range s.Pending {
+		if n == number {
+			return true
+		}
+	}
+	return false
+}
+
+// Files returns the modified files of the pull request if its tip has not moved.
+func (s *CheckState) Files(p *PullRequest) ([]ChangedFileObject, bool) {
+	s.seen[p.Number] = true
+	c, ok := s.PullRequests[p.Number]
+	if !ok || c.Commit != p.Tip.OID {
+		return nil, false
+	}
+	return c.Files, true
+}
+
+// SetFiles records the modified files of the pull request at its current tip.
+func (s *CheckState) SetFiles(p *PullRequest, files []ChangedFileObject) {
+	s.seen[p.Number] = true
+	s.PullRequests[p.Number] = CachedPullRequest{Commit: p.Tip.OID, Files: files}
+}
+
+// Save the state, dropping pull requests which were not seen since it was loaded, or
+// during the current cycle through the pages of pull requests until it is completed.
+func (s *CheckState) Save() error {
+	s.Seen = nil
+	if s.Cursor != "" {
+		for n := range s.seen {
+			s.Seen = append(s.Seen, n)
+		}
+		sort.Ints(s.Seen)
+	} else {
+		for n := range s.PullRequests {
+			if !s.seen[n] {
+				delete(s.PullRequests, n)
+			}
+		}
+	}
+	content, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(filepath.Dir(s.path), s.path, content)
+}
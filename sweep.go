@@ -0,0 +1,143 @@
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// SweepStaleParameters configure put to sweep the stale pull requests of the repository,
+// instead of updating the pull request of the version.
+type SweepStaleParameters struct {
+	Days           int    `json:"days"`
+	Label          string `json:"label"`
+	Comment        string `json:"comment"`
+	Close          bool   `json:"close"`
+	CloseAfterDays int    `json:"close_after_days"`
+}
+
+// defaultCloseAfterDays is the grace period between labelling a pull request as stale
+// and closing it.
+const defaultCloseAfterDays = 7
+
+// Validate the sweep parameters.
+func (s *SweepStaleParameters) Validate() error {
+	if s.Days <= 0 {
+		return errors.New("sweep_stale requires days to be positive")
+	}
+	if s.Label == "" && s.Comment == "" && !s.Close {
+		return errors.New("sweep_stale requires at least one of label, comment or close")
+	}
+	if s.Close && s.Label == "" {
+		return errors.New("sweep_stale requires a label to mark pull requests as stale before closing them")
+	}
+	if s.CloseAfterDays < 0 {
+		return errors.New("sweep_stale close_after_days cannot be negative")
+	}
+	return nil
+}
+
+// sweepable returns true if the pull request matches the filters of the source which do
+// not require additional requests (i.e. all but paths and review approvals).
+func sweepable(source Source, p *PullRequest) bool {
+	if source.BaseBranch != "" && p.BaseRefName != source.BaseBranch {
+		return false
+	}
+	if MatchesAnyPattern(source.IgnoreBaseBranches, p.BaseRefName) {
+		return false
+	}
+	if len(source.Labels) > 0 {
+		var found bool
+		for _, l := range p.Labels {
+			found = found || containsString(source.Labels, l.Name)
+		}
+		if !found {
+			return false
+		}
+	}
+	if source.DisableForks && p.IsCrossRepository {
+		return false
+	}
+	if (source.IgnoreDrafts && p.IsDraft) || (source.OnlyDrafts && !p.IsDraft) {
+		return false
+	}
+	return true
+}
+
+// sweepStale labels and/or comments on the open pull requests matching the source which
+// have had no activity (commits, comments, reviews etc.) for the configured number of
+// days. Pull requests which already have the label have already been swept, and are not
+// commented on again, but are closed (if enabled) once they have had no activity since
+// being labelled for the grace period.
+func sweepStale(request PutRequest, manager Github, path string, now time.Time) (*PutResponse, error) {
+	s := request.Params.SweepStale
+	pulls, err := manager.ListPullRequests([]githubv4.PullRequestState{githubv4.PullRequestStateOpen}, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last commits: %w", err)
+	}
+
+	closeAfter := s.CloseAfterDays
+	if closeAfter == 0 {
+		closeAfter = defaultCloseAfterDays
+	}
+	cutoff := now.Add(-time.Duration(s.Days) * 24 * time.Hour)
+	closeCutoff := now.Add(-time.Duration(closeAfter) * 24 * time.Hour)
+	var swept []string
+	for _, p := range pulls {
+		if !sweepable(request.Source, p) || p.UpdatedAt.After(cutoff) {
+			continue
+		}
+		pr := strconv.Itoa(p.Number)
+		var labelled bool
+		for _, l := range p.Labels {
+			labelled = labelled || (s.Label != "" && l.Name == s.Label)
+		}
+
+		logger.Info("sweeping stale pull request", "pr", pr, "updated", p.UpdatedAt.Time, "labelled", labelled)
+		if s.Label != "" && !labelled {
+			if err := manager.AddLabels(pr, []string{s.Label}); err != nil {
+				return nil, fmt.Errorf("failed to label pull request %s: %w", pr, err)
+			}
+		}
+		if s.Comment != "" && !labelled {
+			data := NewTemplateData(NewVersion(p))
+			comment, err := RenderTemplate("sweep_stale.comment", s.Comment, data)
+			if err != nil {
+				return nil, err
+			}
+			if err := manager.PostComment(pr, safeExpandEnv(comment)); err != nil {
+				return nil, fmt.Errorf("failed to comment on pull request %s: %w", pr, err)
+			}
+		}
+		// Labelling the pull request is activity, so it has been labelled for at least as
+		// long as it has not been updated.
+		if s.Close && labelled && !p.UpdatedAt.After(closeCutoff) {
+			if err := manager.ClosePullRequest(pr); err != nil {
+				return nil, fmt.Errorf("failed to close pull request %s: %w", pr, err)
+			}
+		}
+		swept = append(swept, pr)
+	}
+
+	// Put has to return a version, which is the version of the get step if there is one.
+	var version Version
+	if content, err := ioutil.ReadFile(filepath.Join(path, "version.json")); err == nil {
+		if err := json.Unmarshal(content, &version); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal version from file: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read version from path: %w", err)
+	}
+
+	var metadata Metadata
+	metadata.Add("swept", strings.Join(swept, ","))
+	return &PutResponse{Version: version, Metadata: metadata}, nil
+}
@@ -0,0 +1,263 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// TemplateData is the data available when rendering templated put parameters.
+type TemplateData struct {
+	PR           string
+	Commit       string
+	BuildID      string
+	BuildName    string
+	JobName      string
+	PipelineName string
+	TeamName     string
+	ExternalURL  string
+	BuildURL     string
+	Status       string
+	Duration     string
+	GistURL      string
+}
+
+// NewTemplateData populates template data from the version and the Concourse build metadata.
+func NewTemplateData(version Version) TemplateData {
+	d := TemplateData{
+		PR:           version.PR,
+		Commit:       version.Commit,
+		BuildID:      os.Getenv("BUILD_ID"),
+		BuildName:    os.Getenv("BUILD_NAME"),
+		JobName:      os.Getenv("BUILD_JOB_NAME"),
+		PipelineName: os.Getenv("BUILD_PIPELINE_NAME"),
+		TeamName:     os.Getenv("BUILD_TEAM_NAME"),
+		ExternalURL:  os.Getenv("ATC_EXTERNAL_URL"),
+	}
+	d.BuildURL = strings.Join([]string{d.ExternalURL, "builds", d.BuildID}, "/")
+	return d
+}
+
+// RenderTemplate renders a text/template using the given data.
+func RenderTemplate(name, text string, data interface{}) (string, error) {
+	return renderTemplate(name, text, data, nil)
+}
+
+// RenderComment renders a comment as a text/template using the given data, and the
+// helpers returned by CommentFuncs for files in the given directory.
+func RenderComment(name, text string, data interface{}, dir string) (string, error) {
+	return renderTemplate(name, text, data, CommentFuncs(dir))
+}
+
+func renderTemplate(name, text string, data interface{}, funcs template.FuncMap) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %s", name, err)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %s", name, err)
+	}
+	return b.String(), nil
+}
+
+// MaxCommentLength is the maximum length of a comment on Github.
+const MaxCommentLength = 65536
+
+// truncatedNotice is appended to text which has been truncated.
+const truncatedNotice = "\n\n... output truncated ..."
+
+// CommentFuncs returns the helpers available in comment templates, which read files
+// relative to the given directory:
+//
+//	{{ file "plan/plan.txt" }}           the contents of a file
+//	{{ code "hcl" "text" }}              a fenced code block
+//	{{ details "summary" "text" }}       a collapsible section
+//	{{ table "report/results.csv" }}     a table from a CSV or JSON file
+//	{{ truncate "text" }}                text truncated to fit in a comment
+//	{{ truncateTo 1000 "text" }}         text truncated to the given length
+//
+// The text is the last argument, so that helpers can be chained, e.g.
+// {{ file "plan/plan.txt" | truncate | code "hcl" | details "Plan" }}.
+func CommentFuncs(dir string) template.FuncMap {
+	return template.FuncMap{
+		"file": func(name string) (string, error) {
+			content, err := ioutil.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				return "", fmt.Errorf("failed to read file: %s", err)
+			}
+			return string(content), nil
+		},
+		"code": func(lang, text string) string {
+			return "```" + lang + "\n" + strings.TrimSuffix(text, "\n") + "\n```"
+		},
+		"details": func(summary, text string) string {
+			return "<details><summary>" + summary + "</summary>\n\n" + text + "\n\n</details>"
+		},
+		"table": func(name string) (string, error) {
+			return markdownTable(filepath.Join(dir, name))
+		},
+		"truncate": func(text string) string {
+			// Leave room for the rest of the comment.
+			return Truncate(MaxCommentLength-4096, text)
+		},
+		"truncateTo": Truncate,
+	}
+}
+
+// Truncate text to at most n bytes, including a notice that it was truncated. The text
+// is cut at the start of a character, so that multibyte characters are not split.
+func Truncate(n int, text string) string {
+	if len(text) <= n {
+		return text
+	}
+	var notice string
+	if n >= len(truncatedNotice) {
+		n, notice = n-len(truncatedNotice), truncatedNotice
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n] + notice
+}
+
+// markdownTable reads a CSV file (with a header row), or a JSON file containing
+// an array of objects, and renders it as a markdown table.
+func markdownTable(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %s", err)
+	}
+
+	var rows [][]string
+	switch filepath.Ext(path) {
+	case ".csv":
+		rows, err = csv.NewReader(bytes.NewReader(content)).ReadAll()
+		if err != nil {
+			return "", fmt.Errorf("failed to parse csv: %s", err)
+		}
+	case ".json":
+		var objects []map[string]interface{}
+		if err := json.Unmarshal(content, &objects); err != nil {
+			return "", fmt.Errorf("failed to parse json: %s", err)
+		}
+		var header []string
+		seen := make(map[string]bool)
+		for _, o := range objects {
+			var keys []string
+			for k := range o {
+				if !seen[k] {
+					seen[k] = true
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			header = append(header, keys...)
+		}
+		rows = append(rows, header)
+		for _, o := range objects {
+			var row []string
+			for _, k := range header {
+				if v, ok := o[k]; ok && v != nil {
+					row = append(row, fmt.Sprint(v))
+				} else {
+					row = append(row, "")
+				}
+			}
+			rows = append(rows, row)
+		}
+	default:
+		return "", fmt.Errorf("unsupported table format: %s", path)
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+
+	escape := strings.NewReplacer("|", "\\|", "\n", " ")
+	var b strings.Builder
+	for i, row := range rows {
+		b.WriteString("|")
+		for _, cell := range row {
+			b.WriteString(" " + escape.Replace(cell) + " |")
+		}
+		b.WriteString("\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", len(row)) + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// SplitComment splits a comment which is longer than max into a numbered series of
+// comments, splitting on line breaks where possible. Code blocks which are split are
+// closed at the end of a comment and reopened at the start of the next one.
+func SplitComment(comment string, max int) []string {
+	if len(comment) <= max {
+		return []string{comment}
+	}
+	// Leave room for the numbering and code fences.
+	const reserved = 128
+	limit := max - reserved
+
+	var parts []string
+	var fence string
+	for rest := comment; rest != ""; {
+		var prefix string
+		if fence != "" {
+			prefix = fence + "\n"
+		}
+		chunk := rest
+		if n := limit - len(prefix); len(chunk) > n {
+			chunk = rest[:n]
+			if i := strings.LastIndex(chunk, "\n"); i > 0 {
+				chunk = chunk[:i+1]
+			} else {
+				for n > 0 && !utf8.RuneStart(rest[n]) {
+					n--
+				}
+				chunk = rest[:n]
+			}
+		}
+		rest = rest[len(chunk):]
+
+		part := prefix + chunk
+		fence = openFence(part)
+		if fence != "" && rest != "" {
+			part = strings.TrimSuffix(part, "\n") + "\n```"
+		}
+		parts = append(parts, part)
+	}
+
+	for i := range parts {
+		parts[i] = fmt.Sprintf("(%d/%d)\n\n", i+1, len(parts)) + parts[i]
+	}
+	return parts
+}
+
+// openFence returns the opening line of the code block which is still open at the
+// end of the text, if any.
+func openFence(text string) string {
+	var fence string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, "```") {
+			continue
+		}
+		if fence == "" {
+			fence = strings.TrimSpace(line)
+			if len(fence) > 64 {
+				fence = "```"
+			}
+		} else {
+			fence = ""
+		}
+	}
+	return fence
+}
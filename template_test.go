@@ -0,0 +1,148 @@
+package resource_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resource "github.com/telia-oss/github-pr-resource"
+)
+
+func TestRenderComment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "github-pr-resource")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"plan.txt":     "+ resource \"aws_s3_bucket\"\n",
+		"results.csv":  "test,result\nunit,pass\nlint,fail | warn\n",
+		"results.json": `[{"test": "unit", "result": "pass"}, {"test": "lint", "duration": 2}]`,
+		"results.xml":  `<results/>`,
+	}
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	tests := []struct {
+		description string
+		template    string
+		expected    string
+		wantErr     string
+	}{
+		{
+			description: "renders template data",
+			template:    "Build for #{{.PR}}",
+			expected:    "Build for #pr1",
+		},
+		{
+			description: "renders files in code blocks and collapsible sections",
+			template:    `{{ file "plan.txt" | code "hcl" | details "Plan" }}`,
+			expected:    "<details><summary>Plan</summary>\n\n```hcl\n+ resource \"aws_s3_bucket\"\n```\n\n</details>",
+		},
+		{
+			description: "renders tables from csv files",
+			template:    `{{ table "results.csv" }}`,
+			expected:    "| test | result |\n| --- | --- |\n| unit | pass |\n| lint | fail \\| warn |\n",
+		},
+		{
+			description: "renders tables from json files",
+			template:    `{{ table "results.json" }}`,
+			expected:    "| result | test | duration |\n| --- | --- | --- |\n| pass | unit |  |\n|  | lint | 2 |\n",
+		},
+		{
+			description: "truncates text",
+			template:    `{{ truncateTo 30 "0123456789012345678901234567890123456789" }}`,
+			expected:    "0123\n\n... output truncated ...",
+		},
+		{
+			description: "does not truncate short text",
+			template:    `{{ file "plan.txt" | truncate }}`,
+			expected:    files["plan.txt"],
+		},
+		{
+			description: "fails on missing files",
+			template:    `{{ file "missing.txt" }}`,
+			wantErr:     "failed to read file",
+		},
+		{
+			description: "fails on unsupported table formats",
+			template:    `{{ table "results.xml" }}`,
+			wantErr:     "unsupported table format",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			data := resource.NewTemplateData(resource.Version{PR: "pr1", Commit: "commit1"})
+			output, err := resource.RenderComment("comment", tc.template, data, dir)
+			if tc.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.wantErr)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, output)
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	long := strings.Repeat("a", resource.MaxCommentLength)
+	output := resource.Truncate(resource.MaxCommentLength-1, long)
+	assert.Len(t, output, resource.MaxCommentLength-1)
+	assert.True(t, strings.HasSuffix(output, "output truncated ..."))
+	assert.Equal(t, "aaa", resource.Truncate(3, long))
+
+	// Multibyte characters are not split.
+	output = resource.Truncate(4, "äöü")
+	assert.Equal(t, "äö", output)
+	assert.True(t, utf8.ValidString(output))
+	output = resource.Truncate(50, strings.Repeat("ä", 100))
+	assert.True(t, utf8.ValidString(output))
+	assert.True(t, strings.HasPrefix(output, strings.Repeat("ä", 12)))
+}
+
+func TestSplitComment(t *testing.T) {
+	assert.Equal(t, []string{"short comment"}, resource.SplitComment("short comment", 1000))
+
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, strings.Repeat("x", 19))
+	}
+	plan := "Plan:\n```hcl\n" + strings.Join(lines, "\n") + "\n```\nDone."
+
+	parts := resource.SplitComment(plan, 1000)
+	require.Len(t, parts, 3)
+	for i, part := range parts {
+		assert.True(t, len(part) <= 1000, "part %d is too long", i)
+		assert.Equal(t, 0, strings.Count(part, "```")%2, "part %d has an unterminated code block", i)
+	}
+	assert.True(t, strings.HasPrefix(parts[0], "(1/3)\n\nPlan:\n```hcl\n"))
+	assert.True(t, strings.HasPrefix(parts[1], "(2/3)\n\n```hcl\nxxx"))
+	assert.True(t, strings.HasSuffix(parts[2], "\n```\nDone."))
+
+	// The content is preserved, apart from the numbering and code fences.
+	var content string
+	for i, part := range parts {
+		part = strings.SplitN(part, "\n\n", 2)[1]
+		if i > 0 {
+			part = strings.TrimPrefix(part, "```hcl\n")
+		}
+		if i < len(parts)-1 {
+			part = strings.TrimSuffix(part, "\n```") + "\n"
+		}
+		content += part
+	}
+	assert.Equal(t, plan, content)
+
+	// Text without line breaks is split without breaking up characters.
+	for _, part := range resource.SplitComment(strings.Repeat("ä", 1000), 1000) {
+		assert.True(t, utf8.ValidString(part))
+	}
+}
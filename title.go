@@ -0,0 +1,89 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// titleContext is the context of the status set by validate_title.
+const titleContext = "title"
+
+// titleCommentContext marks the comment posted by validate_title, which sets it apart
+// from the other comments of the job.
+const titleCommentContext = "validate_title"
+
+// titleResolvedComment replaces the comment posted by validate_title once the title is valid.
+const titleResolvedComment = "The title of this pull request is valid now."
+
+// defaultConventionalTypes are the types of conventional commits allowed by default.
+// https://www.conventionalcommits.org
+var defaultConventionalTypes = []string{"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor", "revert", "style", "test"}
+
+// ValidateTitleParameters configure the validation of pull request titles.
+type ValidateTitleParameters struct {
+	Pattern             string   `json:"pattern"`
+	ConventionalCommits bool     `json:"conventional_commits"`
+	Types               []string `json:"types"`
+	Comment             string   `json:"comment"`
+}
+
+// Validate the title validation parameters.
+func (v *ValidateTitleParameters) Validate() error {
+	if v.Pattern == "" && !v.ConventionalCommits {
+		return errors.New("validate_title requires pattern or conventional_commits")
+	}
+	if _, err := regexp.Compile(v.Pattern); err != nil {
+		return fmt.Errorf("validate_title pattern is invalid: %w", err)
+	}
+	if len(v.Types) > 0 && !v.ConventionalCommits {
+		return errors.New("validate_title types can only be set together with conventional_commits")
+	}
+	return nil
+}
+
+// ValidateTitle returns the reason why the title of a pull request is invalid, or an
+// empty string if it is valid.
+func (v *ValidateTitleParameters) ValidateTitle(title string) string {
+	if v.ConventionalCommits {
+		types := v.Types
+		if len(types) == 0 {
+			types = defaultConventionalTypes
+		}
+		var quoted []string
+		for _, t := range types {
+			quoted = append(quoted, regexp.QuoteMeta(t))
+		}
+		re := regexp.MustCompile(`^(` + strings.Join(quoted, "|") + `)(\([^()]+\))?!?: \S`)
+		if !re.MatchString(title) {
+			return fmt.Sprintf("Title must follow conventional commits, e.g. \"fix(api): handle empty responses\", with one of the types: %s", strings.Join(types, ", "))
+		}
+	}
+	if v.Pattern != "" && !regexp.MustCompile(v.Pattern).MatchString(title) {
+		return fmt.Sprintf("Title must match the pattern: %s", v.Pattern)
+	}
+	return ""
+}
+
+// resolveTitleComment updates the comment posted by validate_title (if any) once the title
+// is valid, so that it does not linger on the pull request after the title was fixed.
+func resolveTitleComment(manager Github, source Source, version Version) error {
+	marker := commentMarker(source.ContextNamespace, titleCommentContext)
+	comments, err := manager.ListOwnComments(version.PR)
+	if err != nil {
+		return fmt.Errorf("failed to list previous comments: %w", err)
+	}
+	resolved := titleResolvedComment + "\n\n" + marker
+	for _, c := range comments {
+		if !strings.Contains(c.Body, marker) || c.Body == resolved {
+			continue
+		}
+		logger.Info("updating comment", "pr", version.PR, "comment_id", c.DatabaseID)
+		if err := manager.UpdateComment(strconv.FormatInt(c.DatabaseID, 10), resolved); err != nil {
+			return fmt.Errorf("failed to update comment: %w", err)
+		}
+	}
+	return nil
+}
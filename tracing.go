@@ -0,0 +1,241 @@
+package resource
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracer records spans and exports them to an OpenTelemetry collector using
+// OTLP/HTTP (JSON encoding). All spans of an invocation belong to one trace,
+// and are children of the first span that was started.
+type Tracer struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+
+	mu      sync.Mutex
+	traceID string
+	root    *Span
+	spans   []*Span
+}
+
+// Span is a timed operation within a trace.
+type Span struct {
+	tracer     *Tracer
+	id         string
+	parentID   string
+	name       string
+	kind       int
+	start      time.Time
+	end        time.Time
+	attributes map[string]string
+	err        error
+}
+
+// Span kinds as defined by OTLP.
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+)
+
+// NewTracer for the OTLP/HTTP endpoint of a collector (e.g. http://localhost:4318).
+func NewTracer(endpoint string, headers map[string]string) *Tracer {
+	return &Tracer{
+		endpoint: strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		headers:  headers,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		traceID:  randomID(16),
+	}
+}
+
+// NewTracerFromSource returns the tracer configured in the source, or nil if tracing is disabled.
+func NewTracerFromSource(s *Source) *Tracer {
+	if s.OTLPEndpoint == "" {
+		return nil
+	}
+	return NewTracer(s.OTLPEndpoint, s.OTLPHeaders)
+}
+
+var tracer *Tracer
+
+// SetTracer sets the tracer used by the resource. Tracing is disabled if it is nil.
+func SetTracer(t *Tracer) {
+	tracer = t
+}
+
+// StartSpan starts a span using the tracer set by SetTracer. Attributes are
+// given as key/value pairs. The returned span is nil if tracing is disabled.
+func StartSpan(name string, attributes ...interface{}) *Span {
+	return tracer.start(name, spanKindInternal, attributes...)
+}
+
+// FlushTraces exports the ended spans of the tracer set by SetTracer.
+func FlushTraces() error {
+	return tracer.Flush()
+}
+
+func (t *Tracer) start(name string, kind int, attributes ...interface{}) *Span {
+	if t == nil {
+		return nil
+	}
+	s := &Span{
+		tracer:     t,
+		id:         randomID(8),
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: make(map[string]string),
+	}
+	for i := 0; i+1 < len(attributes); i += 2 {
+		s.SetAttribute(fmt.Sprint(attributes[i]), attributes[i+1])
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.root == nil {
+		t.root = s
+	} else {
+		s.parentID = t.root.id
+	}
+	return s
+}
+
+// SetAttribute on the span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.attributes[key] = fmt.Sprint(value)
+}
+
+// End the span, marking it as failed if err is not nil.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.end = time.Now()
+	s.err = err
+	s.tracer.spans = append(s.tracer.spans, s)
+}
+
+// Flush exports the spans which have ended since the last flush.
+func (t *Tracer) Flush() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	type keyValue struct {
+		Key   string `json:"key"`
+		Value struct {
+			StringValue string `json:"stringValue"`
+		} `json:"value"`
+	}
+	attributes := func(m map[string]string) []keyValue {
+		var kv []keyValue
+		for k, v := range m {
+			a := keyValue{Key: k}
+			a.Value.StringValue = v
+			kv = append(kv, a)
+		}
+		return kv
+	}
+
+	type status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	type span struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              int        `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []keyValue `json:"attributes,omitempty"`
+		Status            status     `json:"status"`
+	}
+
+	var out []span
+	for _, s := range spans {
+		o := span{
+			TraceID:           t.traceID,
+			SpanID:            s.id,
+			ParentSpanID:      s.parentID,
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        attributes(s.attributes),
+			Status:            status{Code: 1},
+		}
+		if s.err != nil {
+			o.Status = status{Code: 2, Message: s.err.Error()}
+		}
+		out = append(out, o)
+	}
+
+	body := map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": attributes(map[string]string{"service.name": "github-pr-resource"}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]string{"name": "github.com/telia-oss/github-pr-resource"},
+						"spans": out,
+					},
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to export spans: %s", resp.Status)
+	}
+	return nil
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
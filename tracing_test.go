@@ -0,0 +1,74 @@
+package resource_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	resource "github.com/telia-oss/github-pr-resource"
+)
+
+func TestTracer(t *testing.T) {
+	type span struct {
+		TraceID      string `json:"traceId"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+		Status       struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"status"`
+	}
+	var payload struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []span `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+
+	var path, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer server.Close()
+
+	resource.SetTracer(resource.NewTracer(server.URL, map[string]string{"Authorization": "Bearer secret"}))
+	defer resource.SetTracer(nil)
+
+	root := resource.StartSpan("check", "repository", "itsdalmo/test-repository")
+	child := resource.StartSpan("git fetch")
+	child.End(errors.New("exit status 128"))
+	root.End(nil)
+	require.NoError(t, resource.FlushTraces())
+
+	assert.Equal(t, "/v1/traces", path)
+	assert.Equal(t, "Bearer secret", auth)
+	require.Len(t, payload.ResourceSpans, 1)
+	require.Len(t, payload.ResourceSpans[0].ScopeSpans, 1)
+
+	spans := payload.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+	assert.Equal(t, "git fetch", spans[0].Name)
+	assert.Equal(t, 2, spans[0].Status.Code)
+	assert.Equal(t, "exit status 128", spans[0].Status.Message)
+	assert.Equal(t, "check", spans[1].Name)
+	assert.Equal(t, 1, spans[1].Status.Code)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, spans[1].TraceID, spans[0].TraceID)
+	assert.Empty(t, spans[1].ParentSpanID)
+}
+
+func TestTracerDisabled(t *testing.T) {
+	resource.SetTracer(nil)
+	span := resource.StartSpan("check")
+	assert.Nil(t, span)
+	span.SetAttribute("key", "value")
+	span.End(nil)
+	assert.NoError(t, resource.FlushTraces())
+}
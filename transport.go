@@ -0,0 +1,196 @@
+package resource
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// loggingTransport logs and traces every request made to the Github APIs, and optionally
+// dumps the request and response bodies (GraphQL queries, variables and raw responses).
+type loggingTransport struct {
+	base       http.RoundTripper
+	dumpBodies bool
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.dumpBodies && req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		logger.Debug("api request body", "method", req.Method, "url", req.URL.String(), "body", string(body))
+	}
+
+	span := tracer.start("github "+req.Method+" "+req.URL.Path, spanKindClient, "http.method", req.Method, "http.url", req.URL.String())
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		logger.Debug("api request failed", "method", req.Method, "url", req.URL.String(), "duration", time.Since(start), "error", err)
+		span.End(err)
+		return nil, err
+	}
+	logger.Debug("api request", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode, "duration", time.Since(start))
+	span.SetAttribute("http.status_code", resp.StatusCode)
+	if resp.StatusCode >= 400 {
+		span.End(errors.New(resp.Status))
+	} else {
+		span.End(nil)
+	}
+
+	if t.dumpBodies && resp.Body != nil {
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		logger.Debug("api response body", "url", req.URL.String(), "body", string(body))
+	}
+	return resp, nil
+}
+
+// ErrAPIBudgetExhausted is returned for requests made after the API budget is spent.
+var ErrAPIBudgetExhausted = errors.New("api budget exhausted")
+
+// budgetTransport fails requests once a budget of requests has been made (if any).
+type budgetTransport struct {
+	base http.RoundTripper
+
+	mu     sync.Mutex
+	budget int
+	calls  int
+}
+
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	if t.budget > 0 && t.calls >= t.budget {
+		t.mu.Unlock()
+		return nil, ErrAPIBudgetExhausted
+	}
+	t.calls++
+	t.mu.Unlock()
+	return t.base.RoundTrip(req)
+}
+
+// tokenRotation is a token source for an access token and the tokens to fall back to.
+type tokenRotation struct {
+	mu      sync.Mutex
+	tokens  []string
+	current int
+}
+
+// Token returns the token currently in use.
+func (r *tokenRotation) Token() (*oauth2.Token, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &oauth2.Token{AccessToken: r.tokens[r.current]}, nil
+}
+
+func (r *tokenRotation) index() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// rotate away from the token with the given index (unless a concurrent request already
+// did), and return false if there is no token left to fall back to.
+func (r *tokenRotation) rotate(i int, reason string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current == i && r.current < len(r.tokens)-1 {
+		r.current++
+		logger.Warn("falling back to the next access token", "reason", reason, "token", r.current+1, "tokens", len(r.tokens))
+	}
+	return r.current != i
+}
+
+// rotatingTransport retries requests with the next access token of the rotation when the
+// current token is rate limited or rejected as invalid.
+type rotatingTransport struct {
+	base   http.RoundTripper
+	tokens *tokenRotation
+}
+
+func (t *rotatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		i := t.tokens.index()
+		r := req.Clone(req.Context())
+		if body != nil {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := t.base.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		reason, err := tokenExhausted(resp)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" || !t.tokens.rotate(i, reason) {
+			return resp, nil
+		}
+		resp.Body.Close()
+	}
+}
+
+// tokenExhausted returns why the token used for a request cannot be used any more (i.e.
+// it is invalid or rate limited), or an empty string if it can.
+func tokenExhausted(resp *http.Response) (string, error) {
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "unauthorized", nil
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return "", nil
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		return "rate limited", nil
+	}
+
+	// The GraphQL API reports exceeded rate limits as errors of a successful response.
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if bytes.Contains(b, []byte(`"RATE_LIMITED"`)) {
+		return "rate limited", nil
+	}
+	return "", nil
+}
+
+// acceptTransport adds media types to the Accept header of requests, e.g. to enable
+// preview APIs.
+type acceptTransport struct {
+	base   http.RoundTripper
+	accept []string
+}
+
+func (t *acceptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	accept := t.accept
+	if h := r.Header.Get("Accept"); h != "" {
+		accept = append([]string{h}, accept...)
+	}
+	r.Header.Set("Accept", strings.Join(accept, ", "))
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,86 @@
+package resource
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TriggerConfig is the trigger policy of a repository, read from the file configured
+// by trigger_config on the base branch of each pull request. Set fields override the
+// corresponding fields of the source.
+type TriggerConfig struct {
+	Paths           []string `yaml:"paths"`
+	IgnorePaths     []string `yaml:"ignore_paths"`
+	PathsChangeType []string `yaml:"paths_changetype"`
+	Labels          []string `yaml:"labels"`
+	IgnoreDrafts    *bool    `yaml:"ignore_drafts"`
+	DisableCISkip   *bool    `yaml:"disable_ci_skip"`
+}
+
+// ParseTriggerConfig parses a trigger configuration, which is written as YAML
+// (or JSON, which is also valid YAML). Unknown fields are rejected.
+func ParseTriggerConfig(b []byte) (*TriggerConfig, error) {
+	var c TriggerConfig
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Apply the configuration to a source.
+func (c *TriggerConfig) Apply(s Source) Source {
+	if c.Paths != nil {
+		s.Paths = c.Paths
+	}
+	if c.IgnorePaths != nil {
+		s.IgnorePaths = c.IgnorePaths
+	}
+	if c.PathsChangeType != nil {
+		s.PathsChangeType = c.PathsChangeType
+	}
+	if c.Labels != nil {
+		s.Labels = c.Labels
+	}
+	if c.IgnoreDrafts != nil {
+		s.IgnoreDrafts = *c.IgnoreDrafts
+	}
+	if c.DisableCISkip != nil {
+		s.DisableCISkip = *c.DisableCISkip
+	}
+	return s
+}
+
+// triggerSources applies the trigger configuration of base branches to the source,
+// fetching the configuration once per branch.
+type triggerSources struct {
+	manager Github
+	source  Source
+	sources map[string]Source
+}
+
+func (t *triggerSources) get(branch string) (Source, error) {
+	if t.source.TriggerConfig == "" {
+		return t.source, nil
+	}
+	if s, ok := t.sources[branch]; ok {
+		return s, nil
+	}
+
+	s := t.source
+	b, err := t.manager.GetFileContents(t.source.TriggerConfig, branch)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to get trigger config from %s: %w", branch, err)
+	}
+	if b != nil {
+		c, err := ParseTriggerConfig(b)
+		if err != nil {
+			return Source{}, fmt.Errorf("failed to parse trigger config from %s: %s", branch, err)
+		}
+		s = c.Apply(s)
+	} else {
+		logger.Debug("trigger config does not exist", "path", t.source.TriggerConfig, "branch", branch)
+	}
+	t.sources[branch] = s
+	return s, nil
+}
@@ -0,0 +1,135 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v28/github"
+)
+
+// ValidateConfig reads a source configuration (or a request containing one) and
+// validates it, including the credentials and repository. It is meant to be used
+// when setting up pipelines, and returns an error listing all problems found.
+func ValidateConfig(r io.Reader) error {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var request struct {
+		Source *json.RawMessage `json:"source"`
+	}
+	if err := json.Unmarshal(content, &request); err != nil {
+		return fmt.Errorf("failed to unmarshal configuration: %s", err)
+	}
+	if request.Source != nil {
+		content = *request.Source
+	}
+
+	var source Source
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&source); err != nil {
+		return fmt.Errorf("failed to unmarshal source: %s", err)
+	}
+	return ValidateSource(&source)
+}
+
+// ValidateSource validates the source configuration, the syntax of its filters,
+// and that the access token can be used to access the repository.
+func ValidateSource(s *Source) error {
+	var problems []string
+
+	for _, pattern := range append(append(append([]string{}, s.Paths...), s.IgnorePaths...), s.RequirePaths...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			problems = append(problems, fmt.Sprintf("path pattern '%s' is invalid: %s", pattern, err))
+		}
+	}
+
+	// The API can only be used with a valid source.
+	if err := s.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	} else if err := s.ResolveAccessToken(); err != nil {
+		problems = append(problems, err.Error())
+	} else {
+		manager, err := NewGithubClient(s)
+		if err != nil {
+			return err
+		}
+		if _, err := manager.verifyRepository(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// verifyRepository checks that the repository exists and that a classic access
+// token has the scopes required to read it, returning the repository.
+func (m *GithubClient) verifyRepository() (*github.Repository, error) {
+	ctx, cancel := m.context()
+	defer cancel()
+
+	repo, response, err := m.V3.Repositories.Get(ctx, m.Owner, m.Repository)
+	if err != nil {
+		if response != nil {
+			switch response.StatusCode {
+			case http.StatusUnauthorized:
+				return nil, errors.New("access token is invalid or has expired")
+			case http.StatusNotFound:
+				return nil, fmt.Errorf("repository %s/%s does not exist, or the access token cannot access it", m.Owner, m.Repository)
+			}
+		}
+		return nil, fmt.Errorf("failed to get repository: %s", err)
+	}
+
+	// Only classic tokens list their scopes.
+	if header := response.Header.Get("X-OAuth-Scopes"); header != "" {
+		scopes := strings.Split(header, ",")
+		for i := range scopes {
+			scopes[i] = strings.TrimSpace(scopes[i])
+		}
+		if !containsString(scopes, "repo") && (repo.GetPrivate() || !containsString(scopes, "public_repo")) {
+			return nil, fmt.Errorf("access token is missing the 'repo' scope (has: %s)", header)
+		}
+	}
+	return repo, nil
+}
+
+// VerifyPermissions checks that the access token can read the repository, and
+// optionally that it can write statuses and comments, so that missing permissions
+// are reported up front instead of as cryptic 404s later.
+func (m *GithubClient) VerifyPermissions(write bool) error {
+	repo, err := m.verifyRepository()
+	if err != nil {
+		return err
+	}
+
+	// Permissions are not listed for some tokens (e.g. Github Apps).
+	permissions := repo.GetPermissions()
+	if permissions == nil {
+		return nil
+	}
+
+	var missing []string
+	if !permissions["pull"] {
+		missing = append(missing, "read the repository")
+	}
+	if write && !permissions["push"] {
+		missing = append(missing, "write commit statuses and comments")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("access token is not allowed to %s in %s/%s", strings.Join(missing, " or "), m.Owner, m.Repository)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package resource_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	resource "github.com/telia-oss/github-pr-resource"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		description string
+		config      string
+		status      int
+		scopes      string
+		private     bool
+		wantErr     []string
+	}{
+		{
+			description: "accepts a valid source",
+			config:      `{"repository": "itsdalmo/test-repository", "access_token": "oauthtoken"}`,
+			status:      http.StatusOK,
+			scopes:      "repo, read:org",
+		},
+		{
+			description: "accepts a request containing a source",
+			config:      `{"source": {"repository": "itsdalmo/test-repository", "access_token": "oauthtoken"}}`,
+			status:      http.StatusOK,
+		},
+		{
+			description: "accepts the public_repo scope for public repositories",
+			config:      `{"repository": "itsdalmo/test-repository", "access_token": "oauthtoken"}`,
+			status:      http.StatusOK,
+			scopes:      "public_repo",
+		},
+		{
+			description: "requires the repo scope for private repositories",
+			config:      `{"repository": "itsdalmo/test-repository", "access_token": "oauthtoken"}`,
+			status:      http.StatusOK,
+			scopes:      "public_repo",
+			private:     true,
+			wantErr:     []string{"access token is missing the 'repo' scope"},
+		},
+		{
+			description: "reports invalid credentials",
+			config:      `{"repository": "itsdalmo/test-repository", "access_token": "oauthtoken"}`,
+			status:      http.StatusUnauthorized,
+			wantErr:     []string{"access token is invalid or has expired"},
+		},
+		{
+			description: "reports missing repositories",
+			config:      `{"repository": "itsdalmo/test-repository", "access_token": "oauthtoken"}`,
+			status:      http.StatusNotFound,
+			wantErr:     []string{"repository itsdalmo/test-repository does not exist"},
+		},
+		{
+			description: "reports every invalid path pattern",
+			config:      `{"repository": "itsdalmo/test-repository", "access_token": "oauthtoken", "paths": ["[a-"], "ignore_paths": ["b\\"]}`,
+			status:      http.StatusOK,
+			wantErr:     []string{"path pattern '[a-' is invalid", "path pattern 'b\\' is invalid"},
+		},
+		{
+			description: "reports unknown fields",
+			config:      `{"repository": "itsdalmo/test-repository", "access_token": "oauthtoken", "ignore_pathes": ["*.md"]}`,
+			wantErr:     []string{`unknown field "ignore_pathes"`},
+		},
+		{
+			description: "reports invalid configuration",
+			config:      `{"repository": "itsdalmo/test-repository"}`,
+			wantErr:     []string{"access_token must be set"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.scopes != "" {
+					w.Header().Set("X-OAuth-Scopes", tc.scopes)
+				}
+				w.WriteHeader(tc.status)
+				if tc.private {
+					w.Write([]byte(`{"private": true}`))
+				} else {
+					w.Write([]byte(`{}`))
+				}
+			}))
+			defer server.Close()
+
+			// Point the source at the test server.
+			config := strings.Replace(tc.config, `"repository"`, `"v3_endpoint": "`+server.URL+`/", "v4_endpoint": "`+server.URL+`/graphql", "repository"`, 1)
+
+			err := resource.ValidateConfig(strings.NewReader(config))
+			if len(tc.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				for _, e := range tc.wantErr {
+					assert.Contains(t, err.Error(), e)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyPermissions(t *testing.T) {
+	tests := []struct {
+		description string
+		permissions string
+		write       bool
+		wantErr     string
+	}{
+		{
+			description: "read access is enough for check and get",
+			permissions: `{"pull": true, "push": false}`,
+		},
+		{
+			description: "write access is required for put",
+			permissions: `{"pull": true, "push": false}`,
+			write:       true,
+			wantErr:     "access token is not allowed to write commit statuses and comments in itsdalmo/test-repository",
+		},
+		{
+			description: "lists all missing permissions",
+			permissions: `{"pull": false, "push": false}`,
+			write:       true,
+			wantErr:     "access token is not allowed to read the repository or write commit statuses and comments in itsdalmo/test-repository",
+		},
+		{
+			description: "accepts write access",
+			permissions: `{"pull": true, "push": true}`,
+			write:       true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"permissions": ` + tc.permissions + `}`))
+			}))
+			defer server.Close()
+
+			github, err := resource.NewGithubClient(&resource.Source{
+				Repository:  "itsdalmo/test-repository",
+				AccessToken: "oauthtoken",
+				V3Endpoint:  server.URL + "/",
+				V4Endpoint:  server.URL + "/graphql",
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			err = github.VerifyPermissions(tc.write)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}